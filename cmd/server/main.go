@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"fmt"
 	"log"
+	"net"
 	"os"
+	"strconv"
+	"strings"
 	"time"
+	_ "time/tzdata" // fusos horários disponíveis mesmo em imagens sem zoneinfo
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -12,14 +18,34 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 
+	"my-crm-backend/internal/anexo"
 	"my-crm-backend/internal/anotacao"
+	"my-crm-backend/internal/auditoria"
+	"my-crm-backend/internal/automacao"
+	"my-crm-backend/internal/calendario"
+	"my-crm-backend/internal/campopersonalizado"
+	"my-crm-backend/internal/captacao"
 	"my-crm-backend/internal/cliente"
+	"my-crm-backend/internal/consolidado"
 	"my-crm-backend/internal/contato"
+	"my-crm-backend/internal/distribuicao"
+	"my-crm-backend/internal/duplicidade"
 	"my-crm-backend/internal/empresa"
+	"my-crm-backend/internal/endereco"
+	"my-crm-backend/internal/enriquecimento"
+	"my-crm-backend/internal/escalonamento"
+	"my-crm-backend/internal/evento"
+	"my-crm-backend/internal/grupoeconomico"
 	"my-crm-backend/internal/historicoetapa"
 	"my-crm-backend/internal/negociacao"
+	"my-crm-backend/internal/notificacao"
+	"my-crm-backend/internal/playbook"
+	"my-crm-backend/internal/pontuacao"
 	"my-crm-backend/internal/quiver"
 	"my-crm-backend/internal/tarefa"
+	"my-crm-backend/internal/timeline"
+	"my-crm-backend/internal/usuario"
+	"my-crm-backend/internal/webhook"
 )
 
 func main() {
@@ -50,7 +76,9 @@ func main() {
 	}
 
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s", host, user, password, dbname, port, sslmode)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// TranslateError converte as violações de restrição do banco nos erros do
+	// GORM (ex: gorm.ErrDuplicatedKey), que os repositórios transformam em 409.
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatalf("Erro ao conectar com o banco de dados: %v", err)
 	}
@@ -59,41 +87,230 @@ func main() {
 		&cliente.Cliente{},
 		&empresa.Empresa{},
 		&contato.Contato{},
+		&contato.VinculoEmpresa{},
 		&negociacao.Negociacao{},
+		&negociacao.Participante{},
 		&tarefa.Tarefa{},
+		&tarefa.Ocorrencia{},
+		&tarefa.Escalonamento{},
+		&tarefa.Reagendamento{},
+		&escalonamento.Regra{},
+		&notificacao.Notificacao{},
+		&playbook.Playbook{},
+		&playbook.Modelo{},
 		&anotacao.Anotacao{},
+		&anotacao.Revisao{},
+		&anexo.Anexo{},
+		&anexo.Versao{},
 		&historicoetapa.HistoricoEtapa{},
 		&quiver.Quiver{},
+		&auditoria.Auditoria{},
+		&grupoeconomico.GrupoEconomico{},
+		&campopersonalizado.Definicao{},
+		&endereco.RegistroCEP{},
+		&usuario.Usuario{},
+		&evento.Evento{},
+		&evento.Consumo{},
+		&webhook.Webhook{},
+		&webhook.Entrega{},
+		&webhook.Tentativa{},
+		&automacao.Regra{},
+		&automacao.Execucao{},
+		&captacao.Formulario{},
+		&captacao.Lead{},
+		&distribuicao.Regra{},
+		&distribuicao.Atribuicao{},
+		&pontuacao.Regra{},
+		&pontuacao.Pontuacao{},
 	)
 	if err != nil {
 		log.Fatalf("Erro ao migrar o banco de dados: %v", err)
 	}
 
+	if err := cliente.MigrarEnderecoLegado(db); err != nil {
+		log.Fatalf("Erro ao migrar os endereços dos clientes: %v", err)
+	}
+
+	if err := campopersonalizado.MigrarIndiceChave(db); err != nil {
+		log.Fatalf("Erro ao migrar o índice dos campos personalizados: %v", err)
+	}
+
+	if err := grupoeconomico.MigrarGruposLegados(db); err != nil {
+		log.Fatalf("Erro ao migrar os grupos econômicos: %v", err)
+	}
+	if err := grupoeconomico.MigrarIndiceNome(db); err != nil {
+		log.Fatalf("Erro ao migrar o índice dos grupos econômicos: %v", err)
+	}
+
+	if err := negociacao.MigrarParticipantes(db); err != nil {
+		log.Fatalf("Erro ao migrar os participantes das negociações: %v", err)
+	}
+
+	convertidos, invalidos, err := contato.MigrarTelefones(db)
+	if err != nil {
+		log.Fatalf("Erro ao normalizar os telefones dos contatos: %v", err)
+	}
+	if convertidos > 0 || len(invalidos) > 0 {
+		log.Printf("Telefones de contatos: %d normalizados, contatos com números inválidos: %v", convertidos, invalidos)
+	}
+
+	fusoPadrao, err := usuario.CarregarFuso("")
+	if err != nil {
+		log.Fatalf("Erro ao carregar o fuso horário padrão: %v", err)
+	}
+	migradas, horariosInvalidos, err := tarefa.MigrarAgendamentoLegado(db, fusoPadrao)
+	if err != nil {
+		log.Fatalf("Erro ao migrar o agendamento das tarefas: %v", err)
+	}
+	if migradas > 0 {
+		log.Printf("Agendamento de tarefas: %d migradas, tarefas com horário inválido (agora dia inteiro): %v", migradas, horariosInvalidos)
+	}
+
+	if err := tarefa.MigrarStatus(db); err != nil {
+		log.Fatalf("Erro ao migrar a situação das tarefas: %v", err)
+	}
+	if err := tarefa.MigrarIndiceEscalonamentos(db); err != nil {
+		log.Fatalf("Erro ao migrar o índice dos escalonamentos: %v", err)
+	}
+
+	if err := anotacao.MigrarEntidades(db); err != nil {
+		log.Fatalf("Erro ao migrar o vínculo das anotações: %v", err)
+	}
+	if renderizadas, err := anotacao.MigrarHTML(db); err != nil {
+		log.Fatalf("Erro ao renderizar as anotações: %v", err)
+	} else if renderizadas > 0 {
+		log.Printf("Anotações: %d renderizadas em HTML", renderizadas)
+	}
+
+	if _, err := negociacao.SincronizarTarefas(db); err != nil {
+		log.Fatalf("Erro ao sincronizar as tarefas das negociações: %v", err)
+	}
+
 	r := gin.Default()
 
+	// Só os proxies listados em PROXIES_CONFIAVEIS (separados por vírgula) podem
+	// informar o IP do cliente em X-Forwarded-For; sem a lista, vale o IP da
+	// conexão. O limite de envios da captação de leads depende disso.
+	var proxies []string
+	if v := os.Getenv("PROXIES_CONFIAVEIS"); v != "" {
+		for _, p := range strings.Split(v, ",") {
+			proxies = append(proxies, strings.TrimSpace(p))
+		}
+	}
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		log.Fatalf("PROXIES_CONFIAVEIS inválido: %v", err)
+	}
+
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", captacao.CabecalhoChave},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
+	enderecoRepo := endereco.NovoRepositorio(db)
+	enderecoHandler := endereco.NovoHandler(enderecoRepo)
+
+	// Carrega a base local de CEPs informada em CEP_DATASET (CSV: cep;logradouro;bairro;cidade;uf).
+	if arquivo := os.Getenv("CEP_DATASET"); arquivo != "" {
+		f, err := os.Open(arquivo)
+		if err != nil {
+			log.Fatalf("Erro ao abrir a base de CEPs: %v", err)
+		}
+		total, err := enderecoRepo.Importar(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Erro ao importar a base de CEPs: %v", err)
+		}
+		log.Printf("Base de CEPs carregada: %d registros", total)
+	}
+
+	usuarioRepo := usuario.NovoRepositorio(db)
+	usuarioHandler := usuario.NovoHandler(usuarioRepo)
+
+	camposRepo := campopersonalizado.NovoRepositorio(db)
+	camposHandler := campopersonalizado.NovoHandler(camposRepo)
+
 	clienteRepo := cliente.NovoRepositorio(db)
-	clienteHandler := cliente.NovoHandler(clienteRepo)
+	clienteHandler := cliente.NovoHandler(clienteRepo, enderecoRepo)
 
 	contatoRepo := contato.NovoRepositorio(db)
-	contatoHandler := contato.NovoHandler(contatoRepo)
+	contatoHandler := contato.NovoHandler(contatoRepo, camposRepo)
 
 	empresaRepo := empresa.NovoRepositorio(db)
-	empresaHandler := empresa.NovoHandler(empresaRepo)
+	empresaHandler := empresa.NovoHandler(empresaRepo, camposRepo, enderecoRepo)
+
+	playbookRepo := playbook.NovoRepositorio(db)
+	playbookHandler := playbook.NovoHandler(playbookRepo)
 
-	negociacaoRepo := negociacao.NovoRepositorio(db)
-	negociacaoHandler := negociacao.NovoHandler(negociacaoRepo)
+	negociacaoRepo := negociacao.NovoRepositorio(db, playbook.NovoGatilho(usuarioRepo))
+	negociacaoHandler := negociacao.NovoHandler(negociacaoRepo, camposRepo, usuarioRepo)
 
 	tarefaRepo := tarefa.NovoRepositorio(db)
-	tarefaHandler := tarefa.NovoHandler(tarefaRepo)
+	tarefaHandler := tarefa.NovoHandler(tarefaRepo, usuarioRepo, negociacaoRepo)
+
+	calendarioHandler := calendario.NovoHandler(usuarioRepo, tarefaRepo)
+
+	notificacaoRepo := notificacao.NovoRepositorio(db)
+	notificacaoHandler := notificacao.NovoHandler(notificacaoRepo)
+
+	// Verificação periódica de tarefas atrasadas e escalonamentos.
+	verificador := escalonamento.NovoVerificador(db, tarefaRepo, usuarioRepo)
+	go verificador.Executar(context.Background(), lerIntervalo("ESCALONAMENTO_INTERVALO", 5*time.Minute))
+	escalonamentoRepo := escalonamento.NovoRepositorio(db)
+	escalonamentoHandler := escalonamento.NovoHandler(escalonamentoRepo, verificador)
+
+	// Envio das entregas de webhooks pendentes.
+	// As entregas de webhooks recusam destinos na rede interna, salvo os das
+	// redes listadas em WEBHOOKS_REDES_PERMITIDAS (CIDRs separados por vírgula).
+	var redesWebhook []*net.IPNet
+	if v := os.Getenv("WEBHOOKS_REDES_PERMITIDAS"); v != "" {
+		for _, cidr := range strings.Split(v, ",") {
+			_, rede, err := net.ParseCIDR(strings.TrimSpace(cidr))
+			if err != nil {
+				log.Fatalf("WEBHOOKS_REDES_PERMITIDAS inválido: %v", err)
+			}
+			redesWebhook = append(redesWebhook, rede)
+		}
+	}
+	entregador := webhook.NovoEntregador(db, webhook.Config{RedesPermitidas: redesWebhook})
+	go entregador.Executar(context.Background(), lerIntervalo("WEBHOOKS_INTERVALO", 15*time.Second))
+	webhookHandler := webhook.NovoHandler(webhook.NovoRepositorio(db), entregador)
+
+	// Barramento de eventos de domínio: entrega aos assinantes o que os
+	// repositórios publicam na caixa de saída. Mudanças de status e valores da
+	// negociação já são auditadas na própria operação, e as de etapa ficam no
+	// histórico de etapas.
+	barramento := evento.NovoBarramento(db, 0)
+	barramento.Assinar("webhooks", webhook.Disparar)
+	barramento.Assinar("auditoria", auditoria.RegistrarEvento,
+		evento.EmpresaCriada, evento.NegociacaoCriada, evento.TarefaCriada, evento.TarefaConcluida)
+	barramento.Assinar("notificacoes", negociacao.NotificarEncerramento,
+		evento.NegociacaoGanha, evento.NegociacaoPerdida)
+
+	// Regras de automação: reagem aos eventos de domínio e, periodicamente, às
+	// apólices perto do vencimento.
+	motor := automacao.NovoMotor(db, usuarioRepo)
+	barramento.Assinar("automacoes", motor.Tratar, automacao.Eventos...)
+	go motor.Executar(context.Background(), lerIntervalo("AUTOMACOES_INTERVALO", time.Hour))
+	automacaoHandler := automacao.NovoHandler(automacao.NovoRepositorio(db), motor)
+
+	// Distribuição de negociações: os leads dos formulários recebem o responsável
+	// na criação; as demais negociações sem responsável, pelo barramento.
+	distribuidor := distribuicao.NovoDistribuidor(db)
+	barramento.Assinar("distribuicao", distribuidor.Tratar, evento.NegociacaoCriada)
+	distribuicaoHandler := distribuicao.NovoHandler(distribuicao.NovoRepositorio(db), distribuidor)
+
+	// Pontuação de leads: recalculada a cada evento relevante e, periodicamente,
+	// para expirar a atividade recente.
+	calculadora := pontuacao.NovaCalculadora(db)
+	barramento.Assinar("pontuacao", calculadora.Tratar, pontuacao.Eventos...)
+	go calculadora.Executar(context.Background(), lerIntervalo("PONTUACAO_INTERVALO", 6*time.Hour))
+	pontuacaoHandler := pontuacao.NovoHandler(pontuacao.NovoRepositorio(db), calculadora)
+	go barramento.Executar(context.Background(), lerIntervalo("EVENTOS_INTERVALO", 2*time.Second))
+	eventoHandler := evento.NovoHandler(evento.NovoRepositorio(db))
 
 	historicoRepo := historicoetapa.NovoRepositorio(db)
 	historicoHandler := historicoetapa.NovoHandler(historicoRepo)
@@ -101,11 +318,62 @@ func main() {
 	anotacaoRepo := anotacao.NovoRepositorio(db)
 	anotacaoHandler := anotacao.NovoHandler(anotacaoRepo)
 
+	armazenamento, configAnexos := configurarAnexos()
+	anexoRepo := anexo.NovoRepositorio(db, armazenamento)
+	anexoHandler := anexo.NovoHandler(anexoRepo, armazenamento, configAnexos)
+
+	limiteCaptacao := 0
+	if v := os.Getenv("CAPTACAO_LIMITE_POR_MINUTO"); v != "" {
+		if limiteCaptacao, err = strconv.Atoi(v); err != nil || limiteCaptacao <= 0 {
+			log.Fatalf("CAPTACAO_LIMITE_POR_MINUTO inválido: %q", v)
+		}
+	}
+	captacaoHandler := captacao.NovoHandler(captacao.NovoRepositorio(db, distribuidor), limiteCaptacao)
+
+	timelineHandler := timeline.NovoHandler(timeline.NovoRepositorio(db))
+
 	quiverRepo := quiver.NovoRepositorio(db)
 	quiverHandler := quiver.NovoHandler(quiverRepo)
 
+	auditoriaRepo := auditoria.NovoRepositorio(db)
+	auditoriaHandler := auditoria.NovoHandler(auditoriaRepo)
+
+	duplicidadeRepo := duplicidade.NovoRepositorio(db)
+	duplicidadeHandler := duplicidade.NovoHandler(duplicidadeRepo)
+
+	// Provedores de enriquecimento de empresas. Provedores reais podem ser registrados aqui.
+	provedores := enriquecimento.NovoRegistro()
+	if arquivo := os.Getenv("ENRIQUECIMENTO_ARQUIVO"); arquivo != "" {
+		provedor, err := enriquecimento.NovoProvedorArquivo("arquivo", arquivo)
+		if err != nil {
+			log.Fatalf("Erro ao carregar o provedor de enriquecimento: %v", err)
+		}
+		provedores.Registrar(provedor)
+	}
+	enriquecimentoHandler := enriquecimento.NovoHandler(provedores, empresaRepo, enderecoRepo)
+
+	grupoRepo := grupoeconomico.NovoRepositorio(db)
+	grupoHandler := grupoeconomico.NovoHandler(grupoRepo)
+
+	consolidadoRepo := consolidado.NovoRepositorio(db)
+	consolidadoHandler := consolidado.NovoHandler(consolidadoRepo)
+
 	api := r.Group("/api")
 	{
+		// Recebe os formulários dos sites; não exige autenticação, apenas a chave do formulário.
+		api.POST("/public/leads", captacaoHandler.Receber)
+
+		formularios := api.Group("/formularios")
+		{
+			formularios.POST("", captacaoHandler.Criar)
+			formularios.GET("", captacaoHandler.Listar)
+			formularios.GET(":id", captacaoHandler.Obter)
+			formularios.PUT(":id", captacaoHandler.Atualizar)
+			formularios.DELETE(":id", captacaoHandler.Deletar)
+			formularios.POST(":id/chave", captacaoHandler.GerarChave)
+			formularios.GET(":id/leads", captacaoHandler.ListarLeads)
+		}
+
 		api.POST("/clientes", clienteHandler.CriarCliente)
 		api.GET("/clientes", clienteHandler.ListarClientes)
 		api.GET("/clientes/:id", clienteHandler.ObterCliente)
@@ -117,19 +385,138 @@ func main() {
 		api.GET("/contatos/:id", contatoHandler.ObterContato)
 		api.PUT("/contatos/:id", contatoHandler.AtualizarContato)
 		api.DELETE("/contatos/:id", contatoHandler.DeletarContato)
+		api.POST("/contatos/:id/vinculos", contatoHandler.AdicionarVinculo)
+		api.PUT("/contatos/:id/vinculos/:vinculoId", contatoHandler.AtualizarVinculo)
+		api.DELETE("/contatos/:id/vinculos/:vinculoId", contatoHandler.RemoverVinculo)
+		api.POST("/contatos/migracao-empresas", contatoHandler.MigrarEmpresasLegadas)
+		api.GET("/contatos/:id/anotacoes", anotacaoHandler.ListarDaEntidade(anotacao.EntidadeContato))
+		api.POST("/contatos/:id/anotacoes", anotacaoHandler.CriarNaEntidade(anotacao.EntidadeContato))
+		api.GET("/contatos/:id/anexos", anexoHandler.ListarDaEntidade(anexo.EntidadeContato))
+		api.POST("/contatos/:id/anexos", anexoHandler.EnviarNaEntidade(anexo.EntidadeContato))
 
 		api.POST("/empresas", empresaHandler.CriarEmpresa)
 		api.GET("/empresas", empresaHandler.ListarEmpresas)
 		api.GET("/empresas/:id", empresaHandler.ObterEmpresa)
 		api.PUT("/empresas/:id", empresaHandler.AtualizarEmpresa)
 		api.DELETE("/empresas/:id", empresaHandler.DeletarEmpresa)
+		api.GET("/empresas/:id/anotacoes", anotacaoHandler.ListarDaEntidade(anotacao.EntidadeEmpresa))
 		api.POST("/empresas/:id/anotacoes", empresaHandler.AdicionarAnotacao)
+		api.GET("/empresas/:id/anexos", anexoHandler.ListarDaEntidade(anexo.EntidadeEmpresa))
+		api.POST("/empresas/:id/anexos", anexoHandler.EnviarNaEntidade(anexo.EntidadeEmpresa))
+		api.GET("/empresas/:id/timeline", timelineHandler.DaEmpresa)
+		api.GET("/empresas/:id/pontuacao", pontuacaoHandler.DaEntidade(pontuacao.EntidadeEmpresa))
+		api.POST("/empresas/:id/pontuacao", pontuacaoHandler.RecalcularDaEntidade(pontuacao.EntidadeEmpresa))
+		api.GET("/empresas/:id/filiais", empresaHandler.ListarFiliais)
+		api.PUT("/empresas/:id/matriz", empresaHandler.DefinirMatriz)
+		api.GET("/empresas/:id/consolidado", consolidadoHandler.PorMatriz)
+		api.GET("/empresas/:id/contatos", contatoHandler.ListarPorEmpresa)
+		api.GET("/empresas/:id/enriquecimento", enriquecimentoHandler.Propor)
+		api.POST("/empresas/:id/enriquecimento", enriquecimentoHandler.Aplicar)
+		api.GET("/enriquecimento/provedores", enriquecimentoHandler.ListarProvedores)
+
+		// Rotas para Grupos Econômicos
+		grupos := api.Group("/grupos")
+		{
+			grupos.POST("", grupoHandler.Criar)
+			grupos.GET("", grupoHandler.Listar)
+			grupos.GET(":id", grupoHandler.Obter)
+			grupos.PUT(":id", grupoHandler.Atualizar)
+			grupos.DELETE(":id", grupoHandler.Deletar)
+			grupos.POST(":id/empresas/:empresaId", grupoHandler.AdicionarEmpresa)
+			grupos.DELETE(":id/empresas/:empresaId", grupoHandler.RemoverEmpresa)
+			grupos.GET(":id/consolidado", consolidadoHandler.PorGrupo)
+		}
+
+		api.POST("/usuarios", usuarioHandler.Criar)
+		api.GET("/usuarios", usuarioHandler.Listar)
+		api.GET("/usuarios/:id", usuarioHandler.Obter)
+		api.PUT("/usuarios/:id", usuarioHandler.Atualizar)
+		api.DELETE("/usuarios/:id", usuarioHandler.Deletar)
+		api.POST("/usuarios/:id/calendario", usuarioHandler.GerarTokenCalendario)
+
+		api.GET("/notificacoes", notificacaoHandler.Listar)
+		api.PUT("/notificacoes/:id/lida", notificacaoHandler.MarcarLida)
+		api.POST("/notificacoes/lidas", notificacaoHandler.MarcarTodasLidas)
+
+		api.POST("/playbooks", playbookHandler.Criar)
+		api.GET("/playbooks", playbookHandler.Listar)
+		api.GET("/playbooks/:id", playbookHandler.Obter)
+		api.PUT("/playbooks/:id", playbookHandler.Atualizar)
+		api.DELETE("/playbooks/:id", playbookHandler.Deletar)
+
+		api.POST("/escalonamento/regras", escalonamentoHandler.Criar)
+		api.GET("/escalonamento/regras", escalonamentoHandler.Listar)
+		api.PUT("/escalonamento/regras/:id", escalonamentoHandler.Atualizar)
+		api.DELETE("/escalonamento/regras/:id", escalonamentoHandler.Deletar)
+		api.POST("/escalonamento/verificar", escalonamentoHandler.Verificar)
+
+		automacoes := api.Group("/automacoes")
+		{
+			automacoes.POST("", automacaoHandler.Criar)
+			automacoes.GET("", automacaoHandler.Listar)
+			automacoes.GET("/metadados", automacaoHandler.Metadados)
+			automacoes.GET("/execucoes", automacaoHandler.ListarExecucoes)
+			automacoes.POST("/verificar-vencimentos", automacaoHandler.VerificarVencimentos)
+			automacoes.GET(":id", automacaoHandler.Obter)
+			automacoes.PUT(":id", automacaoHandler.Atualizar)
+			automacoes.DELETE(":id", automacaoHandler.Deletar)
+			automacoes.PUT(":id/ativa", automacaoHandler.Ativar)
+			automacoes.GET(":id/execucoes", automacaoHandler.ListarExecucoes)
+		}
+
+		pontuacoes := api.Group("/pontuacao")
+		{
+			pontuacoes.GET("/metadados", pontuacaoHandler.Metadados)
+			pontuacoes.POST("/regras", pontuacaoHandler.Criar)
+			pontuacoes.GET("/regras", pontuacaoHandler.Listar)
+			pontuacoes.GET("/regras/:id", pontuacaoHandler.Obter)
+			pontuacoes.PUT("/regras/:id", pontuacaoHandler.Atualizar)
+			pontuacoes.DELETE("/regras/:id", pontuacaoHandler.Deletar)
+			pontuacoes.POST("/recalcular", pontuacaoHandler.Recalcular)
+		}
+
+		distribuicoes := api.Group("/distribuicao")
+		{
+			distribuicoes.POST("/regras", distribuicaoHandler.Criar)
+			distribuicoes.GET("/regras", distribuicaoHandler.Listar)
+			distribuicoes.GET("/regras/:id", distribuicaoHandler.Obter)
+			distribuicoes.PUT("/regras/:id", distribuicaoHandler.Atualizar)
+			distribuicoes.DELETE("/regras/:id", distribuicaoHandler.Deletar)
+			distribuicoes.GET("/atribuicoes", distribuicaoHandler.ListarAtribuicoes)
+			distribuicoes.POST("/redistribuir", distribuicaoHandler.Redistribuir)
+		}
+
+		api.GET("/eventos", eventoHandler.Listar)
+		api.GET("/eventos/tipos", eventoHandler.ListarTipos)
+		api.GET("/eventos/:id", eventoHandler.Obter)
+		api.POST("/eventos/:id/reprocessar", eventoHandler.Reprocessar)
+
+		api.GET("/webhooks/eventos", webhookHandler.ListarEventos)
+		api.POST("/webhooks", webhookHandler.Criar)
+		api.GET("/webhooks", webhookHandler.Listar)
+		api.GET("/webhooks/:id", webhookHandler.Obter)
+		api.PUT("/webhooks/:id", webhookHandler.Atualizar)
+		api.DELETE("/webhooks/:id", webhookHandler.Deletar)
+		api.POST("/webhooks/:id/testar", webhookHandler.Testar)
+		api.GET("/webhooks/:id/entregas", webhookHandler.ListarEntregas)
+		api.GET("/webhooks/:id/entregas/:entregaId", webhookHandler.ObterEntrega)
+		api.POST("/webhooks/:id/entregas/:entregaId/reenviar", webhookHandler.Reenviar)
+		api.GET("/calendario/:arquivo", calendarioHandler.Feed)
 
 		api.POST("/tarefas", tarefaHandler.CriarTarefa)
 		api.GET("/tarefas", tarefaHandler.ListarTarefas)
+		api.GET("/tarefas/atrasadas", tarefaHandler.ListarAtrasadas)
+		api.GET("/tarefas/relatorio", tarefaHandler.Relatorio)
 		api.GET("/tarefas/:id", tarefaHandler.ObterTarefa)
 		api.PUT("/tarefas/:id", tarefaHandler.AtualizarTarefa)
 		api.DELETE("/tarefas/:id", tarefaHandler.DeletarTarefa)
+		api.PUT("/tarefas/:id/status", tarefaHandler.AtualizarStatus)
+		api.POST("/tarefas/:id/reagendar", tarefaHandler.Reagendar)
+		api.GET("/tarefas/:id/ocorrencias", tarefaHandler.ListarOcorrencias)
+		api.PUT("/tarefas/:id/ocorrencias/:data", tarefaHandler.AtualizarOcorrencia)
+		api.DELETE("/tarefas/:id/ocorrencias/:data", tarefaHandler.ExcluirOcorrencia)
+		api.GET("/tarefas/:id/anotacoes", anotacaoHandler.ListarDaEntidade(anotacao.EntidadeTarefa))
+		api.POST("/tarefas/:id/anotacoes", anotacaoHandler.CriarNaEntidade(anotacao.EntidadeTarefa))
 
 		negociacoes := api.Group("/negociacoes")
 		{
@@ -142,6 +529,18 @@ func main() {
 			negociacoes.PUT(":id/status", negociacaoHandler.AtualizarStatusHandler)
 			negociacoes.PUT(":id/valores", negociacaoHandler.AtualizarValoresHandler)
 			negociacoes.GET(":id/historico-etapas", historicoHandler.ListarPorNegociacao)
+			negociacoes.GET(":id/participantes", negociacaoHandler.ListarParticipantesHandler)
+			negociacoes.POST(":id/participantes", negociacaoHandler.AdicionarParticipanteHandler)
+			negociacoes.DELETE(":id/participantes/:contatoId", negociacaoHandler.RemoverParticipanteHandler)
+			negociacoes.GET(":id/tarefas", negociacaoHandler.ListarTarefasHandler)
+			negociacoes.POST(":id/tarefas", negociacaoHandler.AdicionarTarefaHandler)
+			negociacoes.GET(":id/anotacoes", anotacaoHandler.ListarDaEntidade(anotacao.EntidadeNegociacao))
+			negociacoes.POST(":id/anotacoes", anotacaoHandler.CriarNaEntidade(anotacao.EntidadeNegociacao))
+			negociacoes.GET(":id/anexos", anexoHandler.ListarDaEntidade(anexo.EntidadeNegociacao))
+			negociacoes.POST(":id/anexos", anexoHandler.EnviarNaEntidade(anexo.EntidadeNegociacao))
+			negociacoes.GET(":id/timeline", timelineHandler.DaNegociacao)
+			negociacoes.GET(":id/pontuacao", pontuacaoHandler.DaEntidade(pontuacao.EntidadeNegociacao))
+			negociacoes.POST(":id/pontuacao", pontuacaoHandler.RecalcularDaEntidade(pontuacao.EntidadeNegociacao))
 		}
 
 		historico := api.Group("/historico")
@@ -158,6 +557,20 @@ func main() {
 		api.GET("/anotacoes/:id", anotacaoHandler.ObterAnotacao)
 		api.PUT("/anotacoes/:id", anotacaoHandler.AtualizarAnotacao)
 		api.DELETE("/anotacoes/:id", anotacaoHandler.DeletarAnotacao)
+		api.PUT("/anotacoes/:id/fixada", anotacaoHandler.FixarAnotacao)
+		api.GET("/anotacoes/:id/revisoes", anotacaoHandler.ListarRevisoes)
+		api.GET("/anotacoes/:id/anexos", anexoHandler.ListarDaEntidade(anexo.EntidadeAnotacao))
+		api.POST("/anotacoes/:id/anexos", anexoHandler.EnviarNaEntidade(anexo.EntidadeAnotacao))
+
+		anexos := api.Group("/anexos")
+		{
+			anexos.GET(":id", anexoHandler.ObterAnexo)
+			anexos.PUT(":id", anexoHandler.AtualizarAnexo)
+			anexos.DELETE(":id", anexoHandler.DeletarAnexo)
+			anexos.POST(":id/versoes", anexoHandler.EnviarVersao)
+			anexos.GET(":id/url", anexoHandler.GerarURL)
+			anexos.GET(":id/download", anexoHandler.Download)
+		}
 
 		// Rotas para Quiver
 		quivers := api.Group("/quivers")
@@ -168,7 +581,104 @@ func main() {
 			quivers.PUT(":id", quiverHandler.Atualizar)
 			quivers.DELETE(":id", quiverHandler.Deletar)
 		}
+
+		// Rotas para detecção e mesclagem de duplicados
+		duplicidades := api.Group("/duplicidades")
+		{
+			duplicidades.GET("/empresas", duplicidadeHandler.ListarEmpresas)
+			duplicidades.POST("/empresas/mesclar", duplicidadeHandler.MesclarEmpresas)
+			duplicidades.GET("/contatos", duplicidadeHandler.ListarContatos)
+			duplicidades.POST("/contatos/mesclar", duplicidadeHandler.MesclarContatos)
+		}
+
+		api.GET("/auditoria", auditoriaHandler.Listar)
+
+		api.GET("/ceps/:cep", enderecoHandler.Buscar)
+		api.POST("/ceps/importar", enderecoHandler.Importar)
+
+		// Rotas para definições de campos personalizados
+		campos := api.Group("/campos-personalizados")
+		{
+			campos.POST("", camposHandler.Criar)
+			campos.GET("", camposHandler.Listar)
+			campos.GET("/metadados/:entidade", camposHandler.Metadados)
+			campos.GET(":id", camposHandler.Obter)
+			campos.PUT(":id", camposHandler.Atualizar)
+			campos.DELETE(":id", camposHandler.Deletar)
+		}
 	}
 
 	r.Run(":8082")
 }
+
+// lerIntervalo lê da variável de ambiente o intervalo de uma tarefa periódica
+// (ex: "30s", "5m"), usando o padrão quando ela não está definida.
+func lerIntervalo(variavel string, padrao time.Duration) time.Duration {
+	v := os.Getenv(variavel)
+	if v == "" {
+		return padrao
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Fatalf("%s inválido: %q", variavel, v)
+	}
+	return d
+}
+
+// configurarAnexos cria o armazenamento de anexos a partir do ambiente:
+//   - ANEXOS_ARMAZENAMENTO: "local" (padrão) ou "s3"
+//   - ANEXOS_DIRETORIO: diretório do armazenamento local (padrão "dados/anexos")
+//   - S3_ENDPOINT, S3_REGIAO, S3_BUCKET, S3_CHAVE_ACESSO, S3_SEGREDO,
+//     S3_ESTILO_CAMINHO ("true" para MinIO) e S3_ENDPOINT_PUBLICO
+//   - ANEXOS_TAMANHO_MAXIMO_MB: tamanho máximo de um arquivo (padrão 25)
+//   - ANEXOS_SEGREDO: chave das URLs de download assinadas pela API
+func configurarAnexos() (anexo.Armazenamento, anexo.Config) {
+	var armazenamento anexo.Armazenamento
+	switch tipo := os.Getenv("ANEXOS_ARMAZENAMENTO"); tipo {
+	case "", "local":
+		diretorio := os.Getenv("ANEXOS_DIRETORIO")
+		if diretorio == "" {
+			diretorio = "dados/anexos"
+		}
+		local, err := anexo.NovoArmazenamentoLocal(diretorio)
+		if err != nil {
+			log.Fatalf("Erro ao preparar o diretório de anexos: %v", err)
+		}
+		armazenamento = local
+	case "s3":
+		estiloCaminho, _ := strconv.ParseBool(os.Getenv("S3_ESTILO_CAMINHO"))
+		s3, err := anexo.NovoArmazenamentoS3(anexo.ConfigS3{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Regiao:          os.Getenv("S3_REGIAO"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			ChaveAcesso:     os.Getenv("S3_CHAVE_ACESSO"),
+			Segredo:         os.Getenv("S3_SEGREDO"),
+			EstiloCaminho:   estiloCaminho,
+			EndpointPublico: os.Getenv("S3_ENDPOINT_PUBLICO"),
+		})
+		if err != nil {
+			log.Fatalf("Erro ao configurar o armazenamento S3: %v", err)
+		}
+		armazenamento = s3
+	default:
+		log.Fatalf("ANEXOS_ARMAZENAMENTO inválido: %q", tipo)
+	}
+
+	cfg := anexo.Config{TamanhoMaximo: 25 << 20, Segredo: []byte(os.Getenv("ANEXOS_SEGREDO"))}
+	if v := os.Getenv("ANEXOS_TAMANHO_MAXIMO_MB"); v != "" {
+		mb, err := strconv.Atoi(v)
+		if err != nil || mb <= 0 {
+			log.Fatalf("ANEXOS_TAMANHO_MAXIMO_MB inválido: %q", v)
+		}
+		cfg.TamanhoMaximo = int64(mb) << 20
+	}
+	if len(cfg.Segredo) == 0 {
+		// Sem segredo fixo, as URLs de download deixam de valer quando o servidor reinicia.
+		log.Printf("ANEXOS_SEGREDO não definido: usando um segredo temporário para as URLs de download")
+		cfg.Segredo = make([]byte, 32)
+		if _, err := rand.Read(cfg.Segredo); err != nil {
+			log.Fatalf("Erro ao gerar o segredo dos anexos: %v", err)
+		}
+	}
+	return armazenamento, cfg
+}
@@ -0,0 +1,40 @@
+package anexo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrObjetoNaoEncontrado indica que a chave não existe no armazenamento.
+var ErrObjetoNaoEncontrado = errors.New("objeto não encontrado no armazenamento")
+
+// Objeto descreve o conteúdo gravado no armazenamento.
+type Objeto struct {
+	Chave   string
+	Tipo    string // Tipo MIME
+	Tamanho int64
+	SHA256  string // Hash do conteúdo em hexadecimal
+}
+
+// Armazenamento guarda o conteúdo dos anexos. Os metadados ficam no banco; o
+// armazenamento só conhece as chaves geradas pelo repositório.
+type Armazenamento interface {
+	Gravar(ctx context.Context, o Objeto, conteudo io.Reader) error
+	Abrir(ctx context.Context, chave string) (io.ReadCloser, error)
+	Remover(ctx context.Context, chave string) error
+}
+
+// Download descreve como o arquivo deve ser entregue a quem baixá-lo.
+type Download struct {
+	NomeArquivo string
+	Tipo        string
+}
+
+// GeradorURL é implementado pelos armazenamentos capazes de gerar URLs de
+// download assinadas por conta própria, como o S3. Para os demais, a API assina
+// uma URL que ela mesma atende.
+type GeradorURL interface {
+	URLAssinada(chave string, d Download, validade time.Duration) (string, error)
+}
@@ -0,0 +1,299 @@
+package anexo
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Config reúne os limites de envio e o segredo das URLs de download.
+type Config struct {
+	TamanhoMaximo int64         // Tamanho máximo de um arquivo, em bytes
+	Segredo       []byte        // Chave HMAC das URLs de download atendidas pela API
+	ValidadeURL   time.Duration // Validade padrão das URLs de download
+}
+
+// Handler contém as dependências do HTTP para anexos.
+type Handler struct {
+	repo          Repository
+	armazenamento Armazenamento
+	cfg           Config
+}
+
+// NovoHandler cria o handler de anexos.
+func NovoHandler(repo Repository, armazenamento Armazenamento, cfg Config) *Handler {
+	if cfg.ValidadeURL <= 0 {
+		cfg.ValidadeURL = 15 * time.Minute
+	}
+	return &Handler{repo: repo, armazenamento: armazenamento, cfg: cfg}
+}
+
+// statusErro traduz os erros do repositório em status HTTP.
+func statusErro(err error) int {
+	var excedido *http.MaxBytesError
+	switch {
+	case errors.Is(err, ErrEntidadeInvalida), errors.Is(err, ErrArquivoVazio):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrEntidadeNaoEncontrada):
+		return http.StatusNotFound
+	case errors.Is(err, ErrTipoNaoPermitido):
+		return http.StatusUnsupportedMediaType
+	case errors.As(err, &excedido):
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusInternalServerError
+}
+
+// receberArquivo lê o campo "arquivo" do formulário multipart respeitando o
+// tamanho máximo configurado.
+func (h *Handler) receberArquivo(c *gin.Context) (Arquivo, func(), error) {
+	if h.cfg.TamanhoMaximo > 0 {
+		// Folga de 1 MiB para os demais campos e os cabeçalhos do multipart.
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.cfg.TamanhoMaximo+1<<20)
+	}
+	cabecalho, err := c.FormFile("arquivo")
+	if err != nil {
+		return Arquivo{}, nil, err
+	}
+	if h.cfg.TamanhoMaximo > 0 && cabecalho.Size > h.cfg.TamanhoMaximo {
+		return Arquivo{}, nil, &http.MaxBytesError{Limit: h.cfg.TamanhoMaximo}
+	}
+	f, err := cabecalho.Open()
+	if err != nil {
+		return Arquivo{}, nil, err
+	}
+	arq := Arquivo{Nome: cabecalho.Filename, Conteudo: f, Autor: c.PostForm("autor")}
+	return arq, func() { f.Close() }, nil
+}
+
+// responderErroEnvio responde aos erros de recebimento do arquivo.
+func responderErroEnvio(c *gin.Context, err error) {
+	status := statusErro(err)
+	if status == http.StatusInternalServerError {
+		status = http.StatusBadRequest
+	}
+	if status == http.StatusRequestEntityTooLarge {
+		c.JSON(status, gin.H{"error": "arquivo maior que o tamanho máximo permitido"})
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// EnviarNaEntidade retorna o handler que anexa um arquivo à entidade do tipo
+// informado, identificada pelo parâmetro :id da rota. Recebe um formulário
+// multipart com o campo "arquivo" e, opcionalmente, "nome", "descricao" e "autor".
+func (h *Handler) EnviarNaEntidade(entidade string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+			return
+		}
+		arq, fechar, err := h.receberArquivo(c)
+		if err != nil {
+			responderErroEnvio(c, err)
+			return
+		}
+		defer fechar()
+
+		a := Anexo{
+			Entidade:   entidade,
+			EntidadeID: id,
+			Nome:       c.PostForm("nome"),
+			Descricao:  c.PostForm("descricao"),
+			Autor:      arq.Autor,
+		}
+		created, err := h.repo.Adicionar(c.Request.Context(), a, arq)
+		if err != nil {
+			c.JSON(statusErro(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, created)
+	}
+}
+
+// ListarDaEntidade retorna o handler que lista os anexos da entidade do tipo informado.
+func (h *Handler) ListarDaEntidade(entidade string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+			return
+		}
+		anexos, err := h.repo.ListarPorEntidade(entidade, id)
+		if err != nil {
+			c.JSON(statusErro(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, anexos)
+	}
+}
+
+// EnviarVersao recebe uma nova versão do arquivo de um anexo (campo "arquivo").
+func (h *Handler) EnviarVersao(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	arq, fechar, err := h.receberArquivo(c)
+	if err != nil {
+		responderErroEnvio(c, err)
+		return
+	}
+	defer fechar()
+
+	a, err := h.repo.AdicionarVersao(c.Request.Context(), id, arq)
+	if err != nil {
+		c.JSON(statusErro(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, a)
+}
+
+// ObterAnexo retorna os metadados do anexo com o histórico de versões.
+func (h *Handler) ObterAnexo(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	a, err := h.repo.ObterPorID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, a)
+}
+
+// AtualizarAnexo altera o nome de exibição e a descrição do anexo.
+func (h *Handler) AtualizarAnexo(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	var a Anexo
+	if err := c.ShouldBindJSON(&a); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	updated, err := h.repo.Atualizar(id, a)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeletarAnexo remove o anexo.
+func (h *Handler) DeletarAnexo(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	if err := h.repo.Deletar(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GerarURL retorna uma URL de download assinada e temporária para uma versão do
+// anexo (a atual, se "versao" não for informada). Com armazenamento S3 a URL
+// aponta direto para o bucket; nos demais, para a rota de download da API.
+// Parâmetros opcionais: versao e validade (ex: "15m", no máximo 7 dias).
+func (h *Handler) GerarURL(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	numero, _ := strconv.Atoi(c.Query("versao"))
+	validade := h.cfg.ValidadeURL
+	if v := c.Query("validade"); v != "" {
+		validade, err = time.ParseDuration(v)
+		if err != nil || validade <= 0 || validade > 7*24*time.Hour {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "validade inválida: use uma duração entre 1s e 168h"})
+			return
+		}
+	}
+	v, err := h.repo.ObterVersao(id, numero)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	expira := time.Now().Add(validade)
+	var url string
+	if gerador, ok := h.armazenamento.(GeradorURL); ok {
+		url, err = gerador.URLAssinada(v.Chave, Download{NomeArquivo: v.NomeArquivo, Tipo: v.Tipo}, validade)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		base := strings.TrimSuffix(c.Request.URL.Path, "/url") + "/download"
+		url = fmt.Sprintf("%s?versao=%d&expira=%d&assinatura=%s", base, v.Numero, expira.Unix(), h.assinar(id, v.Numero, expira.Unix()))
+	}
+	c.JSON(http.StatusOK, gin.H{"url": url, "versao": v.Numero, "expira_em": expira})
+}
+
+// assinar calcula a assinatura HMAC-SHA256 de uma URL de download.
+func (h *Handler) assinar(id, versao int, expira int64) string {
+	mac := hmac.New(sha256.New, h.cfg.Segredo)
+	fmt.Fprintf(mac, "%d:%d:%d", id, versao, expira)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Download entrega o conteúdo de uma versão do anexo. A URL precisa ter sido
+// gerada por GerarURL e ainda estar dentro da validade.
+func (h *Handler) Download(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	numero, errVersao := strconv.Atoi(c.Query("versao"))
+	expira, errExpira := strconv.ParseInt(c.Query("expira"), 10, 64)
+	assinatura := c.Query("assinatura")
+	if errVersao != nil || errExpira != nil ||
+		!hmac.Equal([]byte(assinatura), []byte(h.assinar(id, numero, expira))) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "assinatura inválida"})
+		return
+	}
+	if time.Now().Unix() > expira {
+		c.JSON(http.StatusForbidden, gin.H{"error": "URL expirada"})
+		return
+	}
+
+	v, err := h.repo.ObterVersao(id, numero)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	conteudo, err := h.repo.Abrir(c.Request.Context(), v)
+	if errors.Is(err, ErrObjetoNaoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer conteudo.Close()
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": v.NomeArquivo}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("ETag", `"`+v.SHA256+`"`)
+	c.DataFromReader(http.StatusOK, v.Tamanho, v.Tipo, conteudo, nil)
+}
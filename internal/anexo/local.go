@@ -0,0 +1,90 @@
+package anexo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ArmazenamentoLocal grava os anexos em um diretório do sistema de arquivos.
+type ArmazenamentoLocal struct {
+	raiz string
+}
+
+// NovoArmazenamentoLocal cria o armazenamento no diretório informado, criando-o
+// se necessário.
+func NovoArmazenamentoLocal(raiz string) (*ArmazenamentoLocal, error) {
+	if err := os.MkdirAll(raiz, 0o750); err != nil {
+		return nil, err
+	}
+	return &ArmazenamentoLocal{raiz: raiz}, nil
+}
+
+// caminho converte a chave em um caminho dentro da raiz, recusando chaves que
+// escapariam dela.
+func (a *ArmazenamentoLocal) caminho(chave string) (string, error) {
+	limpo := filepath.Clean("/" + chave)
+	if limpo == "/" || strings.Contains(chave, "..") {
+		return "", fmt.Errorf("chave inválida: %q", chave)
+	}
+	return filepath.Join(a.raiz, filepath.FromSlash(limpo)), nil
+}
+
+// Gravar escreve o conteúdo em um arquivo temporário e o renomeia ao final, para
+// que um envio interrompido não deixe um objeto pela metade.
+func (a *ArmazenamentoLocal) Gravar(ctx context.Context, o Objeto, conteudo io.Reader) error {
+	destino, err := a.caminho(o.Chave)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(destino), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(destino), ".envio-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, conteudo); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), destino)
+}
+
+// Abrir retorna o conteúdo gravado na chave.
+func (a *ArmazenamentoLocal) Abrir(ctx context.Context, chave string) (io.ReadCloser, error) {
+	caminho, err := a.caminho(chave)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(caminho)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrObjetoNaoEncontrado
+	} else if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Remover apaga o conteúdo da chave. Remover uma chave inexistente não é erro.
+func (a *ArmazenamentoLocal) Remover(ctx context.Context, chave string) error {
+	caminho, err := a.caminho(chave)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(caminho); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
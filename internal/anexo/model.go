@@ -0,0 +1,64 @@
+package anexo
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tipos de entidade que podem receber anexos.
+const (
+	EntidadeNegociacao = "negociacao"
+	EntidadeEmpresa    = "empresa"
+	EntidadeContato    = "contato"
+	EntidadeAnotacao   = "anotacao"
+)
+
+// tabelas relaciona cada tipo de entidade à tabela em que ela é gravada, para
+// conferir a existência do registro sem importar os pacotes das entidades.
+var tabelas = map[string]string{
+	EntidadeNegociacao: "negociacoes",
+	EntidadeEmpresa:    "empresas",
+	EntidadeContato:    "contatos",
+	EntidadeAnotacao:   "anotacoes",
+}
+
+// Anexo é um documento ligado a uma entidade do CRM. O conteúdo fica no
+// armazenamento; cada novo envio do mesmo documento gera uma Versao, e os
+// campos Tipo, Tamanho e SHA256 refletem a versão atual.
+type Anexo struct {
+	ID          int            `json:"id" gorm:"primaryKey;autoIncrement"`
+	Entidade    string         `json:"entidade" gorm:"size:20;index:idx_anexo_entidade"`
+	EntidadeID  int            `json:"entidade_id" gorm:"index:idx_anexo_entidade"`
+	Nome        string         `json:"nome"` // Nome de exibição; por padrão, o nome do primeiro arquivo
+	Descricao   string         `json:"descricao"`
+	Tipo        string         `json:"tipo"`
+	Tamanho     int64          `json:"tamanho"`
+	SHA256      string         `json:"sha256" gorm:"column:sha256"`
+	VersaoAtual int            `json:"versao_atual"`
+	Autor       string         `json:"autor"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	Versoes []Versao `json:"versoes,omitempty" gorm:"foreignKey:AnexoID"`
+}
+
+// Versao é um envio do arquivo de um anexo.
+type Versao struct {
+	ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	AnexoID     int       `json:"anexo_id" gorm:"uniqueIndex:idx_anexo_versao;not null"`
+	Numero      int       `json:"numero" gorm:"uniqueIndex:idx_anexo_versao;not null"`
+	NomeArquivo string    `json:"nome_arquivo"`
+	Tipo        string    `json:"tipo"`
+	Tamanho     int64     `json:"tamanho"`
+	SHA256      string    `json:"sha256" gorm:"column:sha256;index"`
+	Chave       string    `json:"-"` // Chave do conteúdo no armazenamento
+	Autor       string    `json:"autor"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Versao) TableName() string {
+	return "anexo_versoes"
+}
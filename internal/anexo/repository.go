@@ -0,0 +1,241 @@
+package anexo
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Erros de vínculo do anexo com a sua entidade.
+var (
+	ErrEntidadeInvalida      = errors.New("entidade inválida")
+	ErrEntidadeNaoEncontrada = errors.New("entidade não encontrada")
+	ErrArquivoVazio          = errors.New("arquivo vazio")
+)
+
+// Arquivo é o conteúdo enviado para um anexo. O conteúdo precisa permitir
+// releitura: ele é percorrido para calcular o hash antes de ser gravado.
+type Arquivo struct {
+	Nome     string
+	Conteudo io.ReadSeeker
+	Autor    string
+}
+
+// Repository define as operações de anexos: metadados no banco e conteúdo no armazenamento.
+type Repository interface {
+	Adicionar(ctx context.Context, a Anexo, arq Arquivo) (Anexo, error)
+	AdicionarVersao(ctx context.Context, id int, arq Arquivo) (Anexo, error)
+	ListarPorEntidade(entidade string, id int) ([]Anexo, error)
+	ObterPorID(id int) (*Anexo, error)
+	ObterVersao(id, numero int) (Versao, error)
+	Abrir(ctx context.Context, v Versao) (io.ReadCloser, error)
+	Atualizar(id int, updated Anexo) (Anexo, error)
+	Deletar(id int) error
+}
+
+type repository struct {
+	db            *gorm.DB
+	armazenamento Armazenamento
+}
+
+// NovoRepositorio cria o repositório de anexos sobre o armazenamento informado.
+func NovoRepositorio(db *gorm.DB, armazenamento Armazenamento) Repository {
+	return &repository{db: db, armazenamento: armazenamento}
+}
+
+// Adicionar cria o anexo na entidade indicada, com o arquivo como versão 1.
+func (r *repository) Adicionar(ctx context.Context, a Anexo, arq Arquivo) (Anexo, error) {
+	if err := conferirEntidade(r.db, a.Entidade, a.EntidadeID); err != nil {
+		return Anexo{}, err
+	}
+	a.ID = 0
+	a.Versoes = nil
+	a.VersaoAtual = 0
+	if strings.TrimSpace(a.Nome) == "" {
+		a.Nome = arq.Nome
+	}
+	if a.Autor == "" {
+		a.Autor = arq.Autor
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&a).Error; err != nil {
+			return err
+		}
+		return r.gravarVersao(ctx, tx, &a, arq)
+	})
+	if err != nil {
+		return Anexo{}, err
+	}
+	return a, nil
+}
+
+// AdicionarVersao envia uma nova versão do arquivo de um anexo existente.
+func (r *repository) AdicionarVersao(ctx context.Context, id int, arq Arquivo) (Anexo, error) {
+	var a Anexo
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Trava o anexo para que envios simultâneos não disputem o mesmo número de versão.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&a, id).Error; err != nil {
+			return errors.New("Anexo não encontrado")
+		}
+		return r.gravarVersao(ctx, tx, &a, arq)
+	})
+	if err != nil {
+		return Anexo{}, err
+	}
+	return a, nil
+}
+
+// gravarVersao confere o tipo, calcula o tamanho e o SHA-256, grava o conteúdo
+// no armazenamento e registra a versão. Se o banco falhar depois da gravação, o
+// objeto é removido.
+func (r *repository) gravarVersao(ctx context.Context, tx *gorm.DB, a *Anexo, arq Arquivo) error {
+	inicio := make([]byte, 512)
+	n, err := io.ReadFull(arq.Conteudo, inicio)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	if n == 0 {
+		return ErrArquivoVazio
+	}
+	tipo, err := DetectarTipo(arq.Nome, inicio[:n])
+	if err != nil {
+		return err
+	}
+	if _, err := arq.Conteudo.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	hash := sha256.New()
+	tamanho, err := io.Copy(hash, arq.Conteudo)
+	if err != nil {
+		return err
+	}
+	if _, err := arq.Conteudo.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	sufixo := make([]byte, 8)
+	if _, err := rand.Read(sufixo); err != nil {
+		return err
+	}
+	v := Versao{
+		AnexoID:     a.ID,
+		Numero:      a.VersaoAtual + 1,
+		NomeArquivo: filepath.Base(arq.Nome),
+		Tipo:        tipo,
+		Tamanho:     tamanho,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		Autor:       arq.Autor,
+	}
+	v.Chave = fmt.Sprintf("anexos/%d/v%d-%s%s", a.ID, v.Numero, hex.EncodeToString(sufixo), strings.ToLower(filepath.Ext(arq.Nome)))
+
+	objeto := Objeto{Chave: v.Chave, Tipo: v.Tipo, Tamanho: v.Tamanho, SHA256: v.SHA256}
+	if err := r.armazenamento.Gravar(ctx, objeto, arq.Conteudo); err != nil {
+		return fmt.Errorf("erro ao gravar o arquivo: %w", err)
+	}
+	err = tx.Create(&v).Error
+	if err == nil {
+		err = tx.Model(a).Updates(map[string]interface{}{
+			"versao_atual": v.Numero, "tipo": v.Tipo, "tamanho": v.Tamanho, "sha256": v.SHA256,
+		}).Error
+	}
+	if err != nil {
+		r.armazenamento.Remover(context.Background(), v.Chave)
+		return err
+	}
+	a.VersaoAtual, a.Tipo, a.Tamanho, a.SHA256 = v.Numero, v.Tipo, v.Tamanho, v.SHA256
+	return nil
+}
+
+// conferirEntidade valida o tipo da entidade e verifica se o registro existe.
+func conferirEntidade(db *gorm.DB, entidade string, id int) error {
+	tabela, ok := tabelas[entidade]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrEntidadeInvalida, entidade)
+	}
+	var total int64
+	err := db.Table(tabela).Where("id = ? AND deleted_at IS NULL", id).Count(&total).Error
+	if err != nil {
+		return err
+	}
+	if total == 0 {
+		return fmt.Errorf("%w: %s %d", ErrEntidadeNaoEncontrada, entidade, id)
+	}
+	return nil
+}
+
+// ListarPorEntidade retorna os anexos de uma entidade, dos mais recentes para os mais antigos.
+func (r *repository) ListarPorEntidade(entidade string, id int) ([]Anexo, error) {
+	if err := conferirEntidade(r.db, entidade, id); err != nil {
+		return nil, err
+	}
+	anexos := []Anexo{}
+	err := r.db.Where("entidade = ? AND entidade_id = ?", entidade, id).
+		Order("updated_at DESC, id DESC").
+		Find(&anexos).Error
+	return anexos, err
+}
+
+// ObterPorID busca um anexo com todas as suas versões, da mais recente para a mais antiga.
+func (r *repository) ObterPorID(id int) (*Anexo, error) {
+	var a Anexo
+	err := r.db.Preload("Versoes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("numero DESC")
+	}).First(&a, id).Error
+	if err != nil {
+		return nil, errors.New("Anexo não encontrado")
+	}
+	return &a, nil
+}
+
+// ObterVersao retorna uma versão do anexo; numero zero indica a versão atual.
+func (r *repository) ObterVersao(id, numero int) (Versao, error) {
+	var a Anexo
+	if err := r.db.First(&a, id).Error; err != nil {
+		return Versao{}, errors.New("Anexo não encontrado")
+	}
+	if numero == 0 {
+		numero = a.VersaoAtual
+	}
+	var v Versao
+	if err := r.db.Where("anexo_id = ? AND numero = ?", id, numero).First(&v).Error; err != nil {
+		return Versao{}, fmt.Errorf("versão %d do anexo %d não encontrada", numero, id)
+	}
+	return v, nil
+}
+
+// Abrir retorna o conteúdo da versão guardado no armazenamento.
+func (r *repository) Abrir(ctx context.Context, v Versao) (io.ReadCloser, error) {
+	return r.armazenamento.Abrir(ctx, v.Chave)
+}
+
+// Atualizar altera o nome de exibição e a descrição do anexo.
+func (r *repository) Atualizar(id int, updated Anexo) (Anexo, error) {
+	var a Anexo
+	if err := r.db.First(&a, id).Error; err != nil {
+		return Anexo{}, errors.New("Anexo não encontrado")
+	}
+	if strings.TrimSpace(updated.Nome) == "" {
+		updated.Nome = a.Nome
+	}
+	err := r.db.Model(&a).Select("nome", "descricao").Updates(updated).Error
+	if err != nil {
+		return Anexo{}, err
+	}
+	err = r.db.First(&a, id).Error
+	return a, err
+}
+
+// Deletar remove o anexo das listagens. O conteúdo das versões é mantido no
+// armazenamento, assim como os demais registros excluídos do CRM.
+func (r *repository) Deletar(id int) error {
+	return r.db.Delete(&Anexo{}, id).Error
+}
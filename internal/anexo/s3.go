@@ -0,0 +1,273 @@
+package anexo
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigS3 reúne os parâmetros de acesso a um serviço compatível com S3
+// (AWS S3, MinIO etc.).
+type ConfigS3 struct {
+	Endpoint        string // Ex: "https://s3.sa-east-1.amazonaws.com" ou "http://minio:9000"
+	Regiao          string // Padrão: "us-east-1"
+	Bucket          string
+	ChaveAcesso     string
+	Segredo         string
+	EstiloCaminho   bool   // Endereça o bucket no caminho (endpoint/bucket/chave), como exige o MinIO
+	EndpointPublico string // Endpoint usado nas URLs de download, se diferente do interno
+}
+
+// ArmazenamentoS3 grava os anexos em um bucket S3, assinando as requisições com
+// AWS Signature Version 4.
+type ArmazenamentoS3 struct {
+	cfg     ConfigS3
+	cliente *http.Client
+	agora   func() time.Time
+}
+
+// NovoArmazenamentoS3 valida a configuração e cria o armazenamento.
+func NovoArmazenamentoS3(cfg ConfigS3) (*ArmazenamentoS3, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.ChaveAcesso == "" || cfg.Segredo == "" {
+		return nil, fmt.Errorf("S3: endpoint, bucket, chave de acesso e segredo são obrigatórios")
+	}
+	if _, err := url.Parse(cfg.Endpoint); err != nil {
+		return nil, fmt.Errorf("S3: endpoint inválido: %w", err)
+	}
+	if cfg.Regiao == "" {
+		cfg.Regiao = "us-east-1"
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	cfg.EndpointPublico = strings.TrimRight(cfg.EndpointPublico, "/")
+	return &ArmazenamentoS3{cfg: cfg, cliente: &http.Client{Timeout: 5 * time.Minute}, agora: time.Now}, nil
+}
+
+// Gravar envia o objeto com PUT. O SHA-256 calculado no envio é usado como
+// hash do payload da assinatura, o que faz o S3 conferir a integridade.
+func (s *ArmazenamentoS3) Gravar(ctx context.Context, o Objeto, conteudo io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.url(s.cfg.Endpoint, o.Chave), conteudo)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = o.Tamanho
+	req.Header.Set("Content-Type", o.Tipo)
+	s.assinar(req, o.SHA256)
+	resp, err := s.cliente.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return erroS3(resp)
+}
+
+// Abrir baixa o objeto com GET.
+func (s *ArmazenamentoS3) Abrir(ctx context.Context, chave string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url(s.cfg.Endpoint, chave), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.assinar(req, hashVazio)
+	resp, err := s.cliente.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := erroS3(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Remover apaga o objeto com DELETE.
+func (s *ArmazenamentoS3) Remover(ctx context.Context, chave string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.url(s.cfg.Endpoint, chave), nil)
+	if err != nil {
+		return err
+	}
+	s.assinar(req, hashVazio)
+	resp, err := s.cliente.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := erroS3(resp); err != nil && err != ErrObjetoNaoEncontrado {
+		return err
+	}
+	return nil
+}
+
+// URLAssinada gera uma URL pré-assinada (query string SigV4) para baixar o objeto
+// diretamente do S3, com o nome e o tipo do arquivo definidos na resposta.
+func (s *ArmazenamentoS3) URLAssinada(chave string, d Download, validade time.Duration) (string, error) {
+	if validade <= 0 || validade > 7*24*time.Hour {
+		return "", fmt.Errorf("validade deve estar entre 1s e 7 dias")
+	}
+	endpoint := s.cfg.Endpoint
+	if s.cfg.EndpointPublico != "" {
+		endpoint = s.cfg.EndpointPublico
+	}
+	u, err := url.Parse(s.url(endpoint, chave))
+	if err != nil {
+		return "", err
+	}
+	agora := s.agora().UTC()
+	q := url.Values{}
+	q.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	q.Set("X-Amz-Credential", s.cfg.ChaveAcesso+"/"+s.escopo(agora))
+	q.Set("X-Amz-Date", agora.Format(formatoAmz))
+	q.Set("X-Amz-Expires", strconv.Itoa(int(validade.Seconds())))
+	q.Set("X-Amz-SignedHeaders", "host")
+	if d.NomeArquivo != "" {
+		q.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": d.NomeArquivo}))
+	}
+	if d.Tipo != "" {
+		q.Set("response-content-type", d.Tipo)
+	}
+	consulta := consultaCanonica(q)
+	canonica := strings.Join([]string{
+		http.MethodGet,
+		caminhoCanonico(u.Path),
+		consulta,
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	u.RawQuery = consulta + "&X-Amz-Signature=" + s.assinatura(agora, canonica)
+	return u.String(), nil
+}
+
+const (
+	formatoAmz = "20060102T150405Z"
+	// hashVazio é o SHA-256 de um corpo vazio.
+	hashVazio = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// url monta o endereço do objeto no estilo de caminho ou de host virtual.
+func (s *ArmazenamentoS3) url(endpoint, chave string) string {
+	chave = strings.TrimLeft(chave, "/")
+	if s.cfg.EstiloCaminho {
+		return endpoint + "/" + s.cfg.Bucket + "/" + chave
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint + "/" + chave
+	}
+	u.Host = s.cfg.Bucket + "." + u.Host
+	return u.String() + "/" + chave
+}
+
+func (s *ArmazenamentoS3) escopo(t time.Time) string {
+	return t.Format("20060102") + "/" + s.cfg.Regiao + "/s3/aws4_request"
+}
+
+// assinar inclui na requisição os cabeçalhos de autorização SigV4.
+func (s *ArmazenamentoS3) assinar(req *http.Request, hashPayload string) {
+	agora := s.agora().UTC()
+	req.Header.Set("X-Amz-Date", agora.Format(formatoAmz))
+	req.Header.Set("X-Amz-Content-Sha256", hashPayload)
+
+	nomes := []string{"host"}
+	valores := map[string]string{"host": req.URL.Host}
+	for nome, v := range req.Header {
+		n := strings.ToLower(nome)
+		if n == "content-type" || strings.HasPrefix(n, "x-amz-") {
+			nomes = append(nomes, n)
+			valores[n] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	sort.Strings(nomes)
+	var cabecalhos strings.Builder
+	for _, n := range nomes {
+		cabecalhos.WriteString(n + ":" + valores[n] + "\n")
+	}
+	assinados := strings.Join(nomes, ";")
+
+	canonica := strings.Join([]string{
+		req.Method,
+		caminhoCanonico(req.URL.Path),
+		consultaCanonica(req.URL.Query()),
+		cabecalhos.String(),
+		assinados,
+		hashPayload,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.ChaveAcesso, s.escopo(agora), assinados, s.assinatura(agora, canonica)))
+}
+
+// assinatura calcula a assinatura SigV4 da requisição canônica.
+func (s *ArmazenamentoS3) assinatura(t time.Time, canonica string) string {
+	hash := sha256.Sum256([]byte(canonica))
+	texto := "AWS4-HMAC-SHA256\n" + t.Format(formatoAmz) + "\n" + s.escopo(t) + "\n" + hex.EncodeToString(hash[:])
+	chave := hmacSHA256([]byte("AWS4"+s.cfg.Segredo), t.Format("20060102"))
+	chave = hmacSHA256(chave, s.cfg.Regiao)
+	chave = hmacSHA256(chave, "s3")
+	chave = hmacSHA256(chave, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(chave, texto))
+}
+
+func hmacSHA256(chave []byte, dado string) []byte {
+	h := hmac.New(sha256.New, chave)
+	h.Write([]byte(dado))
+	return h.Sum(nil)
+}
+
+// codificar aplica a codificação URI do SigV4: tudo exceto A-Z, a-z, 0-9, '-',
+// '.', '_' e '~' vira %XX.
+func codificar(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// caminhoCanonico codifica cada segmento do caminho, preservando as barras.
+func caminhoCanonico(caminho string) string {
+	if caminho == "" {
+		return "/"
+	}
+	segmentos := strings.Split(caminho, "/")
+	for i, seg := range segmentos {
+		segmentos[i] = codificar(seg)
+	}
+	return strings.Join(segmentos, "/")
+}
+
+// consultaCanonica ordena e codifica os parâmetros da query string.
+func consultaCanonica(q url.Values) string {
+	pares := make([]string, 0, len(q))
+	for nome, valores := range q {
+		for _, v := range valores {
+			pares = append(pares, codificar(nome)+"="+codificar(v))
+		}
+	}
+	sort.Strings(pares)
+	return strings.Join(pares, "&")
+}
+
+// erroS3 converte as respostas de erro do S3 em erros Go.
+func erroS3(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrObjetoNaoEncontrado
+	}
+	corpo, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("S3 respondeu %s: %s", resp.Status, strings.TrimSpace(string(corpo)))
+}
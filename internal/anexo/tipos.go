@@ -0,0 +1,62 @@
+package anexo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// ErrTipoNaoPermitido indica um arquivo cujo tipo não é aceito como anexo.
+var ErrTipoNaoPermitido = errors.New("tipo de arquivo não permitido")
+
+// tipoPermitido relaciona a extensão ao tipo MIME gravado e ao tipo que a
+// detecção pelo conteúdo (http.DetectContentType) deve encontrar.
+type tipoPermitido struct {
+	mime      string
+	detectado string // Prefixo esperado; vazio aceita qualquer conteúdo binário
+}
+
+var tiposPermitidos = map[string]tipoPermitido{
+	".pdf":  {"application/pdf", "application/pdf"},
+	".png":  {"image/png", "image/png"},
+	".jpg":  {"image/jpeg", "image/jpeg"},
+	".jpeg": {"image/jpeg", "image/jpeg"},
+	".gif":  {"image/gif", "image/gif"},
+	".webp": {"image/webp", "image/webp"},
+	".txt":  {"text/plain; charset=utf-8", "text/plain"},
+	".csv":  {"text/csv; charset=utf-8", "text/plain"},
+	".docx": {"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "application/zip"},
+	".xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "application/zip"},
+	".pptx": {"application/vnd.openxmlformats-officedocument.presentationml.presentation", "application/zip"},
+	".odt":  {"application/vnd.oasis.opendocument.text", "application/zip"},
+	".ods":  {"application/vnd.oasis.opendocument.spreadsheet", "application/zip"},
+	".zip":  {"application/zip", "application/zip"},
+	".doc":  {"application/msword", ""},
+	".xls":  {"application/vnd.ms-excel", ""},
+	".msg":  {"application/vnd.ms-outlook", ""},
+	".eml":  {"message/rfc822", "text/plain"},
+}
+
+// DetectarTipo confere o arquivo pela extensão do nome e pelos primeiros bytes
+// do conteúdo, e retorna o tipo MIME a ser gravado. Um arquivo cujo conteúdo
+// não corresponde à extensão (ex: um executável renomeado para .pdf) é recusado.
+func DetectarTipo(nome string, inicio []byte) (string, error) {
+	ext := strings.ToLower(filepath.Ext(nome))
+	permitido, ok := tiposPermitidos[ext]
+	if !ok {
+		return "", fmt.Errorf("%w: extensão %q", ErrTipoNaoPermitido, ext)
+	}
+	detectado := http.DetectContentType(inicio)
+	esperado := permitido.detectado
+	if esperado == "" {
+		// Formatos binários antigos não são reconhecidos pela detecção; basta não
+		// parecerem texto, imagem ou outro formato conhecido.
+		esperado = "application/octet-stream"
+	}
+	if !strings.HasPrefix(detectado, esperado) {
+		return "", fmt.Errorf("%w: o conteúdo (%s) não corresponde à extensão %q", ErrTipoNaoPermitido, detectado, ext)
+	}
+	return permitido.mime, nil
+}
@@ -0,0 +1,55 @@
+package anexo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetectarTipo(t *testing.T) {
+	var (
+		pdf  = []byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n1 0 obj")
+		png  = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+		jpeg = []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00")
+		zip  = []byte("PK\x03\x04\x14\x00\x06\x00\x08\x00")
+		ole  = []byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1\x00\x00\x00\x00")
+		exe  = []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00")
+		html = []byte("<!DOCTYPE html><html><body>")
+	)
+	casos := []struct {
+		nome     string
+		inicio   []byte
+		esperado string // Vazio quando o arquivo deve ser recusado
+	}{
+		{"proposta.pdf", pdf, "application/pdf"},
+		{"PROPOSTA.PDF", pdf, "application/pdf"},
+		{"logo.png", png, "image/png"},
+		{"foto.jpeg", jpeg, "image/jpeg"},
+		{"foto.jpg", jpeg, "image/jpeg"},
+		{"notas.txt", []byte("Reunião às 14h com o cliente"), "text/plain; charset=utf-8"},
+		{"contatos.csv", []byte("nome;email\nAna;ana@exemplo.com\n"), "text/csv; charset=utf-8"},
+		{"contrato.docx", zip, "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{"planilha.xlsx", zip, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{"antigo.doc", ole, "application/msword"},
+		{"antigo.xls", ole, "application/vnd.ms-excel"},
+
+		{"programa.exe", exe, ""},
+		{"sem-extensao", pdf, ""},
+		{"proposta.pdf", exe, ""},
+		{"logo.png", jpeg, ""},
+		{"pagina.pdf", html, ""},
+		{"antigo.doc", []byte("texto simples"), ""},
+		{"contrato.docx", pdf, ""},
+	}
+	for _, c := range casos {
+		tipo, err := DetectarTipo(c.nome, c.inicio)
+		if c.esperado == "" {
+			if !errors.Is(err, ErrTipoNaoPermitido) {
+				t.Errorf("DetectarTipo(%q, %q) = %q, %v; esperado ErrTipoNaoPermitido", c.nome, c.inicio, tipo, err)
+			}
+			continue
+		}
+		if err != nil || tipo != c.esperado {
+			t.Errorf("DetectarTipo(%q, %q) = %q, %v; esperado %q", c.nome, c.inicio, tipo, err, c.esperado)
+		}
+	}
+}
@@ -1,9 +1,9 @@
 package anotacao
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -26,20 +26,114 @@ func (h *Handler) CriarAnotacao(c *gin.Context) {
 		return
 	}
 
-	// Define a data atual se não for enviada
-	if a.Data.IsZero() {
-		a.Data = time.Now()
-	}
+	h.adicionar(c, a)
+}
 
+// adicionar grava a anotação e responde com o status adequado ao erro.
+func (h *Handler) adicionar(c *gin.Context, a Anotacao) {
 	created, err := h.Repo.Adicionar(a)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(statusErro(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, created)
 }
 
+// statusErro traduz os erros de vínculo com a entidade em status HTTP.
+func statusErro(err error) int {
+	switch {
+	case errors.Is(err, ErrEntidadeInvalida):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrEntidadeNaoEncontrada):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// CriarNaEntidade retorna o handler que cria uma anotação na entidade do tipo
+// informado, identificada pelo parâmetro :id da rota.
+// Espera receber um JSON no formato: {"assunto": "...", "anotacao": "...", "autor": "...", "fixada": false}.
+func (h *Handler) CriarNaEntidade(entidade string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+			return
+		}
+
+		var a Anotacao
+		if err := c.ShouldBindJSON(&a); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		a.Entidade = entidade
+		a.EntidadeID = id
+
+		h.adicionar(c, a)
+	}
+}
+
+// ListarDaEntidade retorna o handler que lista as anotações da entidade do tipo
+// informado, com as fixadas primeiro.
+func (h *Handler) ListarDaEntidade(entidade string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+			return
+		}
+
+		anotacoes, err := h.Repo.ListarPorEntidade(entidade, id)
+		if err != nil {
+			c.JSON(statusErro(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, anotacoes)
+	}
+}
+
+// ListarRevisoes trata a requisição para listar o histórico de edições de uma anotação.
+func (h *Handler) ListarRevisoes(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	revisoes, err := h.Repo.ListarRevisoes(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, revisoes)
+}
+
+// FixarAnotacao marca ou desmarca uma anotação como importante.
+// Espera receber um JSON no formato: {"fixada": true}.
+func (h *Handler) FixarAnotacao(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var payload struct {
+		Fixada *bool `json:"fixada" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	a, err := h.Repo.Fixar(id, *payload.Fixada)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, a)
+}
+
 // ListarAnotacoes trata a requisição para listar todas as anotações.
 func (h *Handler) ListarAnotacoes(c *gin.Context) {
 	anotacoes, err := h.Repo.Listar()
@@ -68,6 +162,7 @@ func (h *Handler) ObterAnotacao(c *gin.Context) {
 }
 
 // AtualizarAnotacao trata a requisição para atualizar uma anotação existente.
+// A versão anterior é guardada no histórico em nome de "editado_por".
 func (h *Handler) AtualizarAnotacao(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
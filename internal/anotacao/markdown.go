@@ -0,0 +1,197 @@
+package anotacao
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Resolvedor informa ao renderizador quais menções e referências existem. Só
+// os usuários e entidades reconhecidos viram marcação; o restante fica como texto.
+type Resolvedor interface {
+	UsuarioExiste(login string) bool
+	EntidadeExiste(entidade string, id int) bool
+}
+
+// Documento é o resultado da renderização de uma anotação.
+type Documento struct {
+	HTML    string
+	Mencoes []string // Logins mencionados, sem repetição, na ordem em que aparecem
+}
+
+var (
+	reTitulo       = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	reItem         = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	reItemNumerado = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	reLinha        = regexp.MustCompile(`^\s*(?:-{3,}|\*{3,}|_{3,})\s*$`)
+	reCitacao      = regexp.MustCompile(`^\s*>\s?(.*)$`)
+
+	reCodigo     = regexp.MustCompile("`([^`]+)`")
+	reLink       = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	reMencao     = regexp.MustCompile(`(^|[^\w@/.])@([A-Za-z0-9](?:[A-Za-z0-9._-]*[A-Za-z0-9])?)`)
+	reReferencia = regexp.MustCompile(`(^|[^\w&/])#(empresa|negociacao|contato|tarefa)-(\d+)\b`)
+	reNegrito    = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	reItalico    = regexp.MustCompile(`\*(\S(?:[^*]*?\S)?)\*`)
+	reRiscado    = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	reMarcador   = regexp.MustCompile("\x00(\\d+)\x00")
+)
+
+// Renderizar converte o Markdown da anotação em HTML seguro. Todo o texto é
+// escapado e só são emitidas as marcas geradas aqui: títulos, parágrafos,
+// listas, citações, blocos e trechos de código, negrito, itálico, riscado e
+// links http(s), mailto ou relativos. Menções @login a usuários existentes são
+// destacadas e retornadas em Mencoes; referências como #negociacao-123 a
+// registros existentes viram links para a entidade.
+func Renderizar(texto string, r Resolvedor) Documento {
+	rd := &renderizador{resolvedor: r, vistas: map[string]bool{}}
+	texto = strings.ReplaceAll(texto, "\x00", "")
+	texto = strings.ReplaceAll(texto, "\r\n", "\n")
+	var b strings.Builder
+	rd.blocos(&b, strings.Split(texto, "\n"))
+	return Documento{HTML: strings.TrimSpace(b.String()), Mencoes: rd.mencoes}
+}
+
+type renderizador struct {
+	resolvedor Resolvedor
+	mencoes    []string
+	vistas     map[string]bool
+}
+
+// blocos renderiza as linhas agrupando-as em blocos.
+func (rd *renderizador) blocos(b *strings.Builder, linhas []string) {
+	var paragrafo []string
+	fecharParagrafo := func() {
+		if len(paragrafo) == 0 {
+			return
+		}
+		partes := make([]string, len(paragrafo))
+		for i, l := range paragrafo {
+			partes[i] = rd.inline(strings.TrimSpace(l))
+		}
+		b.WriteString("<p>" + strings.Join(partes, "<br>\n") + "</p>\n")
+		paragrafo = nil
+	}
+
+	for i := 0; i < len(linhas); i++ {
+		linha := linhas[i]
+		switch {
+		case strings.TrimSpace(linha) == "":
+			fecharParagrafo()
+
+		case strings.HasPrefix(strings.TrimSpace(linha), "```"):
+			fecharParagrafo()
+			var codigo []string
+			for i++; i < len(linhas) && !strings.HasPrefix(strings.TrimSpace(linhas[i]), "```"); i++ {
+				codigo = append(codigo, linhas[i])
+			}
+			b.WriteString("<pre><code>" + html.EscapeString(strings.Join(codigo, "\n")) + "</code></pre>\n")
+
+		case reTitulo.MatchString(linha):
+			fecharParagrafo()
+			m := reTitulo.FindStringSubmatch(linha)
+			nivel := len(m[1])
+			fmt.Fprintf(b, "<h%d>%s</h%d>\n", nivel, rd.inline(m[2]), nivel)
+
+		case reLinha.MatchString(linha):
+			fecharParagrafo()
+			b.WriteString("<hr>\n")
+
+		case reCitacao.MatchString(linha):
+			fecharParagrafo()
+			var citacao []string
+			for ; i < len(linhas) && reCitacao.MatchString(linhas[i]); i++ {
+				citacao = append(citacao, reCitacao.FindStringSubmatch(linhas[i])[1])
+			}
+			i--
+			b.WriteString("<blockquote>\n")
+			rd.blocos(b, citacao)
+			b.WriteString("</blockquote>\n")
+
+		case reItem.MatchString(linha), reItemNumerado.MatchString(linha):
+			fecharParagrafo()
+			re, tag := reItem, "ul"
+			if !reItem.MatchString(linha) {
+				re, tag = reItemNumerado, "ol"
+			}
+			b.WriteString("<" + tag + ">\n")
+			for ; i < len(linhas) && re.MatchString(linhas[i]); i++ {
+				b.WriteString("<li>" + rd.inline(re.FindStringSubmatch(linhas[i])[1]) + "</li>\n")
+			}
+			i--
+			b.WriteString("</" + tag + ">\n")
+
+		default:
+			paragrafo = append(paragrafo, linha)
+		}
+	}
+	fecharParagrafo()
+}
+
+// inline renderiza as marcas de uma linha. Trechos de código, links, menções e
+// referências são trocados por marcadores antes da ênfase, para que o seu
+// conteúdo não seja reinterpretado, e restaurados no final.
+func (rd *renderizador) inline(texto string) string {
+	var trechos []string
+	guardar := func(s string) string {
+		trechos = append(trechos, s)
+		return fmt.Sprintf("\x00%d\x00", len(trechos)-1)
+	}
+
+	texto = reCodigo.ReplaceAllStringFunc(texto, func(s string) string {
+		return guardar("<code>" + html.EscapeString(reCodigo.FindStringSubmatch(s)[1]) + "</code>")
+	})
+	texto = reLink.ReplaceAllStringFunc(texto, func(s string) string {
+		m := reLink.FindStringSubmatch(s)
+		if !urlSegura(m[2]) {
+			return m[1]
+		}
+		return guardar(`<a href="`+html.EscapeString(m[2])+`" rel="nofollow noopener noreferrer">`) +
+			m[1] + guardar("</a>")
+	})
+	texto = html.EscapeString(texto)
+	texto = reMencao.ReplaceAllStringFunc(texto, func(s string) string {
+		m := reMencao.FindStringSubmatch(s)
+		login := m[2]
+		if rd.resolvedor == nil || !rd.resolvedor.UsuarioExiste(login) {
+			return s
+		}
+		if !rd.vistas[login] {
+			rd.vistas[login] = true
+			rd.mencoes = append(rd.mencoes, login)
+		}
+		return m[1] + guardar(`<span class="mencao" data-usuario="`+login+`">@`+login+`</span>`)
+	})
+	texto = reReferencia.ReplaceAllStringFunc(texto, func(s string) string {
+		m := reReferencia.FindStringSubmatch(s)
+		id, err := strconv.Atoi(m[3])
+		if err != nil || rd.resolvedor == nil || !rd.resolvedor.EntidadeExiste(m[2], id) {
+			return s
+		}
+		return m[1] + guardar(fmt.Sprintf(`<a href="/%s/%d" class="referencia" data-entidade="%s" data-id="%d">#%s-%d</a>`,
+			tabelas[m[2]], id, m[2], id, m[2], id))
+	})
+	texto = reNegrito.ReplaceAllString(texto, "<strong>$1$2</strong>")
+	texto = reItalico.ReplaceAllString(texto, "<em>$1</em>")
+	texto = reRiscado.ReplaceAllString(texto, "<del>$1</del>")
+
+	return reMarcador.ReplaceAllStringFunc(texto, func(s string) string {
+		i, _ := strconv.Atoi(reMarcador.FindStringSubmatch(s)[1])
+		return trechos[i]
+	})
+}
+
+// urlSegura aceita apenas links http(s), mailto e caminhos relativos ao CRM.
+func urlSegura(url string) bool {
+	u := strings.ToLower(url)
+	switch {
+	case strings.HasPrefix(u, "http://"), strings.HasPrefix(u, "https://"), strings.HasPrefix(u, "mailto:"):
+		return true
+	case strings.HasPrefix(u, "/") && !strings.HasPrefix(u, "//"):
+		return true
+	case strings.HasPrefix(u, "#"):
+		return true
+	}
+	return false
+}
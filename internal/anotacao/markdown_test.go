@@ -0,0 +1,104 @@
+package anotacao
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// resolvedorFixo reconhece os usuários e entidades listados.
+type resolvedorFixo struct {
+	usuarios  []string
+	entidades map[string][]int
+}
+
+func (r resolvedorFixo) UsuarioExiste(login string) bool {
+	for _, u := range r.usuarios {
+		if u == login {
+			return true
+		}
+	}
+	return false
+}
+
+func (r resolvedorFixo) EntidadeExiste(entidade string, id int) bool {
+	for _, e := range r.entidades[entidade] {
+		if e == id {
+			return true
+		}
+	}
+	return false
+}
+
+var resolvedorTeste = resolvedorFixo{
+	usuarios:  []string{"ana", "bruno.silva"},
+	entidades: map[string][]int{EntidadeNegociacao: {123}},
+}
+
+func TestRenderizar(t *testing.T) {
+	casos := []struct {
+		nome, texto, html string
+	}{
+		{"parágrafo com quebra de linha", "linha 1\r\nlinha 2", "<p>linha 1<br>\nlinha 2</p>"},
+		{"título", "## Próximos passos ##", "<h2>Próximos passos</h2>"},
+		{"ênfase", "**negrito**, *itálico* e ~~riscado~~", "<p><strong>negrito</strong>, <em>itálico</em> e <del>riscado</del></p>"},
+		{"lista", "- um\n- dois\n\n1. primeiro", "<ul>\n<li>um</li>\n<li>dois</li>\n</ul>\n<ol>\n<li>primeiro</li>\n</ol>"},
+		{"citação", "> citado\n> **junto**", "<blockquote>\n<p>citado<br>\n<strong>junto</strong></p>\n</blockquote>"},
+		{"linha horizontal", "---", "<hr>"},
+		{"bloco de código", "```\n<b>**x**</b>\n```", "<pre><code>&lt;b&gt;**x**&lt;/b&gt;</code></pre>"},
+		{"trecho de código não recebe ênfase", "use `**a** <b>`", "<p>use <code>**a** &lt;b&gt;</code></p>"},
+		{
+			"link seguro",
+			"[proposta](https://exemplo.com/p?a=1&b=2)",
+			`<p><a href="https://exemplo.com/p?a=1&amp;b=2" rel="nofollow noopener noreferrer">proposta</a></p>`,
+		},
+		{"link relativo", "[ver](/negociacoes/1)", `<p><a href="/negociacoes/1" rel="nofollow noopener noreferrer">ver</a></p>`},
+
+		// HTML e links perigosos nunca chegam à saída.
+		{"HTML escapado", `<script>alert("x")</script>`, "<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;</p>"},
+		{"atributo escapado", `<img src=x onerror=alert(1)>`, "<p>&lt;img src=x onerror=alert(1)&gt;</p>"},
+		{"link javascript", "[clique](javascript:alert(1))", "<p>clique)</p>"},
+		{"link data", "[clique](data:text/html;base64,PHNjcmlwdD4=)", "<p>clique</p>"},
+		{"link para outro domínio sem esquema", "[clique](//malicioso.com)", "<p>clique</p>"},
+		{"aspas na URL", `[x](https://a.com/"onmouseover="alert(1))`, `<p><a href="https://a.com/&#34;onmouseover=&#34;alert(1" rel="nofollow noopener noreferrer">x</a>)</p>`},
+		{"HTML no texto do link", "[<b>x</b>](https://a.com)", `<p><a href="https://a.com" rel="nofollow noopener noreferrer">&lt;b&gt;x&lt;/b&gt;</a></p>`},
+		{"marcador interno digitado", "a\x000\x00b", "<p>a0b</p>"},
+
+		// Menções e referências.
+		{"menção", "falar com @ana", `<p>falar com <span class="mencao" data-usuario="ana">@ana</span></p>`},
+		{"menção desconhecida", "falar com @carlos", "<p>falar com @carlos</p>"},
+		{"e-mail não é menção", "ana@ana.com", "<p>ana@ana.com</p>"},
+		{
+			"referência",
+			"ver #negociacao-123",
+			`<p>ver <a href="/negociacoes/123" class="referencia" data-entidade="negociacao" data-id="123">#negociacao-123</a></p>`,
+		},
+		{"referência desconhecida", "ver #negociacao-999", "<p>ver #negociacao-999</p>"},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			got := Renderizar(c.texto, resolvedorTeste).HTML
+			if got != c.html {
+				t.Errorf("Renderizar(%q) =\n%s\nesperado\n%s", c.texto, got, c.html)
+			}
+			if strings.Contains(strings.ToLower(got), "<script") || strings.Contains(got, "javascript:") {
+				t.Errorf("Renderizar(%q) emitiu conteúdo perigoso: %s", c.texto, got)
+			}
+		})
+	}
+}
+
+func TestRenderizarMencoes(t *testing.T) {
+	doc := Renderizar("@bruno.silva, @ana e @carlos; de novo @ana\n```\n@ana\n```", resolvedorTeste)
+	esperado := []string{"bruno.silva", "ana"}
+	if !reflect.DeepEqual(doc.Mencoes, esperado) {
+		t.Errorf("Mencoes = %v; esperado %v", doc.Mencoes, esperado)
+	}
+}
+
+func TestRenderizarSemResolvedor(t *testing.T) {
+	got := Renderizar("@ana em #negociacao-123", nil).HTML
+	if esperado := "<p>@ana em #negociacao-123</p>"; got != esperado {
+		t.Errorf("Renderizar sem resolvedor = %s; esperado %s", got, esperado)
+	}
+}
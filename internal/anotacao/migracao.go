@@ -0,0 +1,35 @@
+package anotacao
+
+import "gorm.io/gorm"
+
+// MigrarEntidades converte as anotações gravadas com a antiga coluna empresa_id
+// para o vínculo genérico (entidade, entidade_id) e remove a coluna. Pode ser
+// executada a cada inicialização: sem a coluna antiga, nada é feito.
+func MigrarEntidades(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&Anotacao{}, "empresa_id") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`UPDATE anotacoes SET entidade = ?, entidade_id = empresa_id
+			WHERE (entidade IS NULL OR entidade = '') AND empresa_id IS NOT NULL`, EntidadeEmpresa).Error; err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&Anotacao{}, "empresa_id")
+	})
+}
+
+// MigrarHTML renderiza as anotações gravadas antes do suporte a Markdown, sem
+// gerar notificações. Retorna a quantidade de anotações renderizadas.
+func MigrarHTML(db *gorm.DB) (int, error) {
+	var anotacoes []Anotacao
+	if err := db.Where("(html IS NULL OR html = '') AND anotacao <> ''").Find(&anotacoes).Error; err != nil {
+		return 0, err
+	}
+	for _, a := range anotacoes {
+		doc := Renderizar(a.Anotacao, resolvedor{db})
+		if err := db.Model(&a).UpdateColumn("html", doc.HTML).Error; err != nil {
+			return 0, err
+		}
+	}
+	return len(anotacoes), nil
+}
@@ -6,14 +6,66 @@ import (
 	"gorm.io/gorm"
 )
 
-// Anotacao representa a estrutura de uma anotação associada a uma empresa.
+// Tipos de entidade que podem receber anotações.
+const (
+	EntidadeEmpresa    = "empresa"
+	EntidadeNegociacao = "negociacao"
+	EntidadeContato    = "contato"
+	EntidadeTarefa     = "tarefa"
+)
+
+// tabelas relaciona cada tipo de entidade à tabela em que ela é gravada. Os
+// pacotes das entidades importam anotacao, por isso a existência do registro é
+// conferida diretamente na tabela.
+var tabelas = map[string]string{
+	EntidadeEmpresa:    "empresas",
+	EntidadeNegociacao: "negociacoes",
+	EntidadeContato:    "contatos",
+	EntidadeTarefa:     "tarefas",
+}
+
+// Anotacao representa uma anotação associada a uma empresa, negociação, contato
+// ou tarefa. A entidade é identificada pelo par (Entidade, EntidadeID).
+// O texto é escrito em Markdown; HTML guarda a versão renderizada no servidor.
 type Anotacao struct {
-	ID        int            `json:"id" gorm:"primaryKey;autoIncrement"`
-	Data      time.Time      `json:"data"`
-	Assunto   string         `json:"assunto"`
-	Anotacao  string         `json:"anotacao"`
-	EmpresaID int            `json:"empresa_id"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	ID         int            `json:"id" gorm:"primaryKey;autoIncrement"`
+	Data       time.Time      `json:"data"`
+	Assunto    string         `json:"assunto"`
+	Anotacao   string         `json:"anotacao"`
+	HTML       string         `json:"html" gorm:"column:html"`
+	Entidade   string         `json:"entidade" gorm:"size:20;index:idx_anotacao_entidade"`
+	EntidadeID int            `json:"entidade_id" gorm:"index:idx_anotacao_entidade"`
+	Autor      string         `json:"autor"`
+	Fixada     bool           `json:"fixada" gorm:"not null;default:false"`
+	FixadaEm   *time.Time     `json:"fixada_em,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	Revisoes []Revisao `json:"revisoes,omitempty" gorm:"foreignKey:AnotacaoID"`
+
+	// EditadoPor é o login de quem altera a anotação; não é gravado na anotação,
+	// apenas na revisão criada pela alteração.
+	EditadoPor string `json:"editado_por,omitempty" gorm:"-"`
+}
+
+// Revisao guarda a versão anterior de uma anotação a cada edição.
+type Revisao struct {
+	ID         int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	AnotacaoID int       `json:"anotacao_id" gorm:"index;not null"`
+	Assunto    string    `json:"assunto"`
+	Anotacao   string    `json:"anotacao"`
+	EditadoPor string    `json:"editado_por"`
+	Data       time.Time `json:"data"` // Momento da edição que substituiu esta versão
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Revisao) TableName() string {
+	return "anotacao_revisoes"
+}
+
+// EntidadeValida indica se o tipo de entidade aceita anotações.
+func EntidadeValida(entidade string) bool {
+	_, ok := tabelas[entidade]
+	return ok
 }
@@ -2,8 +2,13 @@ package anotacao
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+
+	"my-crm-backend/internal/notificacao"
+	"my-crm-backend/internal/usuario"
 )
 
 // Repository define as operações básicas para manipulação de anotações.
@@ -13,8 +18,17 @@ type Repository interface {
 	ObterPorID(id int) (*Anotacao, error)
 	Atualizar(id int, updated Anotacao) (Anotacao, error)
 	Deletar(id int) error
+	ListarPorEntidade(entidade string, id int) ([]Anotacao, error)
+	Fixar(id int, fixada bool) (Anotacao, error)
+	ListarRevisoes(id int) ([]Revisao, error)
 }
 
+// Erros de vínculo da anotação com a sua entidade.
+var (
+	ErrEntidadeInvalida      = errors.New("entidade inválida")
+	ErrEntidadeNaoEncontrada = errors.New("entidade não encontrada")
+)
+
 type repository struct {
 	db *gorm.DB
 }
@@ -24,35 +38,208 @@ func NovoRepositorio(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
+// Adicionar grava a anotação depois de conferir que a entidade indicada existe,
+// renderiza o Markdown e notifica os usuários mencionados.
 func (r *repository) Adicionar(a Anotacao) (Anotacao, error) {
-	err := r.db.Create(&a).Error
+	if err := conferirEntidade(r.db, a.Entidade, a.EntidadeID); err != nil {
+		return a, err
+	}
+	if a.Data.IsZero() {
+		a.Data = time.Now()
+	}
+	a.FixadaEm = nil
+	if a.Fixada {
+		agora := time.Now()
+		a.FixadaEm = &agora
+	}
+	a.Revisoes = nil
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		doc := Renderizar(a.Anotacao, resolvedor{tx})
+		a.HTML = doc.HTML
+		if err := tx.Create(&a).Error; err != nil {
+			return err
+		}
+		return notificarMencoes(tx, a, doc.Mencoes, a.Autor)
+	})
 	return a, err
 }
 
+// notificarMencoes avisa os usuários mencionados na anotação, exceto quem a escreveu.
+func notificarMencoes(tx *gorm.DB, a Anotacao, mencoes []string, autor string) error {
+	for _, login := range mencoes {
+		if login == autor {
+			continue
+		}
+		titulo := "Você foi mencionado em uma anotação"
+		if autor != "" {
+			titulo = autor + " mencionou você em uma anotação"
+		}
+		if err := notificacao.Notificar(tx, notificacao.Notificacao{
+			Usuario:    login,
+			Tipo:       "mencao",
+			Titulo:     titulo,
+			Mensagem:   fmt.Sprintf("%s (%s %d)", a.Assunto, a.Entidade, a.EntidadeID),
+			Entidade:   "anotacao",
+			EntidadeID: a.ID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolvedor confere no banco as menções e referências das anotações.
+type resolvedor struct {
+	db *gorm.DB
+}
+
+func (r resolvedor) UsuarioExiste(login string) bool {
+	var total int64
+	r.db.Model(&usuario.Usuario{}).Where("login = ?", login).Count(&total)
+	return total > 0
+}
+
+func (r resolvedor) EntidadeExiste(entidade string, id int) bool {
+	return conferirEntidade(r.db, entidade, id) == nil
+}
+
+// conferirEntidade valida o tipo da entidade e verifica se o registro existe.
+func conferirEntidade(db *gorm.DB, entidade string, id int) error {
+	tabela, ok := tabelas[entidade]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrEntidadeInvalida, entidade)
+	}
+	var total int64
+	err := db.Table(tabela).Where("id = ? AND deleted_at IS NULL", id).Count(&total).Error
+	if err != nil {
+		return err
+	}
+	if total == 0 {
+		return fmt.Errorf("%w: %s %d", ErrEntidadeNaoEncontrada, entidade, id)
+	}
+	return nil
+}
+
+// Ordenadas lista as anotações fixadas primeiro e, depois, das mais recentes
+// para as mais antigas. Pode ser usada também em Preload("Anotacoes", ...).
+func Ordenadas(db *gorm.DB) *gorm.DB {
+	return db.Order("fixada DESC, fixada_em DESC, data DESC, id DESC")
+}
+
 func (r *repository) Listar() ([]Anotacao, error) {
 	var anotacoes []Anotacao
-	err := r.db.Find(&anotacoes).Error
+	err := Ordenadas(r.db).Find(&anotacoes).Error
+	return anotacoes, err
+}
+
+// ListarPorEntidade retorna as anotações de uma entidade, fixadas primeiro.
+func (r *repository) ListarPorEntidade(entidade string, id int) ([]Anotacao, error) {
+	if err := conferirEntidade(r.db, entidade, id); err != nil {
+		return nil, err
+	}
+	anotacoes := []Anotacao{}
+	err := Ordenadas(r.db).Where("entidade = ? AND entidade_id = ?", entidade, id).Find(&anotacoes).Error
 	return anotacoes, err
 }
 
 func (r *repository) ObterPorID(id int) (*Anotacao, error) {
 	var a Anotacao
-	err := r.db.First(&a, id).Error
+	err := r.db.Preload("Revisoes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("data DESC, id DESC")
+	}).First(&a, id).Error
 	if err != nil {
 		return nil, errors.New("Anotação não encontrada")
 	}
 	return &a, nil
 }
 
+// ListarRevisoes retorna as versões anteriores da anotação, da mais recente para a mais antiga.
+func (r *repository) ListarRevisoes(id int) ([]Revisao, error) {
+	a, err := r.ObterPorID(id)
+	if err != nil {
+		return nil, err
+	}
+	if a.Revisoes == nil {
+		return []Revisao{}, nil
+	}
+	return a.Revisoes, nil
+}
+
 func (r *repository) Atualizar(id int, updated Anotacao) (Anotacao, error) {
 	var a Anotacao
 	err := r.db.First(&a, id).Error
 	if err != nil {
 		return Anotacao{}, errors.New("Anotação não encontrada")
 	}
-	updated.ID = id
-	err = r.db.Model(&a).Updates(updated).Error
-	return updated, err
+	anterior := a
+	if updated.Anotacao == "" {
+		updated.Anotacao = a.Anotacao
+	}
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		res := resolvedor{tx}
+		doc := Renderizar(updated.Anotacao, res)
+		updated.HTML = doc.HTML
+		updated.Revisoes = nil
+		// A entidade, o autor e a fixação não mudam por aqui: a fixação tem rota própria.
+		if err := tx.Model(&a).Omit("entidade", "entidade_id", "autor", "fixada", "fixada_em").Updates(updated).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&a, id).Error; err != nil {
+			return err
+		}
+		if a.Assunto == anterior.Assunto && a.Anotacao == anterior.Anotacao {
+			return nil
+		}
+		if err := tx.Create(&Revisao{
+			AnotacaoID: id,
+			Assunto:    anterior.Assunto,
+			Anotacao:   anterior.Anotacao,
+			EditadoPor: updated.EditadoPor,
+			Data:       time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		// Só os usuários mencionados pela primeira vez nesta edição são notificados.
+		jaMencionados := map[string]bool{}
+		for _, login := range Renderizar(anterior.Anotacao, res).Mencoes {
+			jaMencionados[login] = true
+		}
+		var novas []string
+		for _, login := range doc.Mencoes {
+			if !jaMencionados[login] {
+				novas = append(novas, login)
+			}
+		}
+		editor := updated.EditadoPor
+		if editor == "" {
+			editor = a.Autor
+		}
+		return notificarMencoes(tx, a, novas, editor)
+	})
+	if err != nil {
+		return Anotacao{}, err
+	}
+	return a, nil
+}
+
+// Fixar marca ou desmarca a anotação como importante. Anotações fixadas
+// aparecem no topo das listagens.
+func (r *repository) Fixar(id int, fixada bool) (Anotacao, error) {
+	var a Anotacao
+	if err := r.db.First(&a, id).Error; err != nil {
+		return Anotacao{}, errors.New("Anotação não encontrada")
+	}
+	var fixadaEm *time.Time
+	if fixada {
+		agora := time.Now()
+		fixadaEm = &agora
+	}
+	err := r.db.Model(&a).Updates(map[string]interface{}{"fixada": fixada, "fixada_em": fixadaEm}).Error
+	if err != nil {
+		return Anotacao{}, err
+	}
+	err = r.db.First(&a, id).Error
+	return a, err
 }
 
 func (r *repository) Deletar(id int) error {
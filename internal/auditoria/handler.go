@@ -0,0 +1,38 @@
+package auditoria
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler define os manipuladores HTTP para consulta da auditoria.
+type Handler struct {
+	repo Repository
+}
+
+// NovoHandler cria e retorna um novo handler para Auditoria.
+func NovoHandler(repo Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+// Listar retorna a trilha de auditoria.
+// Aceita os filtros opcionais ?entidade=empresa&entidade_id=1.
+func (h *Handler) Listar(c *gin.Context) {
+	entidadeID := 0
+	if v := c.Query("entidade_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "entidade_id inválido"})
+			return
+		}
+		entidadeID = id
+	}
+	registros, err := h.repo.Listar(c.Query("entidade"), entidadeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, registros)
+}
@@ -0,0 +1,23 @@
+package auditoria
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Auditoria registra uma operação relevante realizada sobre uma entidade do CRM.
+type Auditoria struct {
+	ID         int            `json:"id" gorm:"primaryKey;autoIncrement"`
+	Entidade   string         `json:"entidade" gorm:"index:idx_auditoria_entidade"`
+	EntidadeID int            `json:"entidade_id" gorm:"index:idx_auditoria_entidade"`
+	Acao       string         `json:"acao"`
+	Usuario    string         `json:"usuario,omitempty"`
+	Detalhes   datatypes.JSON `json:"detalhes,omitempty"`
+	Data       time.Time      `json:"data"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Auditoria) TableName() string {
+	return "auditorias"
+}
@@ -0,0 +1,71 @@
+package auditoria
+
+import (
+	"encoding/json"
+	"time"
+
+	"my-crm-backend/internal/evento"
+
+	"gorm.io/gorm"
+)
+
+// Repository define as operações de consulta da trilha de auditoria.
+type Repository interface {
+	Listar(entidade string, entidadeID int) ([]Auditoria, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NovoRepositorio cria e retorna um repositório baseado em GORM.
+func NovoRepositorio(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Listar retorna os registros de auditoria, opcionalmente filtrados por entidade, do mais recente ao mais antigo.
+func (r *repository) Listar(entidade string, entidadeID int) ([]Auditoria, error) {
+	var registros []Auditoria
+	query := r.db.Order("data DESC")
+	if entidade != "" {
+		query = query.Where("entidade = ?", entidade)
+	}
+	if entidadeID != 0 {
+		query = query.Where("entidade_id = ?", entidadeID)
+	}
+	err := query.Find(&registros).Error
+	return registros, err
+}
+
+// Registrar grava um registro de auditoria usando a conexão (ou transação) informada,
+// permitindo que o registro faça parte da mesma transação da operação auditada.
+func Registrar(tx *gorm.DB, entidade string, entidadeID int, acao, usuario string, detalhes interface{}) error {
+	a := Auditoria{
+		Entidade:   entidade,
+		EntidadeID: entidadeID,
+		Acao:       acao,
+		Usuario:    usuario,
+		Data:       time.Now(),
+	}
+	if detalhes != nil {
+		dados, err := json.Marshal(detalhes)
+		if err != nil {
+			return err
+		}
+		a.Detalhes = dados
+	}
+	return tx.Create(&a).Error
+}
+
+// RegistrarEvento grava um evento de domínio na trilha de auditoria, com o tipo
+// do evento como ação. É registrado como assinante do barramento de eventos.
+func RegistrarEvento(tx *gorm.DB, e evento.Evento) error {
+	return tx.Create(&Auditoria{
+		Entidade:   e.Entidade,
+		EntidadeID: e.EntidadeID,
+		Acao:       e.Tipo,
+		Usuario:    e.Usuario,
+		Detalhes:   e.Dados,
+		Data:       e.CreatedAt,
+	}).Error
+}
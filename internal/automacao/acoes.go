@@ -0,0 +1,186 @@
+package automacao
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"my-crm-backend/internal/anotacao"
+	"my-crm-backend/internal/evento"
+	"my-crm-backend/internal/negociacao"
+	"my-crm-backend/internal/notificacao"
+	"my-crm-backend/internal/tarefa"
+
+	"gorm.io/gorm"
+)
+
+// aplicar executa uma ação sobre a entidade usando os repositórios de cada
+// domínio na transação informada, e descreve o que foi feito.
+func (m *Motor) aplicar(tx *gorm.DB, regra Regra, a Acao, entidade string, id int, dados Dados) (string, error) {
+	switch a.Tipo {
+	case AcaoCriarTarefa:
+		return m.criarTarefa(tx, a, entidade, id, dados)
+	case AcaoAlterarResponsavel:
+		return m.alterarResponsavel(tx, a.Usuario, entidade, id, dados)
+	case AcaoAlterarStatus:
+		return m.alterarStatus(tx, a.Status, entidade, id)
+	case AcaoAdicionarAnotacao:
+		assunto := dados.Preencher(a.Titulo)
+		if assunto == "" {
+			assunto = regra.Nome
+		}
+		nota, err := anotacao.NovoRepositorio(tx).Adicionar(anotacao.Anotacao{
+			Assunto:    assunto,
+			Anotacao:   dados.Preencher(a.Mensagem),
+			Entidade:   entidade,
+			EntidadeID: id,
+			Autor:      Usuario,
+		})
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("anotação %d adicionada", nota.ID), nil
+	case AcaoNotificar:
+		destino := a.Usuario
+		if destino == "" {
+			destino = dados.Texto("responsavel")
+		}
+		if destino == "" {
+			return "sem responsável definido; ninguém foi notificado", nil
+		}
+		if err := notificacao.Notificar(tx, notificacao.Notificacao{
+			Usuario:    destino,
+			Tipo:       "automacao",
+			Titulo:     dados.Preencher(a.Titulo),
+			Mensagem:   dados.Preencher(a.Mensagem),
+			Entidade:   entidade,
+			EntidadeID: id,
+		}); err != nil {
+			return "", err
+		}
+		return "notificado: " + destino, nil
+	case AcaoDispararWebhook:
+		if err := evento.Publicar(tx, evento.AutomacaoDisparada, entidade, id, Usuario, map[string]interface{}{
+			"regra":    map[string]interface{}{"id": regra.ID, "nome": regra.Nome},
+			"mensagem": dados.Preencher(a.Mensagem),
+			entidade:   dados,
+		}); err != nil {
+			return "", err
+		}
+		return "evento " + evento.AutomacaoDisparada + " publicado", nil
+	}
+	return "", fmt.Errorf("tipo de ação desconhecido: %q", a.Tipo)
+}
+
+// criarTarefa cria a tarefa vinculada à negociação, à negociação da tarefa ou à empresa.
+func (m *Motor) criarTarefa(tx *gorm.DB, a Acao, entidade string, id int, dados Dados) (string, error) {
+	t := tarefa.Tarefa{
+		Assunto:     dados.Preencher(a.Assunto),
+		Descricao:   dados.Preencher(a.Descricao),
+		Tipo:        a.TipoTarefa,
+		Prioridade:  a.Prioridade,
+		Responsavel: a.Usuario,
+	}
+	if t.Responsavel == "" {
+		t.Responsavel = dados.Texto("responsavel")
+	}
+	if t.Responsavel == "" {
+		return "", errors.New("sem responsável para a tarefa")
+	}
+	loc, err := m.fusos.Fuso(t.Responsavel)
+	if err != nil {
+		return "", err
+	}
+	dia := m.agora().In(loc).AddDate(0, 0, a.DiasApos)
+	if a.Horario == "" {
+		t.DiaInteiro = true
+		t.Inicio = dia
+	} else {
+		var h, min int
+		fmt.Sscanf(a.Horario, "%d:%d", &h, &min)
+		t.Inicio = time.Date(dia.Year(), dia.Month(), dia.Day(), h, min, 0, 0, loc)
+	}
+	t.FusoHorario = loc.String()
+	if err := tarefa.Preparar(&t, m.fusos); err != nil {
+		return "", err
+	}
+
+	switch entidade {
+	case "negociacao":
+		t, err = negociacao.NovoRepositorio(tx).AdicionarTarefa(id, t)
+	case "tarefa":
+		var origem tarefa.Tarefa
+		if err := tx.First(&origem, id).Error; err != nil {
+			return "", errors.New("Tarefa not found")
+		}
+		t.NegociacaoID = origem.NegociacaoID
+		t.Negociacao = origem.Negociacao
+		t.EmpresaID = origem.EmpresaID
+		t.EmpresaNegociacao = origem.EmpresaNegociacao
+		t, err = tarefa.NovoRepositorio(tx).Adicionar(t)
+	case "empresa":
+		t.EmpresaID = id
+		t.EmpresaNegociacao = dados.Texto("nome")
+		t, err = tarefa.NovoRepositorio(tx).Adicionar(t)
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("tarefa %d criada para %s: %s", t.ID, t.Responsavel, t.Assunto), nil
+}
+
+// alterarResponsavel passa a negociação, com as suas tarefas abertas, ou a
+// tarefa para outro usuário.
+func (m *Motor) alterarResponsavel(tx *gorm.DB, novo, entidade string, id int, dados Dados) (string, error) {
+	anterior := dados.Texto("responsavel")
+	if anterior == novo {
+		return "responsável mantido: " + novo, nil
+	}
+	switch entidade {
+	case "negociacao":
+		var err error
+		if anterior, err = negociacao.NovoRepositorio(tx).AtualizarResponsavel(id, novo, Usuario); err != nil {
+			return "", err
+		}
+	case "tarefa":
+		if err := m.atualizarTarefa(tx, id, func(t *tarefa.Tarefa) { t.Responsavel = novo }); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("%s não tem responsável", entidade)
+	}
+	return fmt.Sprintf("responsável alterado de %s para %s", anterior, novo), nil
+}
+
+// alterarStatus muda o status da negociação ou da tarefa.
+func (m *Motor) alterarStatus(tx *gorm.DB, status, entidade string, id int) (string, error) {
+	switch entidade {
+	case "negociacao":
+		if _, err := negociacao.NovoRepositorio(tx).AtualizarStatus(id, status, Usuario); err != nil {
+			return "", err
+		}
+	case "tarefa":
+		if err := m.atualizarTarefa(tx, id, func(t *tarefa.Tarefa) { t.Status = status }); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("%s não tem status", entidade)
+	}
+	return "status alterado para " + status, nil
+}
+
+// atualizarTarefa aplica a alteração à tarefa e a grava como na API.
+func (m *Motor) atualizarTarefa(tx *gorm.DB, id int, alterar func(t *tarefa.Tarefa)) error {
+	repo := tarefa.NovoRepositorio(tx)
+	t, err := repo.ObterPorID(id)
+	if err != nil {
+		return err
+	}
+	alterar(t)
+	t.AlteradoPor = Usuario
+	if err := tarefa.Preparar(t, m.fusos); err != nil {
+		return err
+	}
+	_, err = repo.Atualizar(id, *t)
+	return err
+}
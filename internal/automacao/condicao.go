@@ -0,0 +1,154 @@
+package automacao
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Dados são os campos da entidade no formato JSON da API, usados para avaliar
+// as condições e preencher os textos das ações.
+type Dados map[string]interface{}
+
+// Campo retorna o valor de um campo, seguindo os pontos em campos aninhados.
+func (d Dados) Campo(caminho string) (interface{}, bool) {
+	var atual interface{} = map[string]interface{}(d)
+	for _, parte := range strings.Split(caminho, ".") {
+		m, ok := atual.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if atual, ok = m[parte]; !ok {
+			return nil, false
+		}
+	}
+	return atual, true
+}
+
+// Texto retorna o valor de um campo como texto; vazio quando ausente.
+func (d Dados) Texto(caminho string) string {
+	v, ok := d.Campo(caminho)
+	if !ok || v == nil {
+		return ""
+	}
+	return texto(v)
+}
+
+// Atende informa se todas as condições são atendidas. Quando não são, retorna
+// a primeira condição que falhou, descrita para o registro da execução.
+func (d Dados) Atende(condicoes []Condicao) (bool, string) {
+	for _, c := range condicoes {
+		v, ok := d.Campo(c.Campo)
+		if !avaliar(v, ok, c) {
+			return false, fmt.Sprintf("condição não atendida: %s %s %q (valor atual: %q)", c.Campo, c.Operador, c.Valor, texto(v))
+		}
+	}
+	return true, ""
+}
+
+func avaliar(v interface{}, existe bool, c Condicao) bool {
+	switch c.Operador {
+	case OperadorVazio:
+		return !existe || vazio(v)
+	case OperadorPreenchido:
+		return existe && !vazio(v)
+	case OperadorContem:
+		if lista, ok := v.([]interface{}); ok {
+			for _, item := range lista {
+				if strings.EqualFold(texto(item), c.Valor) {
+					return true
+				}
+			}
+			return false
+		}
+		return strings.Contains(strings.ToLower(texto(v)), strings.ToLower(c.Valor))
+	case OperadorEm:
+		for _, item := range strings.Split(c.Valor, ",") {
+			if comparar(v, strings.TrimSpace(item)) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+	if !existe {
+		return c.Operador == OperadorDiferente
+	}
+	r := comparar(v, c.Valor)
+	switch c.Operador {
+	case OperadorIgual:
+		return r == 0
+	case OperadorDiferente:
+		return r != 0
+	case OperadorMaior:
+		return r > 0
+	case OperadorMaiorIgual:
+		return r >= 0
+	case OperadorMenor:
+		return r < 0
+	case OperadorMenorIgual:
+		return r <= 0
+	}
+	return false
+}
+
+// comparar compara numericamente quando os dois lados são números e, caso
+// contrário, como texto sem diferenciar maiúsculas. Datas no formato ISO
+// (AAAA-MM-DD...) ficam na ordem certa na comparação de texto.
+func comparar(v interface{}, valor string) int {
+	if n, ok := v.(float64); ok {
+		if m, err := strconv.ParseFloat(valor, 64); err == nil {
+			switch {
+			case n < m:
+				return -1
+			case n > m:
+				return 1
+			}
+			return 0
+		}
+	}
+	if b, ok := v.(bool); ok {
+		if m, err := strconv.ParseBool(valor); err == nil {
+			if b == m {
+				return 0
+			}
+			return 1
+		}
+	}
+	return strings.Compare(strings.ToLower(texto(v)), strings.ToLower(valor))
+}
+
+func vazio(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case []interface{}:
+		return len(t) == 0
+	case map[string]interface{}:
+		return len(t) == 0
+	}
+	return false
+}
+
+func texto(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+var marcador = regexp.MustCompile(`\{\{\s*([\w.]+)\s*\}\}`)
+
+// Preencher substitui os marcadores {{campo}} do texto pelos valores da entidade.
+func (d Dados) Preencher(s string) string {
+	return marcador.ReplaceAllStringFunc(s, func(m string) string {
+		return d.Texto(marcador.FindStringSubmatch(m)[1])
+	})
+}
@@ -0,0 +1,160 @@
+package automacao
+
+import (
+	"encoding/json"
+	"testing"
+
+	"my-crm-backend/internal/evento"
+)
+
+func dadosTeste(t *testing.T, texto string) Dados {
+	t.Helper()
+	var d Dados
+	if err := json.Unmarshal([]byte(texto), &d); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestAtende(t *testing.T) {
+	d := dadosTeste(t, `{
+		"nome_negociacao": "Renovação Frota",
+		"valor_negociacao": 15000.5,
+		"responsavel": "Ana",
+		"prioritaria": true,
+		"observacao": "",
+		"contato_id": null,
+		"data_vencimento_apolice": "2024-06-30T00:00:00Z",
+		"etiquetas": ["Frota", "renovacao"],
+		"empresa": {"nome": "Transportes Silva", "segmento": "Logística", "funcionarios": 250},
+		"campos_personalizados": {"origem": "indicação"}
+	}`)
+	casos := []struct {
+		campo, operador, valor string
+		esperado               bool
+	}{
+		{"responsavel", OperadorIgual, "ana", true},
+		{"responsavel", OperadorIgual, "bruno", false},
+		{"responsavel", OperadorDiferente, "bruno", true},
+		{"inexistente", OperadorDiferente, "x", true},
+		{"inexistente", OperadorIgual, "", false},
+
+		{"valor_negociacao", OperadorMaior, "15000", true},
+		{"valor_negociacao", OperadorMaior, "9000", true}, // Numérico, não textual
+		{"valor_negociacao", OperadorMenor, "100000", true},
+		{"valor_negociacao", OperadorMaiorIgual, "15000.5", true},
+		{"valor_negociacao", OperadorMenorIgual, "15000.49", false},
+		{"empresa.funcionarios", OperadorIgual, "250", true},
+
+		{"prioritaria", OperadorIgual, "true", true},
+		{"prioritaria", OperadorDiferente, "false", true},
+
+		{"data_vencimento_apolice", OperadorMenor, "2024-07-01", true},
+		{"data_vencimento_apolice", OperadorMaiorIgual, "2024-07-01", false},
+
+		{"nome_negociacao", OperadorContem, "FROTA", true},
+		{"etiquetas", OperadorContem, "frota", true},
+		{"etiquetas", OperadorContem, "fro", false}, // Em listas, o item inteiro
+		{"empresa.segmento", OperadorEm, "Varejo, logística", true},
+		{"empresa.segmento", OperadorEm, "Varejo,Indústria", false},
+
+		{"observacao", OperadorVazio, "", true},
+		{"contato_id", OperadorVazio, "", true},
+		{"inexistente", OperadorVazio, "", true},
+		{"etiquetas", OperadorPreenchido, "", true},
+		{"observacao", OperadorPreenchido, "", false},
+		{"campos_personalizados.origem", OperadorPreenchido, "", true},
+		{"campos_personalizados.origem.x", OperadorPreenchido, "", false},
+	}
+	for _, c := range casos {
+		cond := Condicao{Campo: c.campo, Operador: c.operador, Valor: c.valor}
+		if ok, motivo := d.Atende([]Condicao{cond}); ok != c.esperado {
+			t.Errorf("%s %s %q = %v (%s); esperado %v", c.campo, c.operador, c.valor, ok, motivo, c.esperado)
+		}
+	}
+
+	ok, motivo := d.Atende([]Condicao{
+		{Campo: "responsavel", Operador: OperadorIgual, Valor: "Ana"},
+		{Campo: "empresa.funcionarios", Operador: OperadorMaior, Valor: "500"},
+	})
+	esperado := `condição não atendida: empresa.funcionarios maior "500" (valor atual: "250")`
+	if ok || motivo != esperado {
+		t.Errorf("Atende = %v, %q; esperado false, %q", ok, motivo, esperado)
+	}
+	if ok, _ := d.Atende(nil); !ok {
+		t.Error("Atende sem condições deveria ser atendida")
+	}
+}
+
+func TestPreencher(t *testing.T) {
+	d := dadosTeste(t, `{"valor_negociacao": 1500, "empresa": {"nome": "Silva & Filhos"}}`)
+	got := d.Preencher("Ligar para {{ empresa.nome }} sobre {{valor_negociacao}} ({{inexistente}})")
+	if esperado := "Ligar para Silva & Filhos sobre 1500 ()"; got != esperado {
+		t.Errorf("Preencher = %q; esperado %q", got, esperado)
+	}
+}
+
+func TestDispara(t *testing.T) {
+	casos := []struct {
+		nome     string
+		regra    Regra
+		tipo     string
+		dados    string
+		esperado bool
+	}{
+		{
+			nome:     "entrada na etapa ao criar",
+			regra:    Regra{Gatilho: GatilhoEntradaEtapa, Etapa: "Proposta"},
+			tipo:     evento.NegociacaoCriada,
+			dados:    `{"etapa_funil_vendas": "Proposta", "funil_vendas": "Seguros"}`,
+			esperado: true,
+		},
+		{
+			nome:     "entrada na etapa em outro funil",
+			regra:    Regra{Gatilho: GatilhoEntradaEtapa, Etapa: "Proposta", Funil: "Consórcio"},
+			tipo:     evento.NegociacaoEtapaAlterada,
+			dados:    `{"etapa_atual": "Proposta", "negociacao": {"funil_vendas": "Seguros"}}`,
+			esperado: false,
+		},
+		{
+			nome:     "entrada na etapa ao mudar de etapa",
+			regra:    Regra{Gatilho: GatilhoEntradaEtapa, Etapa: "Proposta", Funil: "Seguros"},
+			tipo:     evento.NegociacaoEtapaAlterada,
+			dados:    `{"etapa_atual": "Proposta", "negociacao": {"funil_vendas": "Seguros"}}`,
+			esperado: true,
+		},
+		{
+			nome:     "valor acima ao criar",
+			regra:    Regra{Gatilho: GatilhoValorAcima, Valor: 10000},
+			tipo:     evento.NegociacaoCriada,
+			dados:    `{"valor_negociacao": 10000.01}`,
+			esperado: true,
+		},
+		{
+			nome:     "valor cruza o limite",
+			regra:    Regra{Gatilho: GatilhoValorAcima, Valor: 10000},
+			tipo:     evento.NegociacaoValoresAlterados,
+			dados:    `{"valor_anterior": 10000, "valor_atual": 12000}`,
+			esperado: true,
+		},
+		{
+			nome:     "valor já estava acima",
+			regra:    Regra{Gatilho: GatilhoValorAcima, Valor: 10000},
+			tipo:     evento.NegociacaoValoresAlterados,
+			dados:    `{"valor_anterior": 11000, "valor_atual": 12000}`,
+			esperado: false,
+		},
+		{
+			nome:     "tarefa atrasada",
+			regra:    Regra{Gatilho: GatilhoTarefaAtrasada},
+			tipo:     evento.TarefaAtrasada,
+			dados:    `{}`,
+			esperado: true,
+		},
+	}
+	for _, c := range casos {
+		if got := dispara(c.regra, c.tipo, dadosTeste(t, c.dados)); got != c.esperado {
+			t.Errorf("%s: dispara = %v; esperado %v", c.nome, got, c.esperado)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package automacao
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler define os manipuladores HTTP para as regras de automação.
+type Handler struct {
+	repo  Repository
+	motor *Motor
+}
+
+// NovoHandler cria e retorna um novo handler de automações.
+func NovoHandler(repo Repository, motor *Motor) *Handler {
+	return &Handler{repo: repo, motor: motor}
+}
+
+// Metadados retorna os gatilhos, operadores e tipos de ação aceitos nas regras.
+func (h *Handler) Metadados(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"gatilhos":   Gatilhos,
+		"operadores": Operadores,
+		"acoes":      Acoes,
+	})
+}
+
+// Criar insere uma nova regra de automação.
+// Espera receber um JSON como: {"nome": "Proposta grande", "gatilho": "negociacao_entrou_etapa",
+// "etapa": "Proposta", "condicoes": [{"campo": "valor_negociacao", "operador": "maior", "valor": "50000"}],
+// "acoes": [{"tipo": "notificar", "usuario": "gestor", "titulo": "Proposta de {{nome_negociacao}}"}]}
+func (h *Handler) Criar(c *gin.Context) {
+	r := Regra{Ativa: true}
+	if err := c.ShouldBindJSON(&r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := Validar(r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	criada, err := h.repo.Adicionar(r)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, criada)
+}
+
+// Listar retorna as regras, opcionalmente filtradas por ?gatilho=.
+func (h *Handler) Listar(c *gin.Context) {
+	regras, err := h.repo.Listar(c.Query("gatilho"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, regras)
+}
+
+// Obter retorna uma regra pelo ID.
+func (h *Handler) Obter(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	r, err := h.repo.ObterPorID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// Atualizar substitui uma regra de automação.
+func (h *Handler) Atualizar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	r := Regra{Ativa: true}
+	if err := c.ShouldBindJSON(&r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := Validar(r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	atualizada, err := h.repo.Atualizar(id, r)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, atualizada)
+}
+
+// Ativar liga ou desliga uma regra.
+// Espera receber um JSON no formato: {"ativa": false}.
+func (h *Handler) Ativar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	var payload struct {
+		Ativa *bool `json:"ativa" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	r, err := h.repo.Ativar(id, *payload.Ativa)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// Deletar remove uma regra de automação.
+func (h *Handler) Deletar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	if err := h.repo.Deletar(id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrRegraNaoEncontrada) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListarExecucoes retorna o registro de execuções das regras.
+// Aceita os filtros opcionais ?regra_id=1&status=falhou&entidade=negociacao&entidade_id=1;
+// em /automacoes/:id/execucoes a regra vem do caminho.
+func (h *Handler) ListarExecucoes(c *gin.Context) {
+	filtro := FiltroExecucoes{
+		Status:   c.Query("status"),
+		Entidade: c.Query("entidade"),
+	}
+	for param, destino := range map[string]*int{
+		"regra_id":    &filtro.RegraID,
+		"entidade_id": &filtro.EntidadeID,
+	} {
+		if v := c.Query(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": param + " inválido"})
+				return
+			}
+			*destino = n
+		}
+	}
+	if v := c.Param("id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+			return
+		}
+		filtro.RegraID = id
+	}
+	execucoes, err := h.repo.ListarExecucoes(filtro)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, execucoes)
+}
+
+// VerificarVencimentos executa imediatamente as regras de apólice vencendo.
+func (h *Handler) VerificarVencimentos(c *gin.Context) {
+	execucoes, err := h.motor.VerificarVencimentos(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, execucoes)
+}
@@ -0,0 +1,150 @@
+package automacao
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Gatilhos que disparam uma regra.
+const (
+	GatilhoEntradaEtapa    = "negociacao_entrou_etapa" // A negociação entrou na Etapa
+	GatilhoValorAcima      = "negociacao_valor_acima"  // O valor da negociação passou a superar o Valor
+	GatilhoTarefaAtrasada  = "tarefa_atrasada"         // A tarefa foi marcada como atrasada
+	GatilhoEmpresaCriada   = "empresa_criada"          // Uma empresa foi cadastrada
+	GatilhoApoliceVencendo = "apolice_vencendo"        // A apólice da negociação vence em até Dias dias
+)
+
+// Gatilhos lista os gatilhos válidos.
+var Gatilhos = []string{GatilhoEntradaEtapa, GatilhoValorAcima, GatilhoTarefaAtrasada, GatilhoEmpresaCriada, GatilhoApoliceVencendo}
+
+// Operadores das condições.
+const (
+	OperadorIgual      = "igual"
+	OperadorDiferente  = "diferente"
+	OperadorMaior      = "maior"
+	OperadorMaiorIgual = "maior_igual"
+	OperadorMenor      = "menor"
+	OperadorMenorIgual = "menor_igual"
+	OperadorContem     = "contem"     // Texto contém o valor, ou lista contém o item
+	OperadorEm         = "em"         // Valor é um dos itens separados por vírgula
+	OperadorVazio      = "vazio"      // Campo ausente, nulo ou vazio
+	OperadorPreenchido = "preenchido" // Campo presente e não vazio
+)
+
+// Operadores lista os operadores válidos.
+var Operadores = []string{
+	OperadorIgual, OperadorDiferente, OperadorMaior, OperadorMaiorIgual, OperadorMenor, OperadorMenorIgual,
+	OperadorContem, OperadorEm, OperadorVazio, OperadorPreenchido,
+}
+
+// Tipos de ação.
+const (
+	AcaoCriarTarefa        = "criar_tarefa"
+	AcaoAlterarResponsavel = "alterar_responsavel"
+	AcaoAlterarStatus      = "alterar_status"
+	AcaoAdicionarAnotacao  = "adicionar_anotacao"
+	AcaoNotificar          = "notificar"
+	AcaoDispararWebhook    = "disparar_webhook" // Publica automacao.disparada para os webhooks que o assinam
+)
+
+// Acoes lista os tipos de ação válidos.
+var Acoes = []string{AcaoCriarTarefa, AcaoAlterarResponsavel, AcaoAlterarStatus, AcaoAdicionarAnotacao, AcaoNotificar, AcaoDispararWebhook}
+
+// Usuario é o login registrado como autor das alterações feitas pelas regras.
+const Usuario = "automacao"
+
+// ProfundidadeMaxima limita quantas regras podem se encadear, uma disparando a
+// outra pelos eventos que publica. Além dela a execução é bloqueada.
+const ProfundidadeMaxima = 5
+
+// Regra é uma automação "quando X, se Y, então Z": o gatilho, as condições
+// sobre os campos da entidade (todas precisam ser atendidas) e as ações
+// executadas em ordem.
+type Regra struct {
+	ID        int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	Nome      string `json:"nome"`
+	Descricao string `json:"descricao,omitempty"`
+	Gatilho   string `json:"gatilho" gorm:"index;not null"`
+	Ativa     bool   `json:"ativa" gorm:"not null"`
+
+	// Parâmetros do gatilho.
+	Etapa string  `json:"etapa,omitempty"` // negociacao_entrou_etapa
+	Funil string  `json:"funil,omitempty"` // negociacao_entrou_etapa; vazio vale para todos
+	Valor float64 `json:"valor,omitempty"` // negociacao_valor_acima
+	Dias  int     `json:"dias,omitempty"`  // apolice_vencendo
+
+	Condicoes datatypes.JSONSlice[Condicao] `json:"condicoes"`
+	Acoes     datatypes.JSONSlice[Acao]     `json:"acoes"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Regra) TableName() string {
+	return "automacao_regras"
+}
+
+// Condicao compara um campo da entidade com um valor. O campo é o nome JSON,
+// com pontos para campos aninhados: "valor_negociacao", "empresa.segmento",
+// "campos_personalizados.origem".
+type Condicao struct {
+	Campo    string `json:"campo"`
+	Operador string `json:"operador"`
+	Valor    string `json:"valor,omitempty"`
+}
+
+// Acao descreve o que a regra faz. Os textos aceitam {{campo}} para inserir
+// valores da entidade (ex: "Ligar para {{empresa.nome}}").
+type Acao struct {
+	Tipo string `json:"tipo"`
+
+	// criar_tarefa: o prazo é DiasApos dias após o disparo, no Horario
+	// ("HH:MM") do fuso do responsável, ou no dia inteiro se vazio.
+	Assunto    string `json:"assunto,omitempty"`
+	Descricao  string `json:"descricao,omitempty"`
+	TipoTarefa string `json:"tipo_tarefa,omitempty"`
+	Prioridade string `json:"prioridade,omitempty"`
+	DiasApos   int    `json:"dias_apos,omitempty"`
+	Horario    string `json:"horario,omitempty"`
+
+	// Login do responsável (criar_tarefa, alterar_responsavel) ou do
+	// destinatário (notificar); vazio usa o responsável da entidade.
+	Usuario string `json:"usuario,omitempty"`
+
+	Status   string `json:"status,omitempty"`   // alterar_status
+	Titulo   string `json:"titulo,omitempty"`   // notificar e adicionar_anotacao (assunto)
+	Mensagem string `json:"mensagem,omitempty"` // notificar, adicionar_anotacao (texto) e disparar_webhook
+}
+
+// Situações de uma execução.
+const (
+	ExecucaoExecutada = "executada" // As ações foram executadas
+	ExecucaoIgnorada  = "ignorada"  // As condições não foram atendidas
+	ExecucaoBloqueada = "bloqueada" // Interrompida pela proteção contra laços
+	ExecucaoFalhou    = "falhou"    // Uma ação falhou; nenhuma alteração foi mantida
+)
+
+// Execucao registra cada vez que o gatilho de uma regra foi atingido e o que
+// aconteceu.
+type Execucao struct {
+	ID         int                         `json:"id" gorm:"primaryKey;autoIncrement"`
+	RegraID    int                         `json:"regra_id" gorm:"index:idx_execucao_regra;uniqueIndex:idx_execucao_referencia,where:referencia <> '';not null"`
+	EventoID   *int                        `json:"evento_id,omitempty"` // Evento de domínio que disparou a regra
+	Entidade   string                      `json:"entidade" gorm:"index:idx_execucao_entidade;uniqueIndex:idx_execucao_referencia"`
+	EntidadeID int                         `json:"entidade_id" gorm:"index:idx_execucao_entidade;index:idx_execucao_regra;uniqueIndex:idx_execucao_referencia"`
+	Referencia string                      `json:"referencia,omitempty" gorm:"uniqueIndex:idx_execucao_referencia"` // Vencimento (AAAA-MM-DD) nas regras de apólice vencendo; única por regra e entidade
+	Status     string                      `json:"status" gorm:"index"`
+	Cadeia     datatypes.JSONSlice[int]    `json:"cadeia,omitempty"` // Regras que levaram a esta execução
+	Detalhes   datatypes.JSONSlice[string] `json:"detalhes,omitempty"`
+	Erro       string                      `json:"erro,omitempty"`
+	Data       time.Time                   `json:"data"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Execucao) TableName() string {
+	return "automacao_execucoes"
+}
@@ -0,0 +1,273 @@
+package automacao
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"my-crm-backend/internal/empresa"
+	"my-crm-backend/internal/evento"
+	"my-crm-backend/internal/negociacao"
+	"my-crm-backend/internal/tarefa"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Eventos lista os eventos de domínio que podem disparar regras; o motor deve
+// assiná-los no barramento.
+var Eventos = []string{
+	evento.NegociacaoCriada, evento.NegociacaoEtapaAlterada, evento.NegociacaoValoresAlterados,
+	evento.TarefaAtrasada, evento.EmpresaCriada,
+}
+
+// gatilhosDoEvento relaciona cada evento de domínio aos gatilhos que ele pode atingir.
+var gatilhosDoEvento = map[string][]string{
+	evento.NegociacaoCriada:           {GatilhoEntradaEtapa, GatilhoValorAcima},
+	evento.NegociacaoEtapaAlterada:    {GatilhoEntradaEtapa},
+	evento.NegociacaoValoresAlterados: {GatilhoValorAcima},
+	evento.TarefaAtrasada:             {GatilhoTarefaAtrasada},
+	evento.EmpresaCriada:              {GatilhoEmpresaCriada},
+}
+
+// errJaExecutada indica que a regra já foi executada para a entidade e a referência.
+var errJaExecutada = errors.New("regra já executada para a referência")
+
+// Motor executa as regras de automação: reage aos eventos de domínio do
+// barramento e verifica periodicamente as apólices perto do vencimento.
+type Motor struct {
+	db    *gorm.DB
+	fusos tarefa.Fusos
+	agora func() time.Time
+}
+
+// NovoMotor cria o motor de automações. Os fusos definem o horário das tarefas
+// criadas pelas regras.
+func NovoMotor(db *gorm.DB, fusos tarefa.Fusos) *Motor {
+	return &Motor{db: db, fusos: fusos, agora: time.Now}
+}
+
+// Tratar executa as regras ativas cujo gatilho o evento atinge. É registrado
+// como assinante do barramento para os Eventos. As falhas das ações ficam no
+// registro de execuções e não fazem o evento ser entregue de novo.
+func (m *Motor) Tratar(tx *gorm.DB, e evento.Evento) error {
+	gatilhos := gatilhosDoEvento[e.Tipo]
+	if len(gatilhos) == 0 {
+		return nil
+	}
+	var regras []Regra
+	if err := tx.Where("ativa AND gatilho IN ?", gatilhos).Order("id").Find(&regras).Error; err != nil {
+		return err
+	}
+	if len(regras) == 0 {
+		return nil
+	}
+	var dados Dados
+	if err := json.Unmarshal(e.Dados, &dados); err != nil {
+		return err
+	}
+	eventoID := e.ID
+	for _, regra := range regras {
+		if !dispara(regra, e.Tipo, dados) {
+			continue
+		}
+		if _, err := m.executar(tx, regra, e.Entidade, e.EntidadeID, e.Cadeia, &eventoID, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dispara confere os parâmetros do gatilho da regra com os dados do evento.
+func dispara(regra Regra, tipo string, dados Dados) bool {
+	switch regra.Gatilho {
+	case GatilhoEntradaEtapa:
+		etapa, funil := dados.Texto("etapa_funil_vendas"), dados.Texto("funil_vendas")
+		if tipo == evento.NegociacaoEtapaAlterada {
+			etapa, funil = dados.Texto("etapa_atual"), dados.Texto("negociacao.funil_vendas")
+		}
+		return etapa == regra.Etapa && (regra.Funil == "" || regra.Funil == funil)
+	case GatilhoValorAcima:
+		if tipo == evento.NegociacaoCriada {
+			valor, _ := dados.Campo("valor_negociacao")
+			return comparar(valor, fmt.Sprint(regra.Valor)) > 0
+		}
+		// Dispara apenas quando o valor cruza o limite, não a cada alteração acima dele.
+		anterior, _ := dados.Campo("valor_anterior")
+		atual, _ := dados.Campo("valor_atual")
+		limite := fmt.Sprint(regra.Valor)
+		return comparar(anterior, limite) <= 0 && comparar(atual, limite) > 0
+	}
+	return true
+}
+
+// executar avalia as condições da regra sobre a entidade, aplica as ações em
+// um savepoint e registra a execução. cadeia são as regras cuja execução levou
+// a este disparo; se a regra já está nela, ou a cadeia atingiu
+// ProfundidadeMaxima, a execução é bloqueada. Com referência, a execução é
+// reservada antes das ações e, se a regra já foi executada para a entidade e a
+// referência, retorna errJaExecutada sem aplicá-las. Só retorna outro erro se
+// não conseguir registrar a execução.
+func (m *Motor) executar(tx *gorm.DB, regra Regra, entidade string, id int, cadeia []int, eventoID *int, referencia string) (Execucao, error) {
+	ex := Execucao{
+		RegraID:    regra.ID,
+		EventoID:   eventoID,
+		Entidade:   entidade,
+		EntidadeID: id,
+		Referencia: referencia,
+		Cadeia:     cadeia,
+		Data:       m.agora(),
+	}
+	if referencia != "" {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&ex)
+		if res.Error != nil {
+			return ex, res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ex, errJaExecutada
+		}
+	}
+	switch {
+	case contem(cadeia, regra.ID):
+		ex.Status = ExecucaoBloqueada
+		ex.Erro = fmt.Sprintf("laço detectado: a regra já foi executada na cadeia %v", cadeia)
+	case len(cadeia) >= ProfundidadeMaxima:
+		ex.Status = ExecucaoBloqueada
+		ex.Erro = fmt.Sprintf("a cadeia de automações atingiu o limite de %d regras", ProfundidadeMaxima)
+	default:
+		m.aplicarRegra(tx, regra, &ex)
+	}
+	if ex.Status == ExecucaoBloqueada {
+		log.Printf("Automação %d (%s) bloqueada para %s %d: %s", regra.ID, regra.Nome, entidade, id, ex.Erro)
+	}
+	return ex, tx.Save(&ex).Error
+}
+
+// aplicarRegra preenche o resultado da execução: ignorada, executada ou falhou.
+func (m *Motor) aplicarRegra(tx *gorm.DB, regra Regra, ex *Execucao) {
+	dados, err := carregar(tx, ex.Entidade, ex.EntidadeID)
+	if err != nil {
+		ex.Status, ex.Erro = ExecucaoFalhou, err.Error()
+		return
+	}
+	if ok, motivo := dados.Atende(regra.Condicoes); !ok {
+		ex.Status = ExecucaoIgnorada
+		ex.Detalhes = []string{motivo}
+		return
+	}
+
+	// Os eventos publicados pelas ações carregam a cadeia com esta regra.
+	cadeia := append(append([]int{}, ex.Cadeia...), regra.ID)
+	var detalhes []string
+	err = tx.Transaction(func(sp *gorm.DB) error {
+		sp = evento.ComCadeia(sp, cadeia)
+		for i, a := range regra.Acoes {
+			detalhe, err := m.aplicar(sp, regra, a, ex.Entidade, ex.EntidadeID, dados)
+			if err != nil {
+				return fmt.Errorf("ação %d (%s): %w", i+1, a.Tipo, err)
+			}
+			detalhes = append(detalhes, detalhe)
+		}
+		return nil
+	})
+	if err != nil {
+		ex.Status, ex.Erro = ExecucaoFalhou, err.Error()
+		return
+	}
+	ex.Status = ExecucaoExecutada
+	ex.Detalhes = detalhes
+}
+
+// carregar lê a entidade no formato JSON da API. A negociação vem com a empresa
+// e os participantes, para que as condições possam usá-los.
+func carregar(tx *gorm.DB, entidade string, id int) (Dados, error) {
+	var registro interface{}
+	var err error
+	switch entidade {
+	case "negociacao":
+		var n negociacao.Negociacao
+		err = tx.Preload("Empresa").Preload("Participantes").First(&n, id).Error
+		registro = n
+	case "tarefa":
+		var t tarefa.Tarefa
+		err = tx.First(&t, id).Error
+		registro = t
+	case "empresa":
+		var e empresa.Empresa
+		err = tx.First(&e, id).Error
+		registro = e
+	default:
+		return nil, fmt.Errorf("entidade não suportada: %q", entidade)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s %d não encontrada", entidade, id)
+	}
+	b, err := json.Marshal(registro)
+	if err != nil {
+		return nil, err
+	}
+	var dados Dados
+	err = json.Unmarshal(b, &dados)
+	return dados, err
+}
+
+// Executar verifica as apólices perto do vencimento a cada intervalo até o
+// contexto ser cancelado.
+func (m *Motor) Executar(ctx context.Context, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+	for {
+		execucoes, err := m.VerificarVencimentos(m.agora())
+		if err != nil {
+			log.Printf("Erro na verificação de apólices vencendo: %v", err)
+		} else if len(execucoes) > 0 {
+			log.Printf("Automações de apólices vencendo: %d execuções", len(execucoes))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// VerificarVencimentos executa as regras de apólice vencendo para as
+// negociações cuja apólice vence nos próximos Dias dias. Cada regra é executada
+// uma única vez por negociação e data de vencimento.
+func (m *Motor) VerificarVencimentos(agora time.Time) ([]Execucao, error) {
+	execucoes := []Execucao{}
+	var regras []Regra
+	if err := m.db.Where("ativa AND gatilho = ?", GatilhoApoliceVencendo).Order("id").Find(&regras).Error; err != nil {
+		return execucoes, err
+	}
+	for _, regra := range regras {
+		var negociacoes []negociacao.Negociacao
+		err := m.db.Select("id", "data_vencimento_apolice").
+			Where("data_vencimento_apolice BETWEEN ? AND ?", agora, agora.AddDate(0, 0, regra.Dias)).
+			Order("data_vencimento_apolice, id").
+			Find(&negociacoes).Error
+		if err != nil {
+			return execucoes, err
+		}
+		for _, n := range negociacoes {
+			referencia := n.DataVencimentoApolice.Format("2006-01-02")
+			var ex Execucao
+			err := m.db.Transaction(func(tx *gorm.DB) error {
+				var err error
+				ex, err = m.executar(tx, regra, "negociacao", n.ID, nil, nil, referencia)
+				return err
+			})
+			if errors.Is(err, errJaExecutada) {
+				continue
+			}
+			if err != nil {
+				return execucoes, fmt.Errorf("negociação %d, regra %d: %w", n.ID, regra.ID, err)
+			}
+			execucoes = append(execucoes, ex)
+		}
+	}
+	return execucoes, nil
+}
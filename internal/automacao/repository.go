@@ -0,0 +1,124 @@
+package automacao
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrRegraNaoEncontrada indica que a regra de automação não existe.
+var ErrRegraNaoEncontrada = errors.New("regra de automação não encontrada")
+
+// FiltroExecucoes restringe a listagem do registro de execuções.
+type FiltroExecucoes struct {
+	RegraID    int
+	Status     string
+	Entidade   string
+	EntidadeID int
+}
+
+// Repository define as operações sobre as regras de automação e o registro de execuções.
+type Repository interface {
+	Adicionar(r Regra) (Regra, error)
+	Listar(gatilho string) ([]Regra, error)
+	ObterPorID(id int) (*Regra, error)
+	Atualizar(id int, updated Regra) (Regra, error)
+	Ativar(id int, ativa bool) (Regra, error)
+	Deletar(id int) error
+	ListarExecucoes(filtro FiltroExecucoes) ([]Execucao, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NovoRepositorio cria e retorna um repositório baseado em GORM.
+func NovoRepositorio(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Adicionar insere uma nova regra.
+func (r *repository) Adicionar(regra Regra) (Regra, error) {
+	regra.ID = 0
+	err := r.db.Create(&regra).Error
+	return regra, err
+}
+
+// Listar retorna as regras, opcionalmente de um gatilho.
+func (r *repository) Listar(gatilho string) ([]Regra, error) {
+	regras := []Regra{}
+	query := r.db.Order("gatilho, id")
+	if gatilho != "" {
+		query = query.Where("gatilho = ?", gatilho)
+	}
+	err := query.Find(&regras).Error
+	return regras, err
+}
+
+// ObterPorID busca uma regra pelo ID.
+func (r *repository) ObterPorID(id int) (*Regra, error) {
+	var regra Regra
+	if err := r.db.First(&regra, id).Error; err != nil {
+		return nil, ErrRegraNaoEncontrada
+	}
+	return &regra, nil
+}
+
+// Atualizar substitui os dados de uma regra existente. As execuções já
+// registradas são mantidas.
+func (r *repository) Atualizar(id int, updated Regra) (Regra, error) {
+	var regra Regra
+	if err := r.db.First(&regra, id).Error; err != nil {
+		return Regra{}, ErrRegraNaoEncontrada
+	}
+	updated.ID = id
+	updated.CreatedAt = regra.CreatedAt
+	err := r.db.Model(&regra).Select("*").Omit("id", "created_at", "deleted_at").Updates(updated).Error
+	return updated, err
+}
+
+// Ativar liga ou desliga a regra sem alterar a sua definição.
+func (r *repository) Ativar(id int, ativa bool) (Regra, error) {
+	var regra Regra
+	if err := r.db.First(&regra, id).Error; err != nil {
+		return Regra{}, ErrRegraNaoEncontrada
+	}
+	if err := r.db.Model(&regra).Update("ativa", ativa).Error; err != nil {
+		return Regra{}, err
+	}
+	regra.Ativa = ativa
+	return regra, nil
+}
+
+// Deletar remove uma regra pelo ID.
+func (r *repository) Deletar(id int) error {
+	res := r.db.Delete(&Regra{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRegraNaoEncontrada
+	}
+	return nil
+}
+
+// ListarExecucoes retorna as últimas 200 execuções que atendem ao filtro, da
+// mais recente para a mais antiga.
+func (r *repository) ListarExecucoes(filtro FiltroExecucoes) ([]Execucao, error) {
+	execucoes := []Execucao{}
+	query := r.db.Order("id DESC").Limit(200)
+	if filtro.RegraID != 0 {
+		query = query.Where("regra_id = ?", filtro.RegraID)
+	}
+	if filtro.Status != "" {
+		query = query.Where("status = ?", filtro.Status)
+	}
+	if filtro.Entidade != "" {
+		query = query.Where("entidade = ?", filtro.Entidade)
+	}
+	if filtro.EntidadeID != 0 {
+		query = query.Where("entidade_id = ?", filtro.EntidadeID)
+	}
+	err := query.Find(&execucoes).Error
+	return execucoes, err
+}
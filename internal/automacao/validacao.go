@@ -0,0 +1,130 @@
+package automacao
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"my-crm-backend/internal/negocio"
+	"my-crm-backend/internal/tarefa"
+)
+
+var formatoHorario = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`)
+
+// EntidadeDoGatilho retorna o tipo da entidade sobre a qual a regra atua.
+func EntidadeDoGatilho(gatilho string) string {
+	switch gatilho {
+	case GatilhoTarefaAtrasada:
+		return "tarefa"
+	case GatilhoEmpresaCriada:
+		return "empresa"
+	}
+	return "negociacao"
+}
+
+// Validar confere o gatilho, as condições e as ações da regra.
+func Validar(r Regra) error {
+	if r.Nome == "" {
+		return errors.New("nome é obrigatório")
+	}
+	switch r.Gatilho {
+	case GatilhoEntradaEtapa:
+		if !contem(negocio.FunilOpcoes, r.Etapa) {
+			return fmt.Errorf("etapa inválida: %q", r.Etapa)
+		}
+	case GatilhoValorAcima:
+		if r.Valor <= 0 {
+			return errors.New("informe o valor que dispara a regra")
+		}
+	case GatilhoApoliceVencendo:
+		if r.Dias <= 0 {
+			return errors.New("informe em quantos dias antes do vencimento a regra dispara")
+		}
+	case GatilhoTarefaAtrasada, GatilhoEmpresaCriada:
+	default:
+		return fmt.Errorf("gatilho inválido: %q (use um de %v)", r.Gatilho, Gatilhos)
+	}
+
+	for i, c := range r.Condicoes {
+		if c.Campo == "" {
+			return fmt.Errorf("condição %d: campo é obrigatório", i+1)
+		}
+		if !contem(Operadores, c.Operador) {
+			return fmt.Errorf("condição %d: operador inválido: %q", i+1, c.Operador)
+		}
+	}
+
+	if len(r.Acoes) == 0 {
+		return errors.New("a regra precisa de ao menos uma ação")
+	}
+	entidade := EntidadeDoGatilho(r.Gatilho)
+	for i, a := range r.Acoes {
+		if err := validarAcao(a, entidade); err != nil {
+			return fmt.Errorf("ação %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func validarAcao(a Acao, entidade string) error {
+	switch a.Tipo {
+	case AcaoCriarTarefa:
+		if a.Assunto == "" || a.TipoTarefa == "" {
+			return errors.New("assunto e tipo_tarefa são obrigatórios")
+		}
+		if a.DiasApos < 0 {
+			return errors.New("dias_apos não pode ser negativo")
+		}
+		if a.Horario != "" && !formatoHorario.MatchString(a.Horario) {
+			return errors.New("horário inválido, use HH:MM")
+		}
+		if entidade == "empresa" && a.Usuario == "" {
+			return errors.New("informe o responsável: empresas não têm responsável")
+		}
+		return tarefa.ValidarPrioridade(&tarefa.Tarefa{Prioridade: a.Prioridade})
+	case AcaoAlterarResponsavel:
+		if entidade == "empresa" {
+			return errors.New("empresas não têm responsável")
+		}
+		if a.Usuario == "" {
+			return errors.New("informe o novo responsável")
+		}
+	case AcaoAlterarStatus:
+		switch entidade {
+		case "empresa":
+			return errors.New("empresas não têm status")
+		case "tarefa":
+			if !contem(tarefa.Statuses, a.Status) {
+				return fmt.Errorf("status de tarefa inválido: %q", a.Status)
+			}
+		default:
+			if a.Status == "" {
+				return errors.New("informe o novo status")
+			}
+		}
+	case AcaoAdicionarAnotacao:
+		if a.Mensagem == "" {
+			return errors.New("informe o texto da anotação em mensagem")
+		}
+	case AcaoNotificar:
+		if a.Titulo == "" {
+			return errors.New("título é obrigatório")
+		}
+		if entidade == "empresa" && a.Usuario == "" {
+			return errors.New("informe o destinatário: empresas não têm responsável")
+		}
+	case AcaoDispararWebhook:
+	default:
+		return fmt.Errorf("tipo inválido: %q (use um de %v)", a.Tipo, Acoes)
+	}
+	return nil
+}
+
+func contem[T comparable](lista []T, valor T) bool {
+	for _, v := range lista {
+		if v == valor {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package calendario
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"my-crm-backend/internal/tarefa"
+	"my-crm-backend/internal/usuario"
+
+	"github.com/gin-gonic/gin"
+)
+
+// historico é quanto do passado o feed inclui para tarefas não recorrentes.
+const historico = 90 * 24 * time.Hour
+
+// Handler define o manipulador HTTP do feed de calendário.
+type Handler struct {
+	usuarios usuario.Repository
+	tarefas  tarefa.Repository
+}
+
+// NovoHandler cria e retorna um novo handler de calendário.
+func NovoHandler(usuarios usuario.Repository, tarefas tarefa.Repository) *Handler {
+	return &Handler{usuarios: usuarios, tarefas: tarefas}
+}
+
+// Feed retorna o calendário iCalendar do usuário dono do token:
+// GET /api/calendario/<token>.ics
+func (h *Handler) Feed(c *gin.Context) {
+	arquivo := c.Param("arquivo")
+	if !strings.HasSuffix(arquivo, ".ics") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "calendário não encontrado"})
+		return
+	}
+	u, err := h.usuarios.ObterPorTokenCalendario(strings.TrimSuffix(arquivo, ".ics"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "calendário não encontrado"})
+		return
+	}
+	tarefas, err := h.tarefas.ListarCalendario(u.Login, time.Now().Add(-historico))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	nome := u.Nome
+	if nome == "" {
+		nome = u.Login
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(Gerar("Tarefas - "+nome, tarefas)))
+}
@@ -0,0 +1,347 @@
+// Package calendario gera o feed iCalendar (RFC 5545) com as tarefas de cada
+// responsável, para assinatura em aplicativos de calendário.
+package calendario
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"my-crm-backend/internal/recorrencia"
+	"my-crm-backend/internal/tarefa"
+)
+
+const (
+	formatoDataHora = "20060102T150405"
+	formatoData     = "20060102"
+	formatoUTC      = "20060102T150405Z"
+)
+
+// escritor acumula as linhas do documento iCalendar, já dobradas e com CRLF.
+type escritor struct {
+	b strings.Builder
+}
+
+// linha escreve uma propriedade, dobrando-a em 75 octetos sem partir caracteres UTF-8.
+func (e *escritor) linha(nome, valor string) {
+	l := nome + ":" + valor
+	limite := 75
+	for len(l) > limite {
+		corte := limite
+		for corte > 0 && !utf8.RuneStart(l[corte]) {
+			corte--
+		}
+		e.b.WriteString(l[:corte])
+		e.b.WriteString("\r\n ")
+		l = l[corte:]
+		limite = 74 // a continuação começa com um espaço
+	}
+	e.b.WriteString(l)
+	e.b.WriteString("\r\n")
+}
+
+// texto escapa um valor do tipo TEXT.
+func texto(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// Gerar monta o calendário com as tarefas informadas. Tarefas recorrentes viram
+// um evento com RRULE, as exceções viram EXDATE e as ocorrências alteradas viram
+// eventos próprios com RECURRENCE-ID.
+func Gerar(nome string, tarefas []tarefa.Tarefa) string {
+	e := &escritor{}
+	e.linha("BEGIN", "VCALENDAR")
+	e.linha("VERSION", "2.0")
+	e.linha("PRODID", "-//my-crm-backend//Tarefas//PT-BR")
+	e.linha("CALSCALE", "GREGORIAN")
+	e.linha("METHOD", "PUBLISH")
+	e.linha("X-WR-CALNAME", texto(nome))
+	fusos := make([]*time.Location, len(tarefas))
+	for i, t := range tarefas {
+		loc, err := time.LoadLocation(t.FusoHorario)
+		if err != nil {
+			loc = time.UTC
+		}
+		fusos[i] = loc
+	}
+	for _, p := range periodosFusos(tarefas, fusos, time.Now()) {
+		fuso(e, p.loc, p.de, p.ate)
+	}
+	for i, t := range tarefas {
+		loc := fusos[i]
+		evento(e, t, loc, nil)
+		if t.Recorrencia == "" {
+			continue
+		}
+		for _, o := range t.Ocorrencias {
+			if o.Excluida {
+				continue
+			}
+			o := o
+			evento(e, t, loc, &o)
+		}
+	}
+	e.linha("END", "VCALENDAR")
+	return e.b.String()
+}
+
+// evento escreve o VEVENT da tarefa ou, com alteracao, o da ocorrência alterada.
+func evento(e *escritor, t tarefa.Tarefa, loc *time.Location, alteracao *tarefa.Ocorrencia) {
+	inicio, fim := t.Inicio.In(loc), t.Fim.In(loc)
+	assunto, descricao, concluida := t.Assunto, t.Descricao, t.Concluida
+	atualizado := t.UpdatedAt
+	if alteracao != nil {
+		duracao := fim.Sub(inicio)
+		inicio = alteracao.Original.In(loc)
+		if t.DiaInteiro {
+			fim = inicio.AddDate(0, 0, t.DuracaoMinutos/(24*60))
+		} else {
+			fim = inicio.Add(duracao)
+		}
+		if alteracao.Inicio != nil {
+			inicio = alteracao.Inicio.In(loc)
+			fim = inicio.Add(duracao)
+		}
+		if alteracao.Fim != nil {
+			fim = alteracao.Fim.In(loc)
+		}
+		if alteracao.Assunto != "" {
+			assunto = alteracao.Assunto
+		}
+		if alteracao.Descricao != "" {
+			descricao = alteracao.Descricao
+		}
+		concluida = concluida || alteracao.Concluida
+		atualizado = alteracao.UpdatedAt
+	}
+
+	e.linha("BEGIN", "VEVENT")
+	e.linha("UID", fmt.Sprintf("tarefa-%d@my-crm-backend", t.ID))
+	e.linha("DTSTAMP", atualizado.UTC().Format(formatoUTC))
+	if alteracao != nil {
+		e.linha(instante("RECURRENCE-ID", alteracao.Original.In(loc), t.DiaInteiro))
+	}
+	e.linha(instante("DTSTART", inicio, t.DiaInteiro))
+	e.linha(instante("DTEND", fim, t.DiaInteiro))
+	if alteracao == nil && t.Recorrencia != "" {
+		e.linha("RRULE", regraICS(t, loc))
+		for _, o := range t.Ocorrencias {
+			if o.Excluida {
+				e.linha(instante("EXDATE", o.Original.In(loc), t.DiaInteiro))
+			}
+		}
+	}
+	if concluida {
+		assunto = "✔ " + assunto
+	}
+	e.linha("SUMMARY", texto(assunto))
+	e.linha("DESCRIPTION", texto(detalhes(t, descricao, concluida)))
+	e.linha("CATEGORIES", texto(t.Tipo))
+	if t.Status == tarefa.StatusCancelada {
+		e.linha("STATUS", "CANCELLED")
+	} else {
+		e.linha("STATUS", "CONFIRMED")
+	}
+	e.linha("TRANSP", "OPAQUE")
+	e.linha("END", "VEVENT")
+}
+
+// instante formata uma propriedade de data: data simples para dia inteiro, ou
+// data e hora locais com TZID, para que a série acompanhe o horário de parede.
+// Cada TZID usado tem seu VTIMEZONE no calendário (ver fuso).
+func instante(nome string, t time.Time, diaInteiro bool) (string, string) {
+	if diaInteiro {
+		return nome + ";VALUE=DATE", t.Format(formatoData)
+	}
+	if t.Location() == time.UTC {
+		return nome, t.Format(formatoUTC)
+	}
+	return nome + ";TZID=" + t.Location().String(), t.Format(formatoDataHora)
+}
+
+// regraICS devolve a RRULE da tarefa. Nas séries de dia inteiro o UNTIL, que a
+// regra guarda em UTC, é escrito como data, do mesmo tipo do DTSTART.
+func regraICS(t tarefa.Tarefa, loc *time.Location) string {
+	if !t.DiaInteiro {
+		return t.Recorrencia
+	}
+	r, err := recorrencia.Interpretar(t.Recorrencia, loc)
+	if err != nil || r.Ate == nil {
+		return t.Recorrencia
+	}
+	partes := strings.Split(r.String(), ";")
+	for i, parte := range partes {
+		if strings.HasPrefix(parte, "UNTIL=") {
+			partes[i] = "UNTIL=" + r.Ate.In(loc).Format(formatoData)
+		}
+	}
+	return strings.Join(partes, ";")
+}
+
+// periodo é o intervalo que o VTIMEZONE de um fuso precisa descrever.
+type periodo struct {
+	loc     *time.Location
+	de, ate time.Time
+}
+
+// anosFuturos é quanto além de agora (ou do último horário das tarefas) os
+// VTIMEZONE descrevem, para cobrir as ocorrências futuras das séries.
+const anosFuturos = 5
+
+// periodosFusos reúne, na ordem em que aparecem, os fusos das tarefas com
+// horário marcado e o período que cada um precisa cobrir. UTC e as tarefas de
+// dia inteiro não usam TZID.
+func periodosFusos(tarefas []tarefa.Tarefa, fusos []*time.Location, agora time.Time) []periodo {
+	var periodos []periodo
+	indice := map[string]int{}
+	for i, t := range tarefas {
+		loc := fusos[i]
+		if t.DiaInteiro || loc == time.UTC {
+			continue
+		}
+		de, ate := t.Inicio, t.Fim
+		for _, o := range t.Ocorrencias {
+			for _, instante := range []*time.Time{&o.Original, o.Inicio, o.Fim} {
+				if instante == nil {
+					continue
+				}
+				if instante.Before(de) {
+					de = *instante
+				}
+				if instante.After(ate) {
+					ate = *instante
+				}
+			}
+		}
+		if ate.Before(agora) {
+			ate = agora
+		}
+		j, ok := indice[loc.String()]
+		if !ok {
+			indice[loc.String()] = len(periodos)
+			periodos = append(periodos, periodo{loc: loc, de: de, ate: ate})
+			continue
+		}
+		if de.Before(periodos[j].de) {
+			periodos[j].de = de
+		}
+		if ate.After(periodos[j].ate) {
+			periodos[j].ate = ate
+		}
+	}
+	for i := range periodos {
+		periodos[i].de = periodos[i].de.AddDate(0, 0, -1)
+		periodos[i].ate = periodos[i].ate.AddDate(anosFuturos, 0, 0)
+	}
+	return periodos
+}
+
+// fuso escreve o VTIMEZONE do fuso entre de e ate: o deslocamento em vigor no
+// início e cada mudança (horário de verão) do período, tirados da base de fusos.
+func fuso(e *escritor, loc *time.Location, de, ate time.Time) {
+	e.linha("BEGIN", "VTIMEZONE")
+	e.linha("TZID", loc.String())
+	_, anterior := de.In(loc).Zone()
+	componente(e, de.In(loc), anterior)
+	for _, t := range transicoes(loc, de, ate) {
+		componente(e, t, anterior)
+		_, anterior = t.Zone()
+	}
+	e.linha("END", "VTIMEZONE")
+}
+
+// componente escreve o STANDARD ou DAYLIGHT que passa a valer em t. O DTSTART
+// é o horário local ainda com o deslocamento anterior, como pede a RFC 5545.
+func componente(e *escritor, t time.Time, anterior int) {
+	tipo := "STANDARD"
+	if t.IsDST() {
+		tipo = "DAYLIGHT"
+	}
+	nome, deslocamento := t.Zone()
+	e.linha("BEGIN", tipo)
+	e.linha("DTSTART", t.In(time.FixedZone("", anterior)).Format(formatoDataHora))
+	e.linha("TZOFFSETFROM", formatarDeslocamento(anterior))
+	e.linha("TZOFFSETTO", formatarDeslocamento(deslocamento))
+	e.linha("TZNAME", texto(nome))
+	e.linha("END", tipo)
+}
+
+// transicoes devolve os instantes, no fuso, em que o deslocamento muda entre
+// de e ate. As mudanças distam meses entre si: basta procurar semana a semana
+// e localizar o segundo exato por busca binária.
+func transicoes(loc *time.Location, de, ate time.Time) []time.Time {
+	deslocamento := func(seg int64) int {
+		_, d := time.Unix(seg, 0).In(loc).Zone()
+		return d
+	}
+	var ts []time.Time
+	const semana = 7 * 24 * 60 * 60
+	inicio, fim := de.Unix(), ate.Unix()
+	atual := deslocamento(inicio)
+	for inicio < fim {
+		proximo := inicio + semana
+		if deslocamento(proximo) == atual {
+			inicio = proximo
+			continue
+		}
+		lo, hi := inicio, proximo
+		for hi-lo > 1 {
+			meio := lo + (hi-lo)/2
+			if deslocamento(meio) == atual {
+				lo = meio
+			} else {
+				hi = meio
+			}
+		}
+		ts = append(ts, time.Unix(hi, 0).In(loc))
+		inicio, atual = hi, deslocamento(hi)
+	}
+	return ts
+}
+
+// formatarDeslocamento formata um deslocamento UTC em segundos como ±hhmm, ou
+// ±hhmmss quando há segundos.
+func formatarDeslocamento(seg int) string {
+	sinal := "+"
+	if seg < 0 {
+		sinal, seg = "-", -seg
+	}
+	if seg%60 != 0 {
+		return fmt.Sprintf("%s%02d%02d%02d", sinal, seg/3600, seg/60%60, seg%60)
+	}
+	return fmt.Sprintf("%s%02d%02d", sinal, seg/3600, seg/60%60)
+}
+
+// detalhes monta a descrição do evento com os dados da negociação e da empresa.
+func detalhes(t tarefa.Tarefa, descricao string, concluida bool) string {
+	var linhas []string
+	if t.Negociacao != "" {
+		n := "Negociação: " + t.Negociacao
+		if t.NegociacaoID != 0 {
+			n += fmt.Sprintf(" (#%d)", t.NegociacaoID)
+		}
+		linhas = append(linhas, n)
+	}
+	empresa := t.Empresa.Nome
+	if empresa == "" {
+		empresa = t.EmpresaNegociacao
+	}
+	if empresa != "" {
+		linhas = append(linhas, "Empresa: "+empresa)
+	}
+	if t.Empresa.TelefoneMatriz != "" {
+		linhas = append(linhas, "Telefone: "+t.Empresa.TelefoneMatriz)
+	}
+	if t.Tipo != "" {
+		linhas = append(linhas, "Tipo: "+t.Tipo)
+	}
+	if concluida {
+		linhas = append(linhas, "Situação: concluída")
+	}
+	if descricao != "" {
+		linhas = append(linhas, "", descricao)
+	}
+	return strings.Join(linhas, "\n")
+}
@@ -0,0 +1,130 @@
+package campopersonalizado
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PrefixoFiltro identifica os parâmetros de consulta que filtram por campos personalizados.
+// Exemplos: ?cp.origem=site, ?cp.funcionarios__gte=50, ?ordenar=-cp.funcionarios
+const PrefixoFiltro = "cp."
+
+// Condicao é um filtro sobre um campo personalizado.
+type Condicao struct {
+	Chave    string
+	Tipo     string
+	Operador string // "eq", "gte" ou "lte"
+	Valor    string
+}
+
+// Filtro reúne as condições e a ordenação por campos personalizados de uma listagem.
+type Filtro struct {
+	Condicoes   []Condicao
+	OrdenarPor  *Definicao
+	Decrescente bool
+}
+
+// LerFiltro interpreta os parâmetros "cp.<chave>[__gte|__lte]" e "ordenar=[-]cp.<chave>"
+// conforme as definições da entidade. Chaves desconhecidas resultam em erro.
+func LerFiltro(params url.Values, defs []Definicao) (Filtro, error) {
+	porChave := make(map[string]Definicao, len(defs))
+	for _, d := range defs {
+		porChave[d.Chave] = d
+	}
+
+	var f Filtro
+	for param, valores := range params {
+		if !strings.HasPrefix(param, PrefixoFiltro) {
+			continue
+		}
+		chave, operador := strings.TrimPrefix(param, PrefixoFiltro), "eq"
+		if i := strings.LastIndex(chave, "__"); i > 0 {
+			chave, operador = chave[:i], chave[i+2:]
+		}
+		d, ok := porChave[chave]
+		if !ok {
+			return Filtro{}, fmt.Errorf("campo personalizado desconhecido: %s", chave)
+		}
+		if operador != "eq" && operador != "gte" && operador != "lte" {
+			return Filtro{}, fmt.Errorf("operador inválido: %s", operador)
+		}
+		if operador != "eq" && d.Tipo != TipoNumero && d.Tipo != TipoData {
+			return Filtro{}, fmt.Errorf("o campo %s não aceita comparação por intervalo", chave)
+		}
+		for _, v := range valores {
+			if err := validarFiltro(d, v); err != nil {
+				return Filtro{}, err
+			}
+			f.Condicoes = append(f.Condicoes, Condicao{Chave: chave, Tipo: d.Tipo, Operador: operador, Valor: v})
+		}
+	}
+
+	if ordenar := params.Get("ordenar"); ordenar != "" {
+		desc := strings.HasPrefix(ordenar, "-")
+		ordenar = strings.TrimPrefix(ordenar, "-")
+		if strings.HasPrefix(ordenar, PrefixoFiltro) {
+			d, ok := porChave[strings.TrimPrefix(ordenar, PrefixoFiltro)]
+			if !ok {
+				return Filtro{}, fmt.Errorf("campo personalizado desconhecido: %s", ordenar)
+			}
+			f.OrdenarPor = &d
+			f.Decrescente = desc
+		}
+	}
+	return f, nil
+}
+
+func validarFiltro(d Definicao, v string) error {
+	switch d.Tipo {
+	case TipoNumero:
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return fmt.Errorf("o campo %s espera um número", d.Chave)
+		}
+	case TipoData:
+		if _, err := ParseData(v); err != nil {
+			return fmt.Errorf("o campo %s espera uma data AAAA-MM-DD", d.Chave)
+		}
+	}
+	return nil
+}
+
+// Aplicar adiciona à consulta as condições e a ordenação sobre a coluna JSONB campos_personalizados.
+func (f Filtro) Aplicar(query *gorm.DB) *gorm.DB {
+	operadores := map[string]string{"eq": "=", "gte": ">=", "lte": "<="}
+	for _, c := range f.Condicoes {
+		op := operadores[c.Operador]
+		switch c.Tipo {
+		case TipoNumero:
+			n, _ := strconv.ParseFloat(c.Valor, 64)
+			query = query.Where("(campos_personalizados->>(?::text))::numeric "+op+" ?", c.Chave, n)
+		case TipoData:
+			data, _ := ParseData(c.Valor)
+			query = query.Where("(campos_personalizados->>(?::text))::date "+op+" ?", c.Chave, data.Format("2006-01-02"))
+		case TipoMultiplaSelecao:
+			query = query.Where("(campos_personalizados->(?::text)) @> jsonb_build_array(?::text)", c.Chave, c.Valor)
+		default:
+			query = query.Where("campos_personalizados->>(?::text) = ?", c.Chave, c.Valor)
+		}
+	}
+	if f.OrdenarPor != nil {
+		expr := "campos_personalizados->>(?::text)"
+		switch f.OrdenarPor.Tipo {
+		case TipoNumero:
+			expr = "(campos_personalizados->>(?::text))::numeric"
+		case TipoData:
+			expr = "(campos_personalizados->>(?::text))::date"
+		}
+		if f.Decrescente {
+			expr += " DESC NULLS LAST"
+		} else {
+			expr += " ASC NULLS LAST"
+		}
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{SQL: expr, Vars: []interface{}{f.OrdenarPor.Chave}}})
+	}
+	return query
+}
@@ -0,0 +1,113 @@
+package campopersonalizado
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler define os manipuladores HTTP para as definições de campos personalizados.
+type Handler struct {
+	repo Repository
+}
+
+// NovoHandler cria e retorna um novo handler para campos personalizados.
+func NovoHandler(repo Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+// Criar insere uma nova definição de campo personalizado.
+func (h *Handler) Criar(c *gin.Context) {
+	var d Definicao
+	if err := c.ShouldBindJSON(&d); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	criada, err := h.repo.Adicionar(d)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrChaveDuplicada) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, criada)
+}
+
+// Listar retorna todas as definições de campos personalizados.
+func (h *Handler) Listar(c *gin.Context) {
+	defs, err := h.repo.Listar()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, defs)
+}
+
+// Obter retorna uma definição pelo ID.
+func (h *Handler) Obter(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	d, err := h.repo.ObterPorID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// Atualizar modifica uma definição existente.
+func (h *Handler) Atualizar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	var d Definicao
+	if err := c.ShouldBindJSON(&d); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	atualizada, err := h.repo.Atualizar(id, d)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, atualizada)
+}
+
+// Deletar remove uma definição pelo ID.
+func (h *Handler) Deletar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	if err := h.repo.Deletar(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Metadados retorna as definições de uma entidade, na ordem de exibição, para que o
+// frontend monte os formulários: GET /api/campos-personalizados/metadados/:entidade
+func (h *Handler) Metadados(c *gin.Context) {
+	entidade := c.Param("entidade")
+	if !EntidadeValida(entidade) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "entidade inválida"})
+		return
+	}
+	defs, err := h.repo.Definicoes(entidade)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entidade": entidade, "campos": defs})
+}
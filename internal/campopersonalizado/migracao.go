@@ -0,0 +1,12 @@
+package campopersonalizado
+
+import "gorm.io/gorm"
+
+// MigrarIndiceChave troca o índice único de (entidade, chave), que incluía os
+// campos removidos, pelo índice parcial dos campos ativos. É idempotente.
+func MigrarIndiceChave(db *gorm.DB) error {
+	if !db.Migrator().HasIndex(&Definicao{}, "idx_campo_entidade_chave") {
+		return nil
+	}
+	return db.Migrator().DropIndex(&Definicao{}, "idx_campo_entidade_chave")
+}
@@ -0,0 +1,47 @@
+package campopersonalizado
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Entidades que aceitam campos personalizados.
+const (
+	EntidadeContato    = "contato"
+	EntidadeEmpresa    = "empresa"
+	EntidadeNegociacao = "negociacao"
+)
+
+// Tipos de campo personalizado.
+const (
+	TipoTexto           = "texto"
+	TipoNumero          = "numero"
+	TipoData            = "data"             // Armazenado no formato AAAA-MM-DD
+	TipoSelecao         = "selecao"          // Um valor dentre Opcoes
+	TipoMultiplaSelecao = "multipla_selecao" // Lista de valores dentre Opcoes
+)
+
+// Definicao descreve um campo personalizado criado pelo administrador para uma entidade.
+type Definicao struct {
+	ID          int            `json:"id" gorm:"primaryKey;autoIncrement"`
+	Entidade    string         `json:"entidade" gorm:"uniqueIndex:idx_campo_entidade_chave_ativo,where:deleted_at IS NULL"`
+	Chave       string         `json:"chave" gorm:"uniqueIndex:idx_campo_entidade_chave_ativo,where:deleted_at IS NULL"` // Nome da propriedade no JSON (ex.: "origem_lead")
+	Rotulo      string         `json:"rotulo"`                                                                           // Texto exibido no formulário
+	Tipo        string         `json:"tipo"`
+	Obrigatorio bool           `json:"obrigatorio"`
+	Padrao      datatypes.JSON `json:"padrao,omitempty"` // Valor padrão aplicado na criação
+	Opcoes      datatypes.JSON `json:"opcoes,omitempty"` // Lista de opções para seleção e múltipla seleção
+	Ajuda       string         `json:"ajuda,omitempty"`
+	Ordem       int            `json:"ordem"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Definicao) TableName() string {
+	return "campos_personalizados"
+}
@@ -0,0 +1,128 @@
+package campopersonalizado
+
+import (
+	"errors"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Validador é usado pelos módulos de contato, empresa e negociação para validar
+// os valores de campos personalizados e montar filtros de listagem.
+type Validador interface {
+	Definicoes(entidade string) ([]Definicao, error)
+	Validar(entidade string, valores datatypes.JSON) (datatypes.JSON, error)
+}
+
+// Repository define as operações de manutenção das definições de campos personalizados.
+type Repository interface {
+	Validador
+	Adicionar(d Definicao) (Definicao, error)
+	Listar() ([]Definicao, error)
+	ObterPorID(id int) (*Definicao, error)
+	Atualizar(id int, updated Definicao) (Definicao, error)
+	Deletar(id int) error
+}
+
+// ErrChaveDuplicada indica que a entidade já tem um campo ativo com a mesma chave.
+var ErrChaveDuplicada = errors.New("já existe um campo com essa chave para a entidade")
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NovoRepositorio cria e retorna um repositório baseado em GORM.
+func NovoRepositorio(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Adicionar valida e insere uma nova definição de campo.
+func (r *repository) Adicionar(d Definicao) (Definicao, error) {
+	if d.Rotulo == "" {
+		d.Rotulo = d.Chave
+	}
+	if err := ValidarDefinicao(d); err != nil {
+		return Definicao{}, err
+	}
+	var existente int64
+	if err := r.db.Model(&Definicao{}).Where("entidade = ? AND chave = ?", d.Entidade, d.Chave).Count(&existente).Error; err != nil {
+		return Definicao{}, err
+	}
+	if existente > 0 {
+		return Definicao{}, ErrChaveDuplicada
+	}
+	// A chave de um campo removido pode ser reutilizada; o índice único só
+	// considera os campos ativos e ainda protege contra criações simultâneas.
+	err := r.db.Create(&d).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return Definicao{}, ErrChaveDuplicada
+	}
+	return d, err
+}
+
+// Listar retorna todas as definições, agrupadas por entidade e ordenadas para exibição.
+func (r *repository) Listar() ([]Definicao, error) {
+	var defs []Definicao
+	err := r.db.Order("entidade, ordem, id").Find(&defs).Error
+	return defs, err
+}
+
+// ObterPorID busca uma definição pelo ID.
+func (r *repository) ObterPorID(id int) (*Definicao, error) {
+	var d Definicao
+	if err := r.db.First(&d, id).Error; err != nil {
+		return nil, errors.New("campo personalizado não encontrado")
+	}
+	return &d, nil
+}
+
+// Atualizar modifica uma definição. Entidade, chave e tipo não podem ser alterados,
+// pois os valores já gravados dependem deles.
+func (r *repository) Atualizar(id int, updated Definicao) (Definicao, error) {
+	var d Definicao
+	if err := r.db.First(&d, id).Error; err != nil {
+		return Definicao{}, errors.New("campo personalizado não encontrado")
+	}
+	if (updated.Entidade != "" && updated.Entidade != d.Entidade) ||
+		(updated.Chave != "" && updated.Chave != d.Chave) ||
+		(updated.Tipo != "" && updated.Tipo != d.Tipo) {
+		return Definicao{}, errors.New("entidade, chave e tipo não podem ser alterados")
+	}
+	if updated.Rotulo != "" {
+		d.Rotulo = updated.Rotulo
+	}
+	if updated.Opcoes != nil {
+		d.Opcoes = updated.Opcoes
+	}
+	d.Obrigatorio = updated.Obrigatorio
+	d.Padrao = updated.Padrao
+	d.Ajuda = updated.Ajuda
+	d.Ordem = updated.Ordem
+	if err := ValidarDefinicao(d); err != nil {
+		return Definicao{}, err
+	}
+	err := r.db.Save(&d).Error
+	return d, err
+}
+
+// Deletar remove a definição. Os valores já gravados permanecem no JSON das entidades,
+// mas passam a ser rejeitados em novas gravações.
+func (r *repository) Deletar(id int) error {
+	return r.db.Delete(&Definicao{}, id).Error
+}
+
+// Definicoes retorna as definições de uma entidade na ordem de exibição.
+func (r *repository) Definicoes(entidade string) ([]Definicao, error) {
+	var defs []Definicao
+	err := r.db.Where("entidade = ?", entidade).Order("ordem, id").Find(&defs).Error
+	return defs, err
+}
+
+// Validar confere os valores contra as definições atuais da entidade.
+func (r *repository) Validar(entidade string, valores datatypes.JSON) (datatypes.JSON, error) {
+	defs, err := r.Definicoes(entidade)
+	if err != nil {
+		return nil, err
+	}
+	return ValidarValores(defs, valores)
+}
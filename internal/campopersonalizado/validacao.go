@@ -0,0 +1,213 @@
+package campopersonalizado
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+var chaveValida = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// EntidadeValida indica se a entidade aceita campos personalizados.
+func EntidadeValida(entidade string) bool {
+	switch entidade {
+	case EntidadeContato, EntidadeEmpresa, EntidadeNegociacao:
+		return true
+	}
+	return false
+}
+
+// ValidarDefinicao verifica se a definição é consistente antes de ser gravada.
+func ValidarDefinicao(d Definicao) error {
+	if !EntidadeValida(d.Entidade) {
+		return fmt.Errorf("entidade inválida: use %s, %s ou %s", EntidadeContato, EntidadeEmpresa, EntidadeNegociacao)
+	}
+	if !chaveValida.MatchString(d.Chave) {
+		return errors.New("chave inválida: use letras minúsculas, números e _ começando por letra")
+	}
+	switch d.Tipo {
+	case TipoTexto, TipoNumero, TipoData:
+	case TipoSelecao, TipoMultiplaSelecao:
+		if len(d.opcoes()) == 0 {
+			return errors.New("campos de seleção exigem a lista de opcoes")
+		}
+	default:
+		return fmt.Errorf("tipo inválido: %q", d.Tipo)
+	}
+	if len(d.Padrao) > 0 && string(d.Padrao) != "null" {
+		v, err := decodificar(d.Padrao)
+		if err != nil {
+			return errors.New("padrao inválido")
+		}
+		if _, err := d.validarValor(v); err != nil {
+			return fmt.Errorf("padrao inválido: %v", err)
+		}
+	}
+	return nil
+}
+
+// ValidarValores confere os valores enviados contra as definições da entidade:
+// rejeita chaves desconhecidas, aplica os valores padrão, exige os obrigatórios e
+// normaliza números, datas e seleções. Retorna o JSON normalizado.
+func ValidarValores(defs []Definicao, valores datatypes.JSON) (datatypes.JSON, error) {
+	campos := map[string]interface{}{}
+	if len(valores) > 0 && string(valores) != "null" {
+		v, err := decodificar(valores)
+		if err != nil {
+			return nil, errors.New("campos_personalizados deve ser um objeto JSON")
+		}
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("campos_personalizados deve ser um objeto JSON")
+		}
+		campos = obj
+	}
+
+	porChave := make(map[string]Definicao, len(defs))
+	for _, d := range defs {
+		porChave[d.Chave] = d
+	}
+	for chave := range campos {
+		if _, ok := porChave[chave]; !ok {
+			return nil, fmt.Errorf("campo personalizado desconhecido: %s", chave)
+		}
+	}
+
+	for _, d := range defs {
+		v, ok := campos[d.Chave]
+		if !ok || v == nil {
+			if len(d.Padrao) > 0 && string(d.Padrao) != "null" {
+				padrao, err := decodificar(d.Padrao)
+				if err != nil {
+					return nil, err
+				}
+				v = padrao
+			} else if d.Obrigatorio {
+				return nil, fmt.Errorf("campo personalizado obrigatório: %s", d.Chave)
+			} else {
+				delete(campos, d.Chave)
+				continue
+			}
+		}
+		normalizado, err := d.validarValor(v)
+		if err != nil {
+			return nil, fmt.Errorf("campo %s: %v", d.Chave, err)
+		}
+		campos[d.Chave] = normalizado
+	}
+
+	resultado, err := json.Marshal(campos)
+	if err != nil {
+		return nil, err
+	}
+	return datatypes.JSON(resultado), nil
+}
+
+// validarValor verifica o tipo do valor e retorna a sua forma normalizada.
+func (d Definicao) validarValor(v interface{}) (interface{}, error) {
+	switch d.Tipo {
+	case TipoTexto:
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("esperado texto")
+		}
+		if d.Obrigatorio && s == "" {
+			return nil, errors.New("valor obrigatório")
+		}
+		return s, nil
+	case TipoNumero:
+		switch n := v.(type) {
+		case json.Number:
+			if _, err := n.Float64(); err != nil {
+				return nil, errors.New("esperado número")
+			}
+			return n, nil
+		case string:
+			if _, err := strconv.ParseFloat(n, 64); err != nil {
+				return nil, errors.New("esperado número")
+			}
+			return json.Number(n), nil
+		}
+		return nil, errors.New("esperado número")
+	case TipoData:
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("esperada data no formato AAAA-MM-DD")
+		}
+		data, err := ParseData(s)
+		if err != nil {
+			return nil, err
+		}
+		return data.Format("2006-01-02"), nil
+	case TipoSelecao:
+		s, ok := v.(string)
+		if !ok || !d.opcaoValida(s) {
+			return nil, fmt.Errorf("valor deve ser uma das opções %v", d.opcoes())
+		}
+		return s, nil
+	case TipoMultiplaSelecao:
+		lista, ok := v.([]interface{})
+		if !ok {
+			return nil, errors.New("esperada lista de opções")
+		}
+		vistos := make(map[string]bool)
+		selecionados := make([]string, 0, len(lista))
+		for _, item := range lista {
+			s, ok := item.(string)
+			if !ok || !d.opcaoValida(s) {
+				return nil, fmt.Errorf("valores devem estar entre as opções %v", d.opcoes())
+			}
+			if !vistos[s] {
+				vistos[s] = true
+				selecionados = append(selecionados, s)
+			}
+		}
+		if d.Obrigatorio && len(selecionados) == 0 {
+			return nil, errors.New("selecione ao menos uma opção")
+		}
+		return selecionados, nil
+	}
+	return nil, fmt.Errorf("tipo inválido: %q", d.Tipo)
+}
+
+// ParseData aceita datas no formato AAAA-MM-DD ou RFC 3339.
+func ParseData(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.New("esperada data no formato AAAA-MM-DD")
+}
+
+func (d Definicao) opcoes() []string {
+	var opcoes []string
+	if len(d.Opcoes) > 0 {
+		_ = json.Unmarshal(d.Opcoes, &opcoes)
+	}
+	return opcoes
+}
+
+func (d Definicao) opcaoValida(s string) bool {
+	for _, o := range d.opcoes() {
+		if o == s {
+			return true
+		}
+	}
+	return false
+}
+
+func decodificar(dados []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(dados))
+	dec.UseNumber()
+	var v interface{}
+	err := dec.Decode(&v)
+	return v, err
+}
@@ -0,0 +1,314 @@
+package captacao
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"my-crm-backend/internal/anotacao"
+	"my-crm-backend/internal/contato"
+	"my-crm-backend/internal/empresa"
+	"my-crm-backend/internal/negociacao"
+	"my-crm-backend/internal/negocio"
+	"my-crm-backend/internal/normalizacao"
+	"my-crm-backend/internal/telefone"
+
+	"gorm.io/gorm"
+)
+
+// Usuario é o login registrado como autor dos registros criados pela captação.
+const Usuario = "captacao"
+
+// janelaPadrao é a janela de duplicidade dos formulários que não definem a sua.
+const janelaPadrao = 10 * time.Minute
+
+// Validar confere a configuração do formulário.
+func Validar(f Formulario) error {
+	if f.Nome == "" {
+		return errors.New("nome é obrigatório")
+	}
+	if f.Funil == "" {
+		return errors.New("funil é obrigatório")
+	}
+	if f.JanelaDuplicidadeMinutos < 0 {
+		return errors.New("janela_duplicidade_minutos não pode ser negativa")
+	}
+	return nil
+}
+
+// Preparar valida e normaliza os dados de um envio: exige o nome e um meio de
+// contato, converte o telefone para E.164 e completa os parâmetros UTM a partir
+// da página de origem.
+func Preparar(env *Envio) error {
+	env.Nome = strings.TrimSpace(env.Nome)
+	env.Email = normalizacao.Email(env.Email)
+	env.Empresa = strings.TrimSpace(env.Empresa)
+	if env.Nome == "" {
+		return errors.New("nome é obrigatório")
+	}
+	if env.Email == "" && env.Telefone == "" {
+		return errors.New("informe e-mail ou telefone")
+	}
+	if env.Email != "" && (!strings.Contains(env.Email, "@") || strings.ContainsAny(env.Email, " ,;")) {
+		return errors.New("e-mail inválido")
+	}
+	if env.Telefone != "" {
+		numero, _, err := telefone.Normalizar(env.Telefone)
+		if err != nil {
+			return err
+		}
+		env.Telefone = numero
+	}
+	if env.CNPJ != "" {
+		env.CNPJ = normalizacao.Digitos(env.CNPJ)
+		if len(env.CNPJ) != 14 {
+			return errors.New("CNPJ inválido")
+		}
+	}
+	if u, err := url.Parse(env.Pagina); err == nil && env.Pagina != "" {
+		q := u.Query()
+		for destino, param := range map[*string]string{
+			&env.UTMSource:   "utm_source",
+			&env.UTMMedium:   "utm_medium",
+			&env.UTMCampaign: "utm_campaign",
+			&env.UTMTerm:     "utm_term",
+			&env.UTMContent:  "utm_content",
+		} {
+			if *destino == "" {
+				*destino = q.Get(param)
+			}
+		}
+	}
+	return nil
+}
+
+// hash identifica envios iguais do mesmo formulário.
+func hash(formularioID int, env Envio) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%s\x00%s\x00%s\x00%s", formularioID,
+		normalizacao.Texto(env.Nome), env.Email, env.Telefone,
+		normalizacao.NomeEmpresa(env.Empresa), normalizacao.Texto(env.Mensagem))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Captar registra o envio de um formulário já validado por Preparar. Envios de
+// robôs são apenas registrados como descartados, e reenvios dentro da janela de
+// duplicidade apontam para a negociação já aberta. Nos demais, localiza ou cria
+// a empresa e o contato, abre a negociação na primeira etapa do funil do
+// formulário e define o responsável.
+func (r *repository) Captar(f Formulario, env Envio, ip string) (Lead, error) {
+	agora := time.Now()
+	lead := Lead{
+		FormularioID: f.ID,
+		Hash:         hash(f.ID, env),
+		IP:           ip,
+		Nome:         env.Nome,
+		Email:        env.Email,
+		Telefone:     env.Telefone,
+		Empresa:      env.Empresa,
+		CNPJ:         env.CNPJ,
+		Mensagem:     env.Mensagem,
+		Pagina:       env.Pagina,
+		UTMSource:    env.UTMSource,
+		UTMMedium:    env.UTMMedium,
+		UTMCampaign:  env.UTMCampaign,
+		UTMTerm:      env.UTMTerm,
+		UTMContent:   env.UTMContent,
+		CreatedAt:    agora,
+	}
+	if env.Armadilha != "" {
+		lead.Status = LeadDescartado
+		err := r.db.Create(&lead).Error
+		return lead, err
+	}
+
+	janela := janelaPadrao
+	if f.JanelaDuplicidadeMinutos > 0 {
+		janela = time.Duration(f.JanelaDuplicidadeMinutos) * time.Minute
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Serializa envios iguais do mesmo formulário até o fim da transação,
+		// para que reenvios simultâneos não abram duas negociações.
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?, hashtext(?))", f.ID, lead.Hash).Error; err != nil {
+			return err
+		}
+		var anterior Lead
+		res := tx.Where("formulario_id = ? AND hash = ? AND status = ? AND created_at >= ?",
+			f.ID, lead.Hash, LeadCriado, agora.Add(-janela)).
+			Order("id DESC").Limit(1).Find(&anterior)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			lead.Status = LeadDuplicado
+			lead.EmpresaID, lead.ContatoID, lead.NegociacaoID = anterior.EmpresaID, anterior.ContatoID, anterior.NegociacaoID
+			return tx.Create(&lead).Error
+		}
+
+		c, encontrado, err := buscarContato(tx, env)
+		if err != nil {
+			return err
+		}
+		e, err := r.resolverEmpresa(tx, env, c, encontrado)
+		if err != nil {
+			return err
+		}
+		if c, err = r.resolverContato(tx, env, c, encontrado, e); err != nil {
+			return err
+		}
+		n, err := r.abrirNegociacao(tx, f, env, e, c)
+		if err != nil {
+			return err
+		}
+		lead.Status = LeadCriado
+		lead.EmpresaID, lead.ContatoID, lead.NegociacaoID = e.ID, c.ID, n.ID
+		return tx.Create(&lead).Error
+	})
+	return lead, err
+}
+
+// buscarContato localiza o contato pelo e-mail ou, sem correspondência, pelo telefone.
+func buscarContato(tx *gorm.DB, env Envio) (contato.Contato, bool, error) {
+	var c contato.Contato
+	if env.Email != "" {
+		res := tx.Preload("Vinculos").Where("lower(email) = ?", env.Email).Order("id").Limit(1).Find(&c)
+		if res.Error != nil || res.RowsAffected > 0 {
+			return c, res.Error == nil, res.Error
+		}
+	}
+	if env.Telefone != "" {
+		contatos, err := contato.NovoRepositorio(tx).BuscarPorTelefone(env.Telefone)
+		if err != nil || len(contatos) > 0 {
+			if len(contatos) > 0 {
+				c = contatos[0]
+			}
+			return c, err == nil, err
+		}
+	}
+	return c, false, nil
+}
+
+// resolverEmpresa usa a empresa atual do contato encontrado; senão procura pelo
+// CNPJ e pelo nome normalizado e, sem correspondência, cadastra a empresa. Sem
+// nome de empresa, o lead é tratado como pessoa física e a empresa leva o nome
+// do contato.
+func (r *repository) resolverEmpresa(tx *gorm.DB, env Envio, c contato.Contato, contatoEncontrado bool) (empresa.Empresa, error) {
+	var e empresa.Empresa
+	if contatoEncontrado && env.CNPJ == "" && env.Empresa == "" {
+		for _, v := range c.Vinculos {
+			if v.DataFim == nil {
+				err := tx.First(&e, v.EmpresaID).Error
+				return e, err
+			}
+		}
+	}
+	if env.CNPJ != "" {
+		res := tx.Where("regexp_replace(cnpj_matriz, '\\D', '', 'g') = ?", env.CNPJ).Order("id").Limit(1).Find(&e)
+		if res.Error != nil || res.RowsAffected > 0 {
+			return e, res.Error
+		}
+	}
+	nome := env.Empresa
+	if nome == "" {
+		nome = env.Nome
+	}
+	if alvo := normalizacao.NomeEmpresa(nome); alvo != "" {
+		var candidatas []empresa.Empresa
+		if err := tx.Select("id", "nome").Where("nome ILIKE ?", "%"+strings.Fields(alvo)[0]+"%").
+			Order("id").Find(&candidatas).Error; err != nil {
+			return e, err
+		}
+		for _, candidata := range candidatas {
+			if normalizacao.NomeEmpresa(candidata.Nome) == alvo {
+				err := tx.First(&e, candidata.ID).Error
+				return e, err
+			}
+		}
+	}
+	return empresa.NovoRepositorio(tx).Adicionar(empresa.Empresa{Nome: nome, CNPJMatriz: env.CNPJ})
+}
+
+// resolverContato cadastra o contato quando ele não foi encontrado e garante o
+// vínculo ativo com a empresa do lead.
+func (r *repository) resolverContato(tx *gorm.DB, env Envio, c contato.Contato, encontrado bool, e empresa.Empresa) (contato.Contato, error) {
+	repo := contato.NovoRepositorio(tx)
+	if !encontrado {
+		c = contato.Contato{Nome: env.Nome, Email: env.Email, Cargo: env.Cargo}
+		if env.Telefone != "" {
+			b, err := json.Marshal([]string{env.Telefone})
+			if err != nil {
+				return c, err
+			}
+			if c.Telefones, err = telefone.NormalizarLista(b); err != nil {
+				return c, err
+			}
+		}
+		var err error
+		if c, err = repo.Adicionar(c); err != nil {
+			return c, err
+		}
+	}
+	for _, v := range c.Vinculos {
+		if v.EmpresaID == e.ID && v.DataFim == nil {
+			return c, nil
+		}
+	}
+	_, err := repo.AdicionarVinculo(c.ID, contato.VinculoEmpresa{EmpresaID: e.ID, Cargo: env.Cargo})
+	return c, err
+}
+
+// abrirNegociacao cria a negociação na primeira etapa do funil do formulário,
+// com fonte e campanha vindas dos parâmetros UTM, registra a mensagem do lead
+// como anotação e define o responsável.
+func (r *repository) abrirNegociacao(tx *gorm.DB, f Formulario, env Envio, e empresa.Empresa, c contato.Contato) (negociacao.Negociacao, error) {
+	fonte := env.UTMSource
+	if fonte == "" {
+		fonte = f.Fonte
+	}
+	if fonte == "" {
+		fonte = "site"
+	}
+	n, err := negociacao.NovoRepositorio(tx).Adicionar(negociacao.Negociacao{
+		EmpresaID:        e.ID,
+		ContatoID:        c.ID,
+		NomeNegociacao:   fmt.Sprintf("%s - %s", e.Nome, f.Nome),
+		Responsavel:      f.Responsavel,
+		FunilVendas:      f.Funil,
+		EtapaFunilVendas: negocio.FunilOpcoes[0],
+		Fonte:            fonte,
+		Campanha:         env.UTMCampaign,
+		AlteradoPor:      Usuario,
+	})
+	if err != nil {
+		return n, err
+	}
+
+	if env.Mensagem != "" {
+		// A mensagem vem de fora: fica em um bloco de código para que menções e
+		// referências escritas nela não notifiquem ninguém.
+		texto := "```\n" + strings.ReplaceAll(env.Mensagem, "```", "'''") + "\n```"
+		if _, err := anotacao.NovoRepositorio(tx).Adicionar(anotacao.Anotacao{
+			Assunto:    "Mensagem do formulário " + f.Nome,
+			Anotacao:   texto,
+			Entidade:   anotacao.EntidadeNegociacao,
+			EntidadeID: n.ID,
+			Autor:      Usuario,
+		}); err != nil {
+			return n, err
+		}
+	}
+
+	if n.Responsavel == "" {
+		for _, a := range r.atribuidores {
+			if err := a.Atribuir(tx, &n); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
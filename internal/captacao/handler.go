@@ -0,0 +1,191 @@
+package captacao
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CabecalhoChave permite enviar a chave do formulário fora do corpo.
+const CabecalhoChave = "X-Formulario-Chave"
+
+// Handler define os manipuladores HTTP da captação de leads: o endpoint
+// público que recebe os formulários e a administração dos formulários.
+type Handler struct {
+	repo   Repository
+	limite *limitador
+}
+
+// NovoHandler cria e retorna um novo handler de captação. Cada IP pode enviar
+// até limitePorMinuto formulários por minuto (padrão: 10). O IP é o da conexão,
+// ou o de X-Forwarded-For quando ela vem de um proxy confiável do roteador
+// (PROXIES_CONFIAVEIS no servidor).
+func NovoHandler(repo Repository, limitePorMinuto int) *Handler {
+	if limitePorMinuto <= 0 {
+		limitePorMinuto = 10
+	}
+	return &Handler{repo: repo, limite: novoLimitador(limitePorMinuto, time.Minute)}
+}
+
+// Receber trata o envio público de um formulário de site, em JSON ou como
+// formulário HTML: {"chave": "...", "nome": "Ana", "email": "ana@acme.com",
+// "empresa": "ACME", "utm_source": "google", "utm_campaign": "frota-2025"}.
+// A chave também pode vir no cabeçalho X-Formulario-Chave. Envios aceitos,
+// repetidos ou descartados recebem a mesma resposta.
+func (h *Handler) Receber(c *gin.Context) {
+	if !h.limite.permitir(c.ClientIP(), time.Now()) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "muitos envios; tente novamente em instantes"})
+		return
+	}
+	var env Envio
+	if err := c.ShouldBind(&env); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if env.Chave == "" {
+		env.Chave = c.GetHeader(CabecalhoChave)
+	}
+	f, err := h.repo.ObterPorChave(env.Chave)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if env.Pagina == "" {
+		env.Pagina = c.Request.Referer()
+	}
+	// Envios de robôs não são validados, para que a resposta não os denuncie.
+	if env.Armadilha == "" {
+		if err := Preparar(&env); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if _, err := h.repo.Captar(*f, env, c.ClientIP()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "não foi possível registrar o envio"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "recebido"})
+}
+
+// Criar insere um novo formulário de captação com uma chave gerada.
+// Espera receber um JSON como: {"nome": "Site institucional", "site": "https://acme.com.br",
+// "funil": "Seguros empresariais", "responsavel": ""}
+func (h *Handler) Criar(c *gin.Context) {
+	var f Formulario
+	if err := c.ShouldBindJSON(&f); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := Validar(f); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	criado, err := h.repo.Adicionar(f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, criado)
+}
+
+// Listar retorna todos os formulários de captação.
+func (h *Handler) Listar(c *gin.Context) {
+	formularios, err := h.repo.Listar()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, formularios)
+}
+
+// Obter retorna um formulário pelo ID.
+func (h *Handler) Obter(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	f, err := h.repo.ObterPorID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, f)
+}
+
+// Atualizar altera a configuração de um formulário.
+func (h *Handler) Atualizar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	f := Formulario{Ativo: true}
+	if err := c.ShouldBindJSON(&f); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := Validar(f); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	atualizado, err := h.repo.Atualizar(id, f)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, atualizado)
+}
+
+// GerarChave troca a chave pública de um formulário, por exemplo quando ela
+// passou a ser usada indevidamente.
+func (h *Handler) GerarChave(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	f, err := h.repo.GerarChave(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, f)
+}
+
+// Deletar remove um formulário de captação.
+func (h *Handler) Deletar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	if err := h.repo.Deletar(id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrFormularioNaoEncontrado) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListarLeads retorna os envios recebidos pelo formulário, opcionalmente
+// filtrados por ?status=criado|duplicado|descartado.
+func (h *Handler) ListarLeads(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	leads, err := h.repo.ListarLeads(id, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, leads)
+}
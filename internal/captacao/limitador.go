@@ -0,0 +1,44 @@
+package captacao
+
+import (
+	"sync"
+	"time"
+)
+
+// limitador restringe, em memória, quantos envios cada origem pode fazer por
+// janela de tempo. Com várias instâncias da API, o limite vale por instância.
+type limitador struct {
+	mu        sync.Mutex
+	maximo    int
+	janela    time.Duration
+	contagens map[string]*contagem
+}
+
+type contagem struct {
+	inicio time.Time
+	total  int
+}
+
+func novoLimitador(maximo int, janela time.Duration) *limitador {
+	return &limitador{maximo: maximo, janela: janela, contagens: map[string]*contagem{}}
+}
+
+// permitir registra um envio da origem e informa se ele está dentro do limite.
+func (l *limitador) permitir(origem string, agora time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.contagens) > 10000 {
+		for k, c := range l.contagens {
+			if agora.Sub(c.inicio) >= l.janela {
+				delete(l.contagens, k)
+			}
+		}
+	}
+	c, ok := l.contagens[origem]
+	if !ok || agora.Sub(c.inicio) >= l.janela {
+		l.contagens[origem] = &contagem{inicio: agora, total: 1}
+		return true
+	}
+	c.total++
+	return c.total <= l.maximo
+}
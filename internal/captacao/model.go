@@ -0,0 +1,99 @@
+package captacao
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Formulario é um ponto de captação de leads em um site. A Chave, pública, é
+// enviada pelo formulário do site e identifica o funil em que as negociações
+// são abertas.
+type Formulario struct {
+	ID          int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	Nome        string `json:"nome"`
+	Site        string `json:"site,omitempty"` // Endereço do site, apenas informativo
+	Chave       string `json:"chave" gorm:"size:64;uniqueIndex;not null"`
+	Funil       string `json:"funil"`                 // FunilVendas das negociações abertas; a etapa é a primeira do funil
+	Fonte       string `json:"fonte,omitempty"`       // Fonte quando o lead chega sem utm_source; vazio usa "site"
+	Responsavel string `json:"responsavel,omitempty"` // Dono fixo das negociações; vazio usa a distribuição de leads
+	Ativo       bool   `json:"ativo" gorm:"not null"`
+
+	// Envios iguais dentro da janela são tratados como reenvio do mesmo lead.
+	JanelaDuplicidadeMinutos int `json:"janela_duplicidade_minutos"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Formulario) TableName() string {
+	return "formularios_captacao"
+}
+
+// Envio são os dados enviados pelo formulário do site, em JSON ou como
+// formulário HTML. Os parâmetros UTM ausentes são lidos da Pagina, quando ela
+// os tiver.
+type Envio struct {
+	Chave    string `json:"chave" form:"chave"`
+	Nome     string `json:"nome" form:"nome"`
+	Email    string `json:"email" form:"email"`
+	Telefone string `json:"telefone" form:"telefone"`
+	Empresa  string `json:"empresa" form:"empresa"`
+	CNPJ     string `json:"cnpj" form:"cnpj"`
+	Cargo    string `json:"cargo" form:"cargo"`
+	Mensagem string `json:"mensagem" form:"mensagem"`
+	Pagina   string `json:"pagina" form:"pagina"` // URL da página do formulário
+
+	UTMSource   string `json:"utm_source" form:"utm_source"`
+	UTMMedium   string `json:"utm_medium" form:"utm_medium"`
+	UTMCampaign string `json:"utm_campaign" form:"utm_campaign"`
+	UTMTerm     string `json:"utm_term" form:"utm_term"`
+	UTMContent  string `json:"utm_content" form:"utm_content"`
+
+	// Armadilha é um campo escondido do formulário: pessoas não o preenchem,
+	// robôs sim. Envios com ele preenchido são descartados.
+	Armadilha string `json:"website" form:"website"`
+}
+
+// Situações de um lead recebido.
+const (
+	LeadCriado     = "criado"     // Negociação aberta
+	LeadDuplicado  = "duplicado"  // Reenvio de um lead recebido na janela de duplicidade
+	LeadDescartado = "descartado" // Envio de robô (campo armadilha preenchido)
+)
+
+// Lead registra cada envio recebido por um formulário e o que foi feito com ele.
+type Lead struct {
+	ID           int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	FormularioID int    `json:"formulario_id" gorm:"index:idx_lead_duplicidade,priority:1;not null"`
+	Status       string `json:"status" gorm:"index"`
+	Hash         string `json:"-" gorm:"size:64;index:idx_lead_duplicidade,priority:2"`
+	IP           string `json:"ip,omitempty"`
+
+	Nome     string `json:"nome"`
+	Email    string `json:"email,omitempty"`
+	Telefone string `json:"telefone,omitempty"`
+	Empresa  string `json:"empresa,omitempty"`
+	CNPJ     string `json:"cnpj,omitempty"`
+	Mensagem string `json:"mensagem,omitempty"`
+	Pagina   string `json:"pagina,omitempty"`
+
+	UTMSource   string `json:"utm_source,omitempty"`
+	UTMMedium   string `json:"utm_medium,omitempty"`
+	UTMCampaign string `json:"utm_campaign,omitempty"`
+	UTMTerm     string `json:"utm_term,omitempty"`
+	UTMContent  string `json:"utm_content,omitempty"`
+
+	EmpresaID    int `json:"empresa_id,omitempty"`
+	ContatoID    int `json:"contato_id,omitempty"`
+	NegociacaoID int `json:"negociacao_id,omitempty"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_lead_duplicidade,priority:3"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Lead) TableName() string {
+	return "leads_captados"
+}
@@ -0,0 +1,156 @@
+package captacao
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"my-crm-backend/internal/negociacao"
+
+	"gorm.io/gorm"
+)
+
+// ErrFormularioNaoEncontrado indica que o formulário não existe ou, na
+// captação, que a chave não pertence a um formulário ativo.
+var ErrFormularioNaoEncontrado = errors.New("formulário não encontrado")
+
+// Atribuidor escolhe o responsável por uma negociação recém-aberta, na mesma
+// transação em que ela foi criada.
+type Atribuidor interface {
+	Atribuir(tx *gorm.DB, n *negociacao.Negociacao) error
+}
+
+// Repository define as operações sobre os formulários e a captação de leads.
+type Repository interface {
+	Adicionar(f Formulario) (Formulario, error)
+	Listar() ([]Formulario, error)
+	ObterPorID(id int) (*Formulario, error)
+	ObterPorChave(chave string) (*Formulario, error)
+	Atualizar(id int, updated Formulario) (Formulario, error)
+	GerarChave(id int) (Formulario, error)
+	Deletar(id int) error
+	ListarLeads(formularioID int, status string) ([]Lead, error)
+	Captar(f Formulario, env Envio, ip string) (Lead, error)
+}
+
+type repository struct {
+	db           *gorm.DB
+	atribuidores []Atribuidor
+}
+
+// NovoRepositorio cria e retorna um repositório baseado em GORM. Os
+// atribuidores definem o responsável pelas negociações abertas por formulários
+// sem responsável fixo.
+func NovoRepositorio(db *gorm.DB, atribuidores ...Atribuidor) Repository {
+	return &repository{db: db, atribuidores: atribuidores}
+}
+
+// novaChave gera a chave pública de um formulário.
+func novaChave() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Adicionar cria o formulário ativo com uma nova chave.
+func (r *repository) Adicionar(f Formulario) (Formulario, error) {
+	chave, err := novaChave()
+	if err != nil {
+		return Formulario{}, err
+	}
+	f.ID = 0
+	f.Chave = chave
+	f.Ativo = true
+	err = r.db.Create(&f).Error
+	return f, err
+}
+
+// Listar retorna todos os formulários.
+func (r *repository) Listar() ([]Formulario, error) {
+	formularios := []Formulario{}
+	err := r.db.Order("id").Find(&formularios).Error
+	return formularios, err
+}
+
+// ObterPorID busca um formulário pelo ID.
+func (r *repository) ObterPorID(id int) (*Formulario, error) {
+	var f Formulario
+	if err := r.db.First(&f, id).Error; err != nil {
+		return nil, ErrFormularioNaoEncontrado
+	}
+	return &f, nil
+}
+
+// ObterPorChave busca um formulário ativo pela chave pública.
+func (r *repository) ObterPorChave(chave string) (*Formulario, error) {
+	var f Formulario
+	if chave == "" {
+		return nil, ErrFormularioNaoEncontrado
+	}
+	if err := r.db.Where("chave = ? AND ativo", chave).First(&f).Error; err != nil {
+		return nil, ErrFormularioNaoEncontrado
+	}
+	return &f, nil
+}
+
+// Atualizar altera a configuração do formulário. A chave só muda por GerarChave.
+func (r *repository) Atualizar(id int, updated Formulario) (Formulario, error) {
+	var f Formulario
+	if err := r.db.First(&f, id).Error; err != nil {
+		return Formulario{}, ErrFormularioNaoEncontrado
+	}
+	err := r.db.Model(&f).
+		Select("nome", "site", "funil", "fonte", "responsavel", "ativo", "janela_duplicidade_minutos").
+		Updates(updated).Error
+	if err != nil {
+		return Formulario{}, err
+	}
+	err = r.db.First(&f, id).Error
+	return f, err
+}
+
+// GerarChave troca a chave do formulário; a anterior deixa de ser aceita.
+func (r *repository) GerarChave(id int) (Formulario, error) {
+	var f Formulario
+	if err := r.db.First(&f, id).Error; err != nil {
+		return Formulario{}, ErrFormularioNaoEncontrado
+	}
+	chave, err := novaChave()
+	if err != nil {
+		return Formulario{}, err
+	}
+	if err := r.db.Model(&f).Update("chave", chave).Error; err != nil {
+		return Formulario{}, err
+	}
+	f.Chave = chave
+	return f, nil
+}
+
+// Deletar remove um formulário. Os leads já recebidos são mantidos.
+func (r *repository) Deletar(id int) error {
+	res := r.db.Delete(&Formulario{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrFormularioNaoEncontrado
+	}
+	return nil
+}
+
+// ListarLeads retorna os últimos 200 leads recebidos pelo formulário,
+// opcionalmente de uma situação, do mais recente ao mais antigo.
+func (r *repository) ListarLeads(formularioID int, status string) ([]Lead, error) {
+	if err := r.db.Select("id").First(&Formulario{}, formularioID).Error; err != nil {
+		return nil, ErrFormularioNaoEncontrado
+	}
+	leads := []Lead{}
+	query := r.db.Where("formulario_id = ?", formularioID).Order("id DESC").Limit(200)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&leads).Error
+	return leads, err
+}
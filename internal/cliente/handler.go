@@ -4,17 +4,20 @@ import (
 	"net/http"
 	"strconv"
 
+	"my-crm-backend/internal/endereco"
+
 	"github.com/gin-gonic/gin"
 )
 
 // Handler define os manipuladores das requisições para Cliente.
 type Handler struct {
-	repo *Repositorio
+	repo      *Repositorio
+	enderecos endereco.Completador
 }
 
 // NovoHandler cria um novo handler para cliente.
-func NovoHandler(repo *Repositorio) *Handler {
-	return &Handler{repo: repo}
+func NovoHandler(repo *Repositorio, enderecos endereco.Completador) *Handler {
+	return &Handler{repo: repo, enderecos: enderecos}
 }
 
 // CriarCliente trata a criação de um novo cliente.
@@ -24,6 +27,10 @@ func (h *Handler) CriarCliente(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := h.enderecos.Completar(&novoCliente.Endereco); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	clienteCriado, err := h.repo.Adicionar(novoCliente)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -71,6 +78,17 @@ func (h *Handler) AtualizarCliente(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// O endereço enviado é conferido junto com o que o cliente já tem.
+	atual, err := h.repo.ObterPorID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	updated.Endereco = endereco.Mesclar(atual.Endereco, updated.Endereco)
+	if err := h.enderecos.Completar(&updated.Endereco); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	clienteAtualizado, err := h.repo.Atualizar(id, updated)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -0,0 +1,20 @@
+package cliente
+
+import "gorm.io/gorm"
+
+// MigrarEnderecoLegado copia o antigo endereço em texto livre (coluna "endereco")
+// para o logradouro do endereço estruturado e remove a coluna antiga.
+// É seguro executar a cada inicialização: sem a coluna, nada é feito.
+func MigrarEnderecoLegado(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&Cliente{}, "endereco") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`UPDATE clientes SET endereco_logradouro = endereco
+			WHERE endereco IS NOT NULL AND endereco <> ''
+			  AND (endereco_logradouro IS NULL OR endereco_logradouro = '')`).Error; err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&Cliente{}, "endereco")
+	})
+}
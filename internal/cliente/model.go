@@ -4,16 +4,17 @@ import (
 	"time"
 
 	"my-crm-backend/internal/empresa"
+	"my-crm-backend/internal/endereco"
 
 	"gorm.io/gorm"
 )
 
 type Cliente struct {
-	ID       int    `json:"id" gorm:"primaryKey;autoIncrement"`
-	Nome     string `json:"nome"`
-	CNPJ     string `json:"cnpj"`
-	Endereco string `json:"endereco"`
-	Contato  string `json:"contato"`
+	ID       int               `json:"id" gorm:"primaryKey;autoIncrement"`
+	Nome     string            `json:"nome"`
+	CNPJ     string            `json:"cnpj"`
+	Endereco endereco.Endereco `json:"endereco" gorm:"embedded;embeddedPrefix:endereco_"`
+	Contato  string            `json:"contato"`
 
 	Empresas []empresa.Empresa `json:"empresas" gorm:"foreignKey:ClienteID"`
 
@@ -0,0 +1,48 @@
+package consolidado
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler define os manipuladores HTTP para as consultas consolidadas.
+type Handler struct {
+	repo Repository
+}
+
+// NovoHandler cria e retorna um novo handler para consultas consolidadas.
+func NovoHandler(repo Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+// PorMatriz retorna os totais da matriz e de suas filiais: GET /api/empresas/:id/consolidado
+func (h *Handler) PorMatriz(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	resumo, err := h.repo.PorMatriz(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resumo)
+}
+
+// PorGrupo retorna os totais de um grupo econômico: GET /api/grupos/:id/consolidado
+func (h *Handler) PorGrupo(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	resumo, err := h.repo.PorGrupo(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resumo)
+}
@@ -0,0 +1,11 @@
+package consolidado
+
+// Resumo totaliza os indicadores de um conjunto de empresas (uma matriz com suas filiais ou um grupo econômico).
+type Resumo struct {
+	EmpresaIDs       []int   `json:"empresa_ids"`
+	Negociacoes      int64   `json:"negociacoes"`
+	ValorNegociacoes float64 `json:"valor_negociacoes"`
+	TarefasAbertas   int64   `json:"tarefas_abertas"`
+	ApolicesQuiver   int64   `json:"apolices_quiver"`
+	PremioQuiver     float64 `json:"premio_quiver"`
+}
@@ -0,0 +1,111 @@
+package consolidado
+
+import (
+	"errors"
+
+	"my-crm-backend/internal/empresa"
+	"my-crm-backend/internal/negociacao"
+	"my-crm-backend/internal/normalizacao"
+	"my-crm-backend/internal/quiver"
+	"my-crm-backend/internal/tarefa"
+
+	"gorm.io/gorm"
+)
+
+// Repository define as consultas consolidadas por matriz e por grupo econômico.
+type Repository interface {
+	PorMatriz(empresaID int) (Resumo, error)
+	PorGrupo(grupoID int) (Resumo, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NovoRepositorio cria e retorna um repositório baseado em GORM.
+func NovoRepositorio(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// PorMatriz totaliza a empresa informada e todas as suas filiais.
+func (r *repository) PorMatriz(empresaID int) (Resumo, error) {
+	var empresas []empresa.Empresa
+	if err := r.db.Where("id = ? OR matriz_id = ?", empresaID, empresaID).Find(&empresas).Error; err != nil {
+		return Resumo{}, err
+	}
+	if len(empresas) == 0 {
+		return Resumo{}, errors.New("empresa not found")
+	}
+	return r.calcular(empresas)
+}
+
+// PorGrupo totaliza todas as empresas do grupo econômico, incluindo as filiais de suas matrizes.
+func (r *repository) PorGrupo(grupoID int) (Resumo, error) {
+	var existe int64
+	if err := r.db.Table("grupos_economicos").Where("id = ? AND deleted_at IS NULL", grupoID).Count(&existe).Error; err != nil {
+		return Resumo{}, err
+	}
+	if existe == 0 {
+		return Resumo{}, errors.New("grupo econômico não encontrado")
+	}
+	var empresas []empresa.Empresa
+	err := r.db.
+		Where("grupo_economico_id = ?", grupoID).
+		Or("matriz_id IN (?)", r.db.Model(&empresa.Empresa{}).Select("id").Where("grupo_economico_id = ?", grupoID)).
+		Find(&empresas).Error
+	if err != nil {
+		return Resumo{}, err
+	}
+	return r.calcular(empresas)
+}
+
+func (r *repository) calcular(empresas []empresa.Empresa) (Resumo, error) {
+	resumo := Resumo{EmpresaIDs: []int{}}
+	if len(empresas) == 0 {
+		return resumo, nil
+	}
+	raizes := make([]string, 0, len(empresas))
+	vistas := make(map[string]bool)
+	for _, e := range empresas {
+		resumo.EmpresaIDs = append(resumo.EmpresaIDs, e.ID)
+		if raiz := normalizacao.RaizCNPJ(e.CNPJMatriz); raiz != "" && !vistas[raiz] {
+			vistas[raiz] = true
+			raizes = append(raizes, raiz)
+		}
+	}
+
+	var negociacoes struct {
+		Quantidade int64
+		Valor      float64
+	}
+	if err := r.db.Model(&negociacao.Negociacao{}).
+		Select("COUNT(*) AS quantidade, COALESCE(SUM(valor_negociacao), 0) AS valor").
+		Where("empresa_id IN ?", resumo.EmpresaIDs).
+		Scan(&negociacoes).Error; err != nil {
+		return Resumo{}, err
+	}
+	resumo.Negociacoes = negociacoes.Quantidade
+	resumo.ValorNegociacoes = negociacoes.Valor
+
+	if err := r.db.Model(&tarefa.Tarefa{}).
+		Where("empresa_id IN ? AND status IN ?", resumo.EmpresaIDs, tarefa.StatusesAbertos).
+		Count(&resumo.TarefasAbertas).Error; err != nil {
+		return Resumo{}, err
+	}
+
+	if len(raizes) > 0 {
+		var apolices struct {
+			Quantidade int64
+			Premio     float64
+		}
+		if err := r.db.Model(&quiver.Quiver{}).
+			Select("COUNT(*) AS quantidade, COALESCE(SUM(valor_premio), 0) AS premio").
+			Where("LEFT(regexp_replace(cpf_cnpj, '[^0-9]', '', 'g'), 8) IN ?", raizes).
+			Scan(&apolices).Error; err != nil {
+			return Resumo{}, err
+		}
+		resumo.ApolicesQuiver = apolices.Quantidade
+		resumo.PremioQuiver = apolices.Premio
+	}
+	return resumo, nil
+}
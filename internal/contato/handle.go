@@ -4,17 +4,21 @@ import (
 	"net/http"
 	"strconv"
 
+	"my-crm-backend/internal/campopersonalizado"
+	"my-crm-backend/internal/telefone"
+
 	"github.com/gin-gonic/gin"
 )
 
 // Handler define os manipuladores HTTP para as operações de contato.
 type Handler struct {
-	repo Repository
+	repo   Repository
+	campos campopersonalizado.Validador
 }
 
 // NovoHandler cria um novo handler para Contato.
-func NovoHandler(repo Repository) *Handler {
-	return &Handler{repo: repo}
+func NovoHandler(repo Repository, campos campopersonalizado.Validador) *Handler {
+	return &Handler{repo: repo, campos: campos}
 }
 
 // CriarContato cria um novo contato.
@@ -28,6 +32,16 @@ func (h *Handler) CriarContato(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Nome é obrigatório"})
 		return
 	}
+	campos, err := h.campos.Validar(campopersonalizado.EntidadeContato, contato.CamposPersonalizados)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	contato.CamposPersonalizados = campos
+	if contato.Telefones, err = telefone.NormalizarLista(contato.Telefones); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	novoContato, err := h.repo.Adicionar(contato)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -37,8 +51,34 @@ func (h *Handler) CriarContato(c *gin.Context) {
 }
 
 // ListarContatos retorna todos os contatos.
+// Aceita filtros e ordenação por campos personalizados (ex.: ?cp.origem=site&ordenar=cp.origem)
+// e a busca por telefone (ex.: ?telefone=11999998888), usada para identificar ligações recebidas.
 func (h *Handler) ListarContatos(c *gin.Context) {
-	contatos, err := h.repo.Listar()
+	if numero := c.Query("telefone"); numero != "" {
+		normalizado, _, err := telefone.Normalizar(numero)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		contatos, err := h.repo.BuscarPorTelefone(normalizado)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, contatos)
+		return
+	}
+	defs, err := h.campos.Definicoes(campopersonalizado.EntidadeContato)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	filtro, err := campopersonalizado.LerFiltro(c.Request.URL.Query(), defs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	contatos, err := h.repo.Listar(filtro)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -75,6 +115,20 @@ func (h *Handler) AtualizarContato(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if len(updated.CamposPersonalizados) > 0 {
+		campos, err := h.campos.Validar(campopersonalizado.EntidadeContato, updated.CamposPersonalizados)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updated.CamposPersonalizados = campos
+	}
+	if len(updated.Telefones) > 0 {
+		if updated.Telefones, err = telefone.NormalizarLista(updated.Telefones); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
 	contatoAtualizado, err := h.repo.Atualizar(id, updated)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -97,3 +151,102 @@ func (h *Handler) DeletarContato(c *gin.Context) {
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// ListarPorEmpresa retorna os contatos vinculados a uma empresa: GET /api/empresas/:id/contatos
+// Aceita ?ativos=true para listar apenas os vínculos sem data de término.
+func (h *Handler) ListarPorEmpresa(c *gin.Context) {
+	empresaID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	contatos, err := h.repo.ListarPorEmpresa(empresaID, c.Query("ativos") == "true")
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, contatos)
+}
+
+// AdicionarVinculo relaciona o contato a uma empresa.
+// Espera receber um JSON com: {"empresa_id": 1, "cargo": "Diretor", "data_inicio": "2024-01-01T00:00:00Z"}
+func (h *Handler) AdicionarVinculo(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	var v VinculoEmpresa
+	if err := c.ShouldBindJSON(&v); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if v.EmpresaID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empresa_id é obrigatório"})
+		return
+	}
+	novo, err := h.repo.AdicionarVinculo(id, v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, novo)
+}
+
+// AtualizarVinculo altera o cargo ou o período de um vínculo.
+// Para encerrar o vínculo, envie {"data_fim": "2025-06-30T00:00:00Z"}.
+func (h *Handler) AtualizarVinculo(c *gin.Context) {
+	id, vinculoID, ok := lerIDsVinculo(c)
+	if !ok {
+		return
+	}
+	var v VinculoEmpresa
+	if err := c.ShouldBindJSON(&v); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	atualizado, err := h.repo.AtualizarVinculo(id, vinculoID, v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, atualizado)
+}
+
+// RemoverVinculo exclui um vínculo do contato.
+func (h *Handler) RemoverVinculo(c *gin.Context) {
+	id, vinculoID, ok := lerIDsVinculo(c)
+	if !ok {
+		return
+	}
+	if err := h.repo.RemoverVinculo(id, vinculoID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// MigrarEmpresasLegadas converte o campo texto "empresa" dos contatos em vínculos.
+// Por padrão apenas simula e retorna o relatório; envie ?aplicar=true para gravar os vínculos.
+func (h *Handler) MigrarEmpresasLegadas(c *gin.Context) {
+	relatorio, err := h.repo.MigrarEmpresasLegadas(c.Query("aplicar") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, relatorio)
+}
+
+func lerIDsVinculo(c *gin.Context) (int, int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return 0, 0, false
+	}
+	vinculoID, err := strconv.Atoi(c.Param("vinculoId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "vinculoId inválido"})
+		return 0, 0, false
+	}
+	return id, vinculoID, true
+}
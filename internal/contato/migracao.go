@@ -0,0 +1,120 @@
+package contato
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"my-crm-backend/internal/empresa"
+	"my-crm-backend/internal/normalizacao"
+	"my-crm-backend/internal/telefone"
+
+	"gorm.io/gorm"
+)
+
+// MigrarEmpresasLegadas procura, para cada contato com o campo texto Empresa preenchido
+// e que nunca teve vínculo, a empresa de mesmo nome (ou razão social) normalizado. Quando há
+// exatamente uma candidata, cria o vínculo; os demais casos são apenas reportados.
+// Com aplicar falso, nada é gravado e o relatório indica o que seria feito.
+func MigrarEmpresasLegadas(db *gorm.DB, aplicar bool) (RelatorioMigracao, error) {
+	relatorio := RelatorioMigracao{
+		Aplicado:           aplicar,
+		SemCorrespondencia: []ContatoPendente{},
+		Ambiguos:           []ContatoPendente{},
+	}
+
+	var empresas []empresa.Empresa
+	if err := db.Find(&empresas).Error; err != nil {
+		return relatorio, err
+	}
+	porNome := make(map[string][]int)
+	for _, e := range empresas {
+		vistos := make(map[string]bool)
+		for _, nome := range []string{e.Nome, e.RazaoSocial} {
+			n := normalizacao.NomeEmpresa(nome)
+			if n == "" || vistos[n] {
+				continue
+			}
+			vistos[n] = true
+			porNome[n] = append(porNome[n], e.ID)
+		}
+	}
+
+	var contatos []Contato
+	err := db.
+		Where("empresa IS NOT NULL AND empresa <> ''").
+		// Vínculos removidos também contam: o contato já foi migrado e não deve
+		// voltar a ser vinculado pelo texto legado.
+		Where("id NOT IN (?)", db.Unscoped().Model(&VinculoEmpresa{}).Select("contato_id")).
+		Find(&contatos).Error
+	if err != nil {
+		return relatorio, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, c := range contatos {
+			candidatas := porNome[normalizacao.NomeEmpresa(c.Empresa)]
+			pendente := ContatoPendente{ContatoID: c.ID, Nome: c.Nome, Empresa: c.Empresa, Candidatas: candidatas}
+			switch len(candidatas) {
+			case 0:
+				relatorio.SemCorrespondencia = append(relatorio.SemCorrespondencia, pendente)
+				continue
+			case 1:
+			default:
+				relatorio.Ambiguos = append(relatorio.Ambiguos, pendente)
+				continue
+			}
+			relatorio.Vinculados++
+			if !aplicar {
+				continue
+			}
+			v := VinculoEmpresa{
+				ContatoID:  c.ID,
+				EmpresaID:  candidatas[0],
+				Cargo:      c.Cargo,
+				DataInicio: c.CreatedAt,
+			}
+			if err := tx.Create(&v).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return relatorio, err
+}
+
+// MigrarTelefones normaliza para E.164 os telefones já gravados, convertendo o formato
+// legado (lista de textos) em objetos {numero, tipo, principal}. Contatos com números
+// inválidos não são alterados e têm os IDs retornados para correção manual.
+func MigrarTelefones(db *gorm.DB) (int, []int, error) {
+	var contatos []Contato
+	if err := db.Select("id", "telefones").Where("telefones IS NOT NULL").Find(&contatos).Error; err != nil {
+		return 0, nil, err
+	}
+	convertidos := 0
+	invalidos := []int{}
+	for _, c := range contatos {
+		normalizado, err := telefone.NormalizarLista(c.Telefones)
+		if err != nil {
+			invalidos = append(invalidos, c.ID)
+			continue
+		}
+		if mesmoJSON(normalizado, c.Telefones) {
+			continue
+		}
+		if err := db.Model(&Contato{}).Where("id = ?", c.ID).UpdateColumn("telefones", normalizado).Error; err != nil {
+			return convertidos, invalidos, err
+		}
+		convertidos++
+	}
+	return convertidos, invalidos, nil
+}
+
+// mesmoJSON compara dois documentos JSON pelo conteúdo: o JSONB devolvido pelo
+// banco tem espaçamento diferente do gerado pelo Go.
+func mesmoJSON(a, b []byte) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -3,6 +3,8 @@ package contato
 import (
 	"time"
 
+	"my-crm-backend/internal/empresa"
+
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
@@ -11,14 +13,17 @@ type Contato struct {
 	ID                    int            `json:"id" gorm:"primaryKey;autoIncrement"`
 	Nome                  string         `json:"nome"`
 	Cargo                 string         `json:"cargo,omitempty"`
-	Telefones             datatypes.JSON `json:"telefones,omitempty"` // Exemplo: ["+5511999998888", "+551188887777"]
+	Telefones             datatypes.JSON `json:"telefones,omitempty"` // Exemplo: [{"numero": "+5511999998888", "tipo": "celular", "principal": true}]
 	Email                 string         `json:"email,omitempty"`
-	Empresa               string         `json:"empresa,omitempty"`
+	Empresa               string         `json:"empresa,omitempty"` // Legado: use Vinculos para relacionar o contato às empresas
 	InformacoesAdicionais string         `json:"informacoes_adicionais,omitempty"`
 	LinkedIn              string         `json:"linkedin,omitempty"`
 	CamposPersonalizados  datatypes.JSON `json:"campos_personalizados,omitempty"`
 	EDecisor              bool           `json:"e_decisor"`
 
+	// Empresas em que o contato trabalha ou trabalhou
+	Vinculos []VinculoEmpresa `json:"vinculos,omitempty" gorm:"foreignKey:ContatoID"`
+
 	// Campo auxiliar para armazenar os IDs das negociações em que o contato está envolvido
 	NegociacaoIDs []int `json:"negociacao_ids,omitempty" gorm:"-"`
 
@@ -26,3 +31,40 @@ type Contato struct {
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
+
+// VinculoEmpresa relaciona um contato a uma empresa durante um período, com o cargo ocupado.
+// Um vínculo sem DataFim é considerado ativo.
+type VinculoEmpresa struct {
+	ID         int              `json:"id" gorm:"primaryKey;autoIncrement"`
+	ContatoID  int              `json:"contato_id" gorm:"index"`
+	EmpresaID  int              `json:"empresa_id" gorm:"index"`
+	Empresa    *empresa.Empresa `json:"empresa,omitempty" gorm:"foreignKey:EmpresaID"`
+	Cargo      string           `json:"cargo,omitempty"`
+	DataInicio time.Time        `json:"data_inicio"`
+	DataFim    *time.Time       `json:"data_fim,omitempty"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (VinculoEmpresa) TableName() string {
+	return "contato_empresas"
+}
+
+// RelatorioMigracao resume a conversão do campo legado Contato.Empresa em vínculos.
+type RelatorioMigracao struct {
+	Aplicado           bool              `json:"aplicado"`
+	Vinculados         int               `json:"vinculados"`
+	SemCorrespondencia []ContatoPendente `json:"sem_correspondencia"`
+	Ambiguos           []ContatoPendente `json:"ambiguos"`
+}
+
+// ContatoPendente descreve um contato cujo campo Empresa não pôde ser vinculado automaticamente.
+type ContatoPendente struct {
+	ContatoID  int    `json:"contato_id"`
+	Nome       string `json:"nome"`
+	Empresa    string `json:"empresa"`
+	Candidatas []int  `json:"candidatas,omitempty"` // IDs das empresas com o mesmo nome normalizado
+}
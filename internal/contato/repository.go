@@ -1,7 +1,12 @@
 package contato
 
 import (
+	"encoding/json"
 	"errors"
+	"time"
+
+	"my-crm-backend/internal/campopersonalizado"
+	"my-crm-backend/internal/empresa"
 
 	"gorm.io/gorm"
 )
@@ -9,10 +14,16 @@ import (
 // Repository define as operações básicas para manipular contatos.
 type Repository interface {
 	Adicionar(c Contato) (Contato, error)
-	Listar() ([]Contato, error)
+	Listar(filtro campopersonalizado.Filtro) ([]Contato, error)
+	BuscarPorTelefone(numero string) ([]Contato, error)
 	ObterPorID(id int) (*Contato, error)
 	Atualizar(id int, updated Contato) (Contato, error)
 	Deletar(id int) error
+	ListarPorEmpresa(empresaID int, apenasAtivos bool) ([]Contato, error)
+	AdicionarVinculo(contatoID int, v VinculoEmpresa) (VinculoEmpresa, error)
+	AtualizarVinculo(contatoID, vinculoID int, updated VinculoEmpresa) (VinculoEmpresa, error)
+	RemoverVinculo(contatoID, vinculoID int) error
+	MigrarEmpresasLegadas(aplicar bool) (RelatorioMigracao, error)
 }
 
 type repository struct {
@@ -30,21 +41,51 @@ func (r *repository) Adicionar(c Contato) (Contato, error) {
 	return c, err
 }
 
-// Listar retorna todos os contatos do banco de dados.
-func (r *repository) Listar() ([]Contato, error) {
+// Listar retorna os contatos do banco de dados, aplicando o filtro por campos personalizados.
+func (r *repository) Listar(filtro campopersonalizado.Filtro) ([]Contato, error) {
 	var contatos []Contato
-	err := r.db.Find(&contatos).Error
+	if err := filtro.Aplicar(r.db.Preload("Vinculos")).Find(&contatos).Error; err != nil {
+		return nil, err
+	}
+	err := r.preencherNegociacoes(contatos)
+	return contatos, err
+}
+
+// BuscarPorTelefone retorna os contatos que possuem o número informado (em E.164),
+// tanto no formato estruturado quanto no formato legado de lista de textos.
+func (r *repository) BuscarPorTelefone(numero string) ([]Contato, error) {
+	estruturado, err := json.Marshal([]map[string]string{{"numero": numero}})
+	if err != nil {
+		return nil, err
+	}
+	legado, err := json.Marshal([]string{numero})
+	if err != nil {
+		return nil, err
+	}
+	var contatos []Contato
+	err = r.db.
+		Preload("Vinculos").
+		Where("telefones @> ?::jsonb OR telefones @> ?::jsonb", string(estruturado), string(legado)).
+		Find(&contatos).Error
+	if err != nil {
+		return nil, err
+	}
+	err = r.preencherNegociacoes(contatos)
 	return contatos, err
 }
 
 // ObterPorID busca um contato pelo ID.
 func (r *repository) ObterPorID(id int) (*Contato, error) {
 	var contato Contato
-	err := r.db.First(&contato, id).Error
+	err := r.db.Preload("Vinculos.Empresa").First(&contato, id).Error
 	if err != nil {
 		return nil, errors.New("Contato not found")
 	}
-	return &contato, nil
+	lista := []Contato{contato}
+	if err := r.preencherNegociacoes(lista); err != nil {
+		return nil, err
+	}
+	return &lista[0], nil
 }
 
 // Atualizar modifica os dados de um contato existente.
@@ -64,3 +105,126 @@ func (r *repository) Atualizar(id int, updated Contato) (Contato, error) {
 func (r *repository) Deletar(id int) error {
 	return r.db.Delete(&Contato{}, id).Error
 }
+
+// ListarPorEmpresa retorna os contatos vinculados à empresa, com os respectivos vínculos.
+// Se apenasAtivos for verdadeiro, considera somente vínculos sem data de término.
+func (r *repository) ListarPorEmpresa(empresaID int, apenasAtivos bool) ([]Contato, error) {
+	if err := r.db.First(&empresa.Empresa{}, empresaID).Error; err != nil {
+		return nil, errors.New("empresa not found")
+	}
+	vinculos := r.db.Model(&VinculoEmpresa{}).Select("contato_id").Where("empresa_id = ?", empresaID)
+	if apenasAtivos {
+		vinculos = vinculos.Where("data_fim IS NULL")
+	}
+	var contatos []Contato
+	err := r.db.
+		Preload("Vinculos", func(db *gorm.DB) *gorm.DB {
+			return db.Where("empresa_id = ?", empresaID).Order("data_inicio DESC")
+		}).
+		Where("id IN (?)", vinculos).
+		Order("nome").
+		Find(&contatos).Error
+	if err != nil {
+		return nil, err
+	}
+	err = r.preencherNegociacoes(contatos)
+	return contatos, err
+}
+
+// AdicionarVinculo relaciona o contato a uma empresa. Se DataInicio não for informada, usa a data atual.
+func (r *repository) AdicionarVinculo(contatoID int, v VinculoEmpresa) (VinculoEmpresa, error) {
+	if err := r.db.First(&Contato{}, contatoID).Error; err != nil {
+		return VinculoEmpresa{}, errors.New("Contato not found")
+	}
+	if err := r.db.First(&empresa.Empresa{}, v.EmpresaID).Error; err != nil {
+		return VinculoEmpresa{}, errors.New("empresa not found")
+	}
+	if v.DataInicio.IsZero() {
+		v.DataInicio = time.Now()
+	}
+	if v.DataFim != nil && v.DataFim.Before(v.DataInicio) {
+		return VinculoEmpresa{}, errors.New("data_fim deve ser posterior a data_inicio")
+	}
+	v.ID = 0
+	v.ContatoID = contatoID
+	v.Empresa = nil
+	err := r.db.Create(&v).Error
+	return v, err
+}
+
+// AtualizarVinculo modifica o cargo ou o período de um vínculo do contato.
+func (r *repository) AtualizarVinculo(contatoID, vinculoID int, updated VinculoEmpresa) (VinculoEmpresa, error) {
+	var v VinculoEmpresa
+	if err := r.db.Where("contato_id = ?", contatoID).First(&v, vinculoID).Error; err != nil {
+		return VinculoEmpresa{}, errors.New("vínculo não encontrado")
+	}
+	if updated.Cargo != "" {
+		v.Cargo = updated.Cargo
+	}
+	if !updated.DataInicio.IsZero() {
+		v.DataInicio = updated.DataInicio
+	}
+	if updated.DataFim != nil {
+		v.DataFim = updated.DataFim
+	}
+	if v.DataFim != nil && v.DataFim.Before(v.DataInicio) {
+		return VinculoEmpresa{}, errors.New("data_fim deve ser posterior a data_inicio")
+	}
+	err := r.db.Model(&v).Select("cargo", "data_inicio", "data_fim").Updates(&v).Error
+	return v, err
+}
+
+// RemoverVinculo exclui um vínculo do contato. Para registrar a saída do contato
+// da empresa, prefira informar DataFim via AtualizarVinculo.
+func (r *repository) RemoverVinculo(contatoID, vinculoID int) error {
+	res := r.db.Where("contato_id = ?", contatoID).Delete(&VinculoEmpresa{}, vinculoID)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("vínculo não encontrado")
+	}
+	return nil
+}
+
+// MigrarEmpresasLegadas executa (ou simula, se aplicar for falso) a conversão do campo legado Empresa.
+func (r *repository) MigrarEmpresasLegadas(aplicar bool) (RelatorioMigracao, error) {
+	return MigrarEmpresasLegadas(r.db, aplicar)
+}
+
+// preencherNegociacoes preenche NegociacaoIDs com as negociações em que cada contato
+// participa ou é o contato principal.
+func (r *repository) preencherNegociacoes(contatos []Contato) error {
+	if len(contatos) == 0 {
+		return nil
+	}
+	ids := make([]int, len(contatos))
+	for i, c := range contatos {
+		ids[i] = c.ID
+	}
+	var linhas []struct {
+		ContatoID    int
+		NegociacaoID int
+	}
+	err := r.db.Raw(`
+		SELECT p.contato_id, p.negociacao_id
+		FROM negociacao_contatos p
+		JOIN negociacoes n ON n.id = p.negociacao_id AND n.deleted_at IS NULL
+		WHERE p.contato_id IN ?
+		UNION
+		SELECT n.contato_id, n.id
+		FROM negociacoes n
+		WHERE n.deleted_at IS NULL AND n.contato_id IN ?
+		ORDER BY 2`, ids, ids).Scan(&linhas).Error
+	if err != nil {
+		return err
+	}
+	porContato := make(map[int][]int)
+	for _, l := range linhas {
+		porContato[l.ContatoID] = append(porContato[l.ContatoID], l.NegociacaoID)
+	}
+	for i := range contatos {
+		contatos[i].NegociacaoIDs = porContato[contatos[i].ID]
+	}
+	return nil
+}
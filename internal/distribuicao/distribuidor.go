@@ -0,0 +1,328 @@
+package distribuicao
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"my-crm-backend/internal/empresa"
+	"my-crm-backend/internal/evento"
+	"my-crm-backend/internal/negociacao"
+	"my-crm-backend/internal/normalizacao"
+	"my-crm-backend/internal/notificacao"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Distribuidor escolhe o responsável das negociações novas pelas regras de
+// distribuição e redistribui a carteira de quem sai da equipe.
+type Distribuidor struct {
+	db    *gorm.DB
+	agora func() time.Time
+}
+
+// NovoDistribuidor cria o distribuidor de negociações.
+func NovoDistribuidor(db *gorm.DB) *Distribuidor {
+	return &Distribuidor{db: db, agora: time.Now}
+}
+
+// Resultado resume uma redistribuição.
+type Resultado struct {
+	RegrasAlteradas int   `json:"regras_alteradas"` // Regras de que o usuário foi removido
+	Redistribuidas  int   `json:"redistribuidas"`
+	SemDestino      []int `json:"sem_destino,omitempty"` // Negociações que nenhuma regra conseguiu atribuir
+}
+
+// Atribuir define o responsável de uma negociação recém-criada, na mesma
+// transação da criação. Sem regra que a atenda, a negociação fica sem
+// responsável. Atende à interface captacao.Atribuidor.
+func (d *Distribuidor) Atribuir(tx *gorm.DB, n *negociacao.Negociacao) error {
+	_, err := d.atribuir(tx, n, MotivoNova, Usuario)
+	return err
+}
+
+// Tratar atribui as negociações criadas sem responsável. É registrado como
+// assinante do barramento para negociacao.criada.
+func (d *Distribuidor) Tratar(tx *gorm.DB, e evento.Evento) error {
+	if e.Tipo != evento.NegociacaoCriada {
+		return nil
+	}
+	var n negociacao.Negociacao
+	res := tx.Limit(1).Find(&n, e.EntidadeID)
+	if res.Error != nil || res.RowsAffected == 0 || n.Responsavel != "" {
+		return res.Error
+	}
+	return d.Atribuir(tx, &n)
+}
+
+// Redistribuir remove o usuário de todas as regras e passa as negociações
+// abertas dele, com as tarefas pendentes delas, a outro responsável: o
+// informado em para ou, se vazio, o escolhido pelas regras de cada negociação.
+func (d *Distribuidor) Redistribuir(usuario, para, autor string) (Resultado, error) {
+	var resultado Resultado
+	if usuario == "" {
+		return resultado, errors.New("usuário é obrigatório")
+	}
+	if para == usuario {
+		return resultado, errors.New("o destino deve ser outro usuário")
+	}
+	if autor == "" {
+		autor = Usuario
+	}
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		resultado = Resultado{}
+		var regras []Regra
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("id").Find(&regras).Error; err != nil {
+			return err
+		}
+		for _, regra := range regras {
+			membros := make([]Membro, 0, len(regra.Membros))
+			for _, m := range regra.Membros {
+				if m.Usuario != usuario {
+					membros = append(membros, m)
+				}
+			}
+			if len(membros) == len(regra.Membros) {
+				continue
+			}
+			if err := tx.Model(&regra).UpdateColumn("membros", datatypes.JSONSlice[Membro](membros)).Error; err != nil {
+				return err
+			}
+			resultado.RegrasAlteradas++
+		}
+
+		var negociacoes []negociacao.Negociacao
+		if err := abertas(tx).Where("responsavel = ?", usuario).Order("id").Find(&negociacoes).Error; err != nil {
+			return err
+		}
+		for i := range negociacoes {
+			n := &negociacoes[i]
+			if para != "" {
+				if err := d.definir(tx, n, nil, para, MotivoRedistribuicao, "destino informado", autor); err != nil {
+					return err
+				}
+				resultado.Redistribuidas++
+				continue
+			}
+			atribuida, err := d.atribuir(tx, n, MotivoRedistribuicao, autor)
+			if err != nil {
+				return err
+			}
+			if atribuida {
+				resultado.Redistribuidas++
+			} else {
+				resultado.SemDestino = append(resultado.SemDestino, n.ID)
+			}
+		}
+		return nil
+	})
+	return resultado, err
+}
+
+// atribuir aplica a primeira regra ativa do funil e da fonte da negociação que
+// consegue escolher um membro. As regras ficam travadas até o fim da transação
+// para que atribuições simultâneas não repitam o mesmo membro no rodízio.
+func (d *Distribuidor) atribuir(tx *gorm.DB, n *negociacao.Negociacao, motivo, autor string) (bool, error) {
+	var regras []Regra
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("ativa AND (funil = '' OR funil = ?) AND (fonte = '' OR fonte = ?)", n.FunilVendas, n.Fonte).
+		Order("prioridade, id").Find(&regras).Error; err != nil {
+		return false, err
+	}
+	if len(regras) == 0 {
+		return false, nil
+	}
+	var e empresa.Empresa
+	if err := tx.Select("id", "estado", "cidade", "segmento").Limit(1).Find(&e, n.EmpresaID).Error; err != nil {
+		return false, err
+	}
+	for i := range regras {
+		regra := &regras[i]
+		usuario, detalhes, err := escolher(tx, *regra, e, n.Responsavel)
+		if err != nil {
+			return false, err
+		}
+		if usuario == "" {
+			continue
+		}
+		if err := tx.Model(regra).UpdateColumn("ultimo_usuario", usuario).Error; err != nil {
+			return false, err
+		}
+		return true, d.definir(tx, n, regra, usuario, motivo, detalhes, autor)
+	}
+	return false, nil
+}
+
+// escolher retorna o membro da regra que recebe a negociação, ou vazio se
+// nenhum puder recebê-la, e descreve a escolha. O responsável atual nunca é
+// escolhido.
+func escolher(tx *gorm.DB, regra Regra, e empresa.Empresa, atual string) (string, string, error) {
+	candidatos := candidatos(regra, e, atual)
+	if len(candidatos) == 0 {
+		return "", "", nil
+	}
+
+	logins := make([]string, len(candidatos))
+	for i, m := range candidatos {
+		logins[i] = m.Usuario
+	}
+	var cargas []struct {
+		Responsavel string
+		Total       int
+	}
+	if err := abertas(tx.Model(&negociacao.Negociacao{})).
+		Select("responsavel, count(*) AS total").
+		Where("responsavel IN ?", logins).
+		Group("responsavel").Scan(&cargas).Error; err != nil {
+		return "", "", err
+	}
+	carga := map[string]int{}
+	for _, c := range cargas {
+		carga[c.Responsavel] = c.Total
+	}
+	escolhido, ok := escolherMembro(regra, candidatos, carga)
+	if !ok {
+		return "", "", nil
+	}
+	detalhes := fmt.Sprintf("%s: %d negociação(ões) aberta(s)", regra.Estrategia, carga[escolhido.Usuario])
+	if escolhido.Capacidade > 0 {
+		detalhes += fmt.Sprintf(" de %d", escolhido.Capacidade)
+	}
+	return escolhido.Usuario, detalhes, nil
+}
+
+// candidatos retorna os membros da regra que podem receber a negociação da
+// empresa: todos menos o responsável atual e, na estratégia de território,
+// apenas os que atendem a empresa.
+func candidatos(regra Regra, e empresa.Empresa, atual string) []Membro {
+	var resultado []Membro
+	for _, m := range regra.Membros {
+		if m.Usuario == atual {
+			continue
+		}
+		if regra.Estrategia == EstrategiaTerritorio && !noTerritorio(m, e) {
+			continue
+		}
+		resultado = append(resultado, m)
+	}
+	return resultado
+}
+
+// escolherMembro aplica a estratégia da regra aos candidatos, dada a carga de
+// negociações abertas de cada um. Quem atingiu a capacidade fica de fora; sem
+// ninguém disponível, retorna false.
+func escolherMembro(regra Regra, candidatos []Membro, carga map[string]int) (Membro, bool) {
+	var disponiveis []Membro
+	for _, m := range candidatos {
+		if m.Capacidade == 0 || carga[m.Usuario] < m.Capacidade {
+			disponiveis = append(disponiveis, m)
+		}
+	}
+	if len(disponiveis) == 0 {
+		return Membro{}, false
+	}
+	if regra.Estrategia != EstrategiaCapacidade {
+		return proximo(regra, disponiveis), true
+	}
+	ocupacao := func(m Membro) float64 {
+		if m.Capacidade == 0 {
+			return float64(carga[m.Usuario])
+		}
+		return float64(carga[m.Usuario]) / float64(m.Capacidade)
+	}
+	escolhido := disponiveis[0]
+	for _, m := range disponiveis[1:] {
+		if ocupacao(m) < ocupacao(escolhido) {
+			escolhido = m
+		}
+	}
+	return escolhido, true
+}
+
+// proximo segue o rodízio: o primeiro disponível depois do último escolhido,
+// na ordem dos membros da regra.
+func proximo(regra Regra, disponiveis []Membro) Membro {
+	inicio := 0
+	for i, m := range regra.Membros {
+		if m.Usuario == regra.UltimoUsuario {
+			inicio = i + 1
+			break
+		}
+	}
+	for i := 0; i < len(regra.Membros); i++ {
+		m := regra.Membros[(inicio+i)%len(regra.Membros)]
+		for _, d := range disponiveis {
+			if d.Usuario == m.Usuario {
+				return d
+			}
+		}
+	}
+	return disponiveis[0]
+}
+
+// noTerritorio informa se a empresa está no território do membro.
+func noTerritorio(m Membro, e empresa.Empresa) bool {
+	return atende(m.Estados, e.Estado, strings.ToUpper) &&
+		atende(m.Cidades, e.Cidade, normalizacao.Texto) &&
+		atende(m.Segmentos, e.Segmento, normalizacao.Texto)
+}
+
+// atende informa se o valor está na lista, comparando pela forma normalizada.
+// Lista vazia aceita qualquer valor.
+func atende(lista []string, valor string, normalizar func(string) string) bool {
+	if len(lista) == 0 {
+		return true
+	}
+	valor = normalizar(strings.TrimSpace(valor))
+	if valor == "" {
+		return false
+	}
+	for _, item := range lista {
+		if normalizar(strings.TrimSpace(item)) == valor {
+			return true
+		}
+	}
+	return false
+}
+
+// definir passa a negociação e suas tarefas pendentes do responsável anterior
+// para o usuário, registra a atribuição e avisa o novo responsável.
+func (d *Distribuidor) definir(tx *gorm.DB, n *negociacao.Negociacao, regra *Regra, usuario, motivo, detalhes, autor string) error {
+	anterior, err := negociacao.NovoRepositorio(tx).AtualizarResponsavel(n.ID, usuario, autor)
+	if err != nil {
+		return err
+	}
+
+	a := Atribuicao{
+		NegociacaoID: n.ID,
+		Motivo:       motivo,
+		Anterior:     anterior,
+		Usuario:      usuario,
+		Detalhes:     detalhes,
+		Autor:        autor,
+		Data:         d.agora(),
+	}
+	if regra != nil {
+		a.RegraID = &regra.ID
+		a.Estrategia = regra.Estrategia
+	}
+	if err := tx.Create(&a).Error; err != nil {
+		return err
+	}
+	n.Responsavel = usuario
+	return notificacao.Notificar(tx, notificacao.Notificacao{
+		Usuario:    usuario,
+		Tipo:       "negociacao_atribuida",
+		Titulo:     "Negociação atribuída a você: " + n.NomeNegociacao,
+		Entidade:   "negociacao",
+		EntidadeID: n.ID,
+	})
+}
+
+// abertas restringe a consulta às negociações que não foram ganhas nem perdidas.
+func abertas(tx *gorm.DB) *gorm.DB {
+	return tx.Where("COALESCE(status, '') NOT IN ?", []string{negociacao.StatusGanha, negociacao.StatusPerdida})
+}
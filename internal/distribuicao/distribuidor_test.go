@@ -0,0 +1,137 @@
+package distribuicao
+
+import (
+	"testing"
+
+	"my-crm-backend/internal/empresa"
+)
+
+func TestCandidatos(t *testing.T) {
+	membros := []Membro{
+		{Usuario: "ana", Estados: []string{"SP", "rj"}},
+		{Usuario: "bruno", Cidades: []string{"São Paulo"}, Segmentos: []string{"Logística"}},
+		{Usuario: "carla"},
+		{Usuario: "davi", Estados: []string{"MG"}},
+	}
+	e := empresa.Empresa{}
+	e.Estado, e.Cidade, e.Segmento = "sp", "SAO PAULO", "logistica"
+
+	casos := []struct {
+		nome     string
+		regra    Regra
+		atual    string
+		esperado []string
+	}{
+		{"rodízio ignora o território", Regra{Estrategia: EstrategiaRodizio, Membros: membros}, "", []string{"ana", "bruno", "carla", "davi"}},
+		{"responsável atual fica de fora", Regra{Estrategia: EstrategiaRodizio, Membros: membros}, "carla", []string{"ana", "bruno", "davi"}},
+		{"território compara sem acentos e maiúsculas", Regra{Estrategia: EstrategiaTerritorio, Membros: membros}, "", []string{"ana", "bruno", "carla"}},
+	}
+	for _, c := range casos {
+		got := candidatos(c.regra, e, c.atual)
+		if len(got) != len(c.esperado) {
+			t.Errorf("%s: candidatos = %v; esperado %v", c.nome, got, c.esperado)
+			continue
+		}
+		for i := range got {
+			if got[i].Usuario != c.esperado[i] {
+				t.Errorf("%s: candidatos = %v; esperado %v", c.nome, got, c.esperado)
+				break
+			}
+		}
+	}
+
+	semEndereco := empresa.Empresa{}
+	if got := candidatos(Regra{Estrategia: EstrategiaTerritorio, Membros: membros}, semEndereco, ""); len(got) != 1 || got[0].Usuario != "carla" {
+		t.Errorf("empresa sem endereço: candidatos = %v; esperado só quem não tem território", got)
+	}
+}
+
+func TestEscolherMembro(t *testing.T) {
+	equipe := []Membro{{Usuario: "ana"}, {Usuario: "bruno"}, {Usuario: "carla"}}
+	casos := []struct {
+		nome       string
+		regra      Regra
+		candidatos []Membro
+		carga      map[string]int
+		esperado   string // Vazio quando ninguém pode receber
+	}{
+		{
+			nome:       "rodízio começa pelo primeiro",
+			regra:      Regra{Estrategia: EstrategiaRodizio, Membros: equipe},
+			candidatos: equipe,
+			esperado:   "ana",
+		},
+		{
+			nome:       "rodízio continua depois do último escolhido",
+			regra:      Regra{Estrategia: EstrategiaRodizio, Membros: equipe, UltimoUsuario: "ana"},
+			candidatos: equipe,
+			esperado:   "bruno",
+		},
+		{
+			nome:       "rodízio volta ao início",
+			regra:      Regra{Estrategia: EstrategiaRodizio, Membros: equipe, UltimoUsuario: "carla"},
+			candidatos: equipe,
+			esperado:   "ana",
+		},
+		{
+			nome:       "rodízio pula quem não é candidato",
+			regra:      Regra{Estrategia: EstrategiaTerritorio, Membros: equipe, UltimoUsuario: "ana"},
+			candidatos: []Membro{{Usuario: "ana"}, {Usuario: "carla"}},
+			esperado:   "carla",
+		},
+		{
+			nome:       "rodízio pula quem atingiu a capacidade",
+			regra:      Regra{Estrategia: EstrategiaRodizio, Membros: equipe, UltimoUsuario: "ana"},
+			candidatos: []Membro{{Usuario: "ana"}, {Usuario: "bruno", Capacidade: 2}, {Usuario: "carla"}},
+			carga:      map[string]int{"bruno": 2},
+			esperado:   "carla",
+		},
+		{
+			nome:  "capacidade escolhe a menor ocupação",
+			regra: Regra{Estrategia: EstrategiaCapacidade},
+			candidatos: []Membro{
+				{Usuario: "ana", Capacidade: 10},  // 50%
+				{Usuario: "bruno", Capacidade: 4}, // 25%
+				{Usuario: "carla", Capacidade: 2}, // 50%
+			},
+			carga:    map[string]int{"ana": 5, "bruno": 1, "carla": 1},
+			esperado: "bruno",
+		},
+		{
+			nome:       "capacidade desempata pela ordem dos membros",
+			regra:      Regra{Estrategia: EstrategiaCapacidade},
+			candidatos: []Membro{{Usuario: "ana", Capacidade: 4}, {Usuario: "bruno", Capacidade: 2}},
+			carga:      map[string]int{"ana": 2, "bruno": 1},
+			esperado:   "ana",
+		},
+		{
+			nome:       "sem capacidade definida conta as negociações abertas",
+			regra:      Regra{Estrategia: EstrategiaCapacidade},
+			candidatos: []Membro{{Usuario: "ana"}, {Usuario: "bruno"}},
+			carga:      map[string]int{"ana": 3, "bruno": 2},
+			esperado:   "bruno",
+		},
+		{
+			nome:       "todos na capacidade",
+			regra:      Regra{Estrategia: EstrategiaCapacidade},
+			candidatos: []Membro{{Usuario: "ana", Capacidade: 1}, {Usuario: "bruno", Capacidade: 3}},
+			carga:      map[string]int{"ana": 1, "bruno": 3},
+		},
+		{
+			nome:  "sem candidatos",
+			regra: Regra{Estrategia: EstrategiaRodizio, Membros: equipe},
+		},
+	}
+	for _, c := range casos {
+		m, ok := escolherMembro(c.regra, c.candidatos, c.carga)
+		if c.esperado == "" {
+			if ok {
+				t.Errorf("%s: escolherMembro = %s; esperado ninguém", c.nome, m.Usuario)
+			}
+			continue
+		}
+		if !ok || m.Usuario != c.esperado {
+			t.Errorf("%s: escolherMembro = %s, %v; esperado %s", c.nome, m.Usuario, ok, c.esperado)
+		}
+	}
+}
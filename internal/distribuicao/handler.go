@@ -0,0 +1,155 @@
+package distribuicao
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler define os manipuladores HTTP da distribuição de negociações.
+type Handler struct {
+	repo         Repository
+	distribuidor *Distribuidor
+}
+
+// NovoHandler cria e retorna um novo handler de distribuição.
+func NovoHandler(repo Repository, distribuidor *Distribuidor) *Handler {
+	return &Handler{repo: repo, distribuidor: distribuidor}
+}
+
+// Criar insere uma nova regra de distribuição.
+// Espera receber um JSON como: {"nome": "Site - Sudeste", "funil": "Seguros empresariais",
+// "fonte": "site", "estrategia": "territorio", "membros": [{"usuario": "ana", "estados": ["SP", "RJ"],
+// "capacidade": 30}, {"usuario": "bruno", "segmentos": ["Transporte"]}]}
+func (h *Handler) Criar(c *gin.Context) {
+	r := Regra{Ativa: true}
+	if err := c.ShouldBindJSON(&r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := Validar(r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	criada, err := h.repo.Adicionar(r)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, criada)
+}
+
+// Listar retorna as regras na ordem em que são avaliadas.
+func (h *Handler) Listar(c *gin.Context) {
+	regras, err := h.repo.Listar()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, regras)
+}
+
+// Obter retorna uma regra pelo ID.
+func (h *Handler) Obter(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	r, err := h.repo.ObterPorID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// Atualizar substitui uma regra de distribuição.
+func (h *Handler) Atualizar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	r := Regra{Ativa: true}
+	if err := c.ShouldBindJSON(&r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := Validar(r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	atualizada, err := h.repo.Atualizar(id, r)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, atualizada)
+}
+
+// Deletar remove uma regra de distribuição.
+func (h *Handler) Deletar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	if err := h.repo.Deletar(id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrRegraNaoEncontrada) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListarAtribuicoes retorna o registro de atribuições.
+// Aceita os filtros opcionais ?negociacao_id=1&usuario=ana&regra_id=2.
+func (h *Handler) ListarAtribuicoes(c *gin.Context) {
+	filtro := FiltroAtribuicoes{Usuario: c.Query("usuario")}
+	for param, destino := range map[string]*int{
+		"negociacao_id": &filtro.NegociacaoID,
+		"regra_id":      &filtro.RegraID,
+	} {
+		if v := c.Query(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": param + " inválido"})
+				return
+			}
+			*destino = n
+		}
+	}
+	atribuicoes, err := h.repo.ListarAtribuicoes(filtro)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, atribuicoes)
+}
+
+// Redistribuir tira um usuário das equipes e passa as negociações abertas dele
+// adiante, pelas regras ou para o destino informado.
+// Espera receber um JSON como: {"usuario": "carlos", "para": "", "alterado_por": "gestor"}.
+func (h *Handler) Redistribuir(c *gin.Context) {
+	var payload struct {
+		Usuario     string `json:"usuario" binding:"required"`
+		Para        string `json:"para"`
+		AlteradoPor string `json:"alterado_por"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resultado, err := h.distribuidor.Redistribuir(payload.Usuario, payload.Para, payload.AlteradoPor)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resultado)
+}
@@ -0,0 +1,86 @@
+package distribuicao
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Estratégias de escolha do responsável entre os membros da regra.
+const (
+	EstrategiaRodizio    = "rodizio"    // Um de cada vez, na ordem dos membros
+	EstrategiaTerritorio = "territorio" // Membros cujo território inclui a empresa, em rodízio entre eles
+	EstrategiaCapacidade = "capacidade" // Membro com menor ocupação: negociações abertas / capacidade
+)
+
+// Estrategias lista as estratégias válidas.
+var Estrategias = []string{EstrategiaRodizio, EstrategiaTerritorio, EstrategiaCapacidade}
+
+// Usuario é o login registrado como autor das atribuições automáticas.
+const Usuario = "distribuicao"
+
+// Regra define como as negociações novas sem responsável de um funil e de uma
+// fonte são distribuídas entre os membros de uma equipe. Vale a primeira regra
+// ativa, pela Prioridade, que consegue escolher um membro.
+type Regra struct {
+	ID         int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	Nome       string `json:"nome"`
+	Funil      string `json:"funil,omitempty"` // FunilVendas da negociação; vazio vale para todos
+	Fonte      string `json:"fonte,omitempty"` // Fonte da negociação; vazio vale para todas
+	Estrategia string `json:"estrategia" gorm:"not null"`
+	Prioridade int    `json:"prioridade"` // Menor primeiro
+	Ativa      bool   `json:"ativa" gorm:"not null"`
+
+	Membros datatypes.JSONSlice[Membro] `json:"membros"`
+
+	// Último membro escolhido, de onde o rodízio continua.
+	UltimoUsuario string `json:"ultimo_usuario,omitempty"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Regra) TableName() string {
+	return "distribuicao_regras"
+}
+
+// Membro é um usuário da equipe de uma regra. Em qualquer estratégia, quem já
+// tem Capacidade negociações abertas não recebe novas; zero não limita.
+type Membro struct {
+	Usuario    string `json:"usuario"`
+	Capacidade int    `json:"capacidade,omitempty"`
+
+	// Território (estratégia territorio): a empresa precisa estar em um dos
+	// estados, em uma das cidades e em um dos segmentos; listas vazias não restringem.
+	Estados   []string `json:"estados,omitempty"`
+	Cidades   []string `json:"cidades,omitempty"`
+	Segmentos []string `json:"segmentos,omitempty"`
+}
+
+// Motivos de uma atribuição.
+const (
+	MotivoNova           = "nova"           // Negociação criada sem responsável
+	MotivoRedistribuicao = "redistribuicao" // O responsável anterior saiu da equipe
+)
+
+// Atribuicao registra cada responsável definido pela distribuição e por quê.
+type Atribuicao struct {
+	ID           int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	NegociacaoID int       `json:"negociacao_id" gorm:"index;not null"`
+	RegraID      *int      `json:"regra_id,omitempty" gorm:"index"` // Nulo quando o destino foi informado na redistribuição
+	Estrategia   string    `json:"estrategia,omitempty"`
+	Motivo       string    `json:"motivo"`
+	Anterior     string    `json:"anterior,omitempty"`
+	Usuario      string    `json:"usuario" gorm:"index"`
+	Detalhes     string    `json:"detalhes,omitempty"`
+	Autor        string    `json:"autor"`
+	Data         time.Time `json:"data"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Atribuicao) TableName() string {
+	return "distribuicao_atribuicoes"
+}
@@ -0,0 +1,103 @@
+package distribuicao
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrRegraNaoEncontrada indica que a regra de distribuição não existe.
+var ErrRegraNaoEncontrada = errors.New("regra de distribuição não encontrada")
+
+// FiltroAtribuicoes restringe a listagem do registro de atribuições.
+type FiltroAtribuicoes struct {
+	NegociacaoID int
+	Usuario      string
+	RegraID      int
+}
+
+// Repository define as operações sobre as regras de distribuição e o registro de atribuições.
+type Repository interface {
+	Adicionar(r Regra) (Regra, error)
+	Listar() ([]Regra, error)
+	ObterPorID(id int) (*Regra, error)
+	Atualizar(id int, updated Regra) (Regra, error)
+	Deletar(id int) error
+	ListarAtribuicoes(filtro FiltroAtribuicoes) ([]Atribuicao, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NovoRepositorio cria e retorna um repositório baseado em GORM.
+func NovoRepositorio(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Adicionar insere uma nova regra.
+func (r *repository) Adicionar(regra Regra) (Regra, error) {
+	regra.ID = 0
+	regra.UltimoUsuario = ""
+	err := r.db.Create(&regra).Error
+	return regra, err
+}
+
+// Listar retorna as regras na ordem em que são avaliadas.
+func (r *repository) Listar() ([]Regra, error) {
+	regras := []Regra{}
+	err := r.db.Order("prioridade, id").Find(&regras).Error
+	return regras, err
+}
+
+// ObterPorID busca uma regra pelo ID.
+func (r *repository) ObterPorID(id int) (*Regra, error) {
+	var regra Regra
+	if err := r.db.First(&regra, id).Error; err != nil {
+		return nil, ErrRegraNaoEncontrada
+	}
+	return &regra, nil
+}
+
+// Atualizar substitui os dados de uma regra existente, mantendo a posição do rodízio.
+func (r *repository) Atualizar(id int, updated Regra) (Regra, error) {
+	var regra Regra
+	if err := r.db.First(&regra, id).Error; err != nil {
+		return Regra{}, ErrRegraNaoEncontrada
+	}
+	updated.ID = id
+	updated.CreatedAt = regra.CreatedAt
+	updated.UltimoUsuario = regra.UltimoUsuario
+	err := r.db.Model(&regra).Select("*").Omit("id", "created_at", "deleted_at", "ultimo_usuario").Updates(updated).Error
+	return updated, err
+}
+
+// Deletar remove uma regra pelo ID. As atribuições já registradas são mantidas.
+func (r *repository) Deletar(id int) error {
+	res := r.db.Delete(&Regra{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRegraNaoEncontrada
+	}
+	return nil
+}
+
+// ListarAtribuicoes retorna as últimas 200 atribuições que atendem ao filtro,
+// da mais recente para a mais antiga.
+func (r *repository) ListarAtribuicoes(filtro FiltroAtribuicoes) ([]Atribuicao, error) {
+	atribuicoes := []Atribuicao{}
+	query := r.db.Order("id DESC").Limit(200)
+	if filtro.NegociacaoID != 0 {
+		query = query.Where("negociacao_id = ?", filtro.NegociacaoID)
+	}
+	if filtro.Usuario != "" {
+		query = query.Where("usuario = ? OR anterior = ?", filtro.Usuario, filtro.Usuario)
+	}
+	if filtro.RegraID != 0 {
+		query = query.Where("regra_id = ?", filtro.RegraID)
+	}
+	err := query.Find(&atribuicoes).Error
+	return atribuicoes, err
+}
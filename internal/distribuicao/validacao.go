@@ -0,0 +1,43 @@
+package distribuicao
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Validar confere a regra de distribuição: estratégia conhecida e membros
+// distintos, com capacidade não negativa e, na estratégia territorio, com
+// algum território definido.
+func Validar(r Regra) error {
+	if r.Nome == "" {
+		return errors.New("nome é obrigatório")
+	}
+	valida := false
+	for _, e := range Estrategias {
+		valida = valida || e == r.Estrategia
+	}
+	if !valida {
+		return fmt.Errorf("estratégia inválida: %q (use %s)", r.Estrategia, strings.Join(Estrategias, ", "))
+	}
+	if len(r.Membros) == 0 {
+		return errors.New("informe ao menos um membro")
+	}
+	vistos := map[string]bool{}
+	for _, m := range r.Membros {
+		if m.Usuario == "" {
+			return errors.New("usuário do membro é obrigatório")
+		}
+		if vistos[m.Usuario] {
+			return fmt.Errorf("membro repetido: %s", m.Usuario)
+		}
+		vistos[m.Usuario] = true
+		if m.Capacidade < 0 {
+			return fmt.Errorf("capacidade de %s não pode ser negativa", m.Usuario)
+		}
+		if r.Estrategia == EstrategiaTerritorio && len(m.Estados)+len(m.Cidades)+len(m.Segmentos) == 0 {
+			return fmt.Errorf("defina estados, cidades ou segmentos do território de %s", m.Usuario)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package duplicidade
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler define os manipuladores HTTP para detecção e mesclagem de duplicados.
+type Handler struct {
+	repo Repository
+}
+
+// NovoHandler cria e retorna um novo handler para duplicidades.
+func NovoHandler(repo Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+// ListarEmpresas retorna os grupos de empresas suspeitas de duplicidade.
+// Aceita ?limiar=N (0 a 100) para ajustar a pontuação mínima.
+func (h *Handler) ListarEmpresas(c *gin.Context) {
+	limiar, ok := lerLimiar(c)
+	if !ok {
+		return
+	}
+	clusters, err := h.repo.ClustersEmpresas(limiar)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, clusters)
+}
+
+// ListarContatos retorna os grupos de contatos suspeitos de duplicidade.
+// Aceita ?limiar=N (0 a 100) para ajustar a pontuação mínima.
+func (h *Handler) ListarContatos(c *gin.Context) {
+	limiar, ok := lerLimiar(c)
+	if !ok {
+		return
+	}
+	clusters, err := h.repo.ClustersContatos(limiar)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, clusters)
+}
+
+// MesclarEmpresas mescla empresas duplicadas na sobrevivente.
+// Espera receber um JSON com: {"sobrevivente_id": 1, "duplicadas_ids": [2, 3], "usuario": "fulano"}
+func (h *Handler) MesclarEmpresas(c *gin.Context) {
+	var p PedidoMesclagem
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
+		return
+	}
+	if err := validarPedido(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resultado, err := h.repo.MesclarEmpresas(p)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resultado)
+}
+
+// MesclarContatos mescla contatos duplicados no sobrevivente.
+// Espera receber um JSON com: {"sobrevivente_id": 1, "duplicadas_ids": [2, 3], "usuario": "fulano"}
+func (h *Handler) MesclarContatos(c *gin.Context) {
+	var p PedidoMesclagem
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
+		return
+	}
+	if err := validarPedido(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resultado, err := h.repo.MesclarContatos(p)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resultado)
+}
+
+func lerLimiar(c *gin.Context) (int, bool) {
+	v := c.Query("limiar")
+	if v == "" {
+		return LimiarPadrao, true
+	}
+	limiar, err := strconv.Atoi(v)
+	if err != nil || limiar < 0 || limiar > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limiar deve ser um número entre 0 e 100"})
+		return 0, false
+	}
+	return limiar, true
+}
@@ -0,0 +1,29 @@
+package duplicidade
+
+// Membro identifica um registro que faz parte de um grupo de possíveis duplicados.
+type Membro struct {
+	ID        int    `json:"id"`
+	Nome      string `json:"nome"`
+	Documento string `json:"documento,omitempty"` // CNPJ para empresas, e-mail para contatos
+}
+
+// Cluster agrupa registros suspeitos de representarem a mesma empresa ou contato.
+type Cluster struct {
+	Membros   []Membro `json:"membros"`
+	Pontuacao int      `json:"pontuacao"` // Maior pontuação entre os pares do grupo (0 a 100)
+	Motivos   []string `json:"motivos"`
+}
+
+// PedidoMesclagem descreve uma mesclagem: o registro sobrevivente e os duplicados que serão absorvidos.
+type PedidoMesclagem struct {
+	SobreviventeID int    `json:"sobrevivente_id"`
+	DuplicadasIDs  []int  `json:"duplicadas_ids"`
+	Usuario        string `json:"usuario"`
+}
+
+// ResultadoMesclagem resume o que foi alterado por uma mesclagem.
+type ResultadoMesclagem struct {
+	SobreviventeID int              `json:"sobrevivente_id"`
+	Mescladas      []int            `json:"mescladas"`
+	Reapontados    map[string]int64 `json:"reapontados"` // Quantidade de registros reapontados por tabela
+}
@@ -0,0 +1,219 @@
+package duplicidade
+
+import (
+	"sort"
+	"strings"
+
+	"my-crm-backend/internal/contato"
+	"my-crm-backend/internal/empresa"
+	"my-crm-backend/internal/normalizacao"
+)
+
+// LimiarPadrao é a pontuação mínima para que dois registros sejam considerados duplicados.
+const LimiarPadrao = 50
+
+type empresaNormalizada struct {
+	empresa.Empresa
+	nome     string
+	raiz     string
+	telefone string
+}
+
+type contatoNormalizado struct {
+	contato.Contato
+	nome      string
+	email     string
+	telefones []string
+}
+
+// pontuarEmpresas compara duas empresas e retorna a pontuação e os motivos da suspeita.
+func pontuarEmpresas(a, b empresaNormalizada) (int, []string) {
+	pontos := 0
+	var motivos []string
+	if a.raiz != "" && a.raiz == b.raiz {
+		pontos += 60
+		motivos = append(motivos, "mesma raiz de CNPJ")
+	}
+	if a.nome != "" && b.nome != "" {
+		if a.nome == b.nome {
+			pontos += 50
+			motivos = append(motivos, "mesmo nome normalizado")
+		} else if s := normalizacao.Similaridade(a.nome, b.nome); s >= 0.8 {
+			pontos += int(40 * s)
+			motivos = append(motivos, "nomes semelhantes")
+		}
+	}
+	if a.telefone != "" && a.telefone == b.telefone {
+		pontos += 20
+		motivos = append(motivos, "mesmo telefone")
+	}
+	return min(pontos, 100), motivos
+}
+
+// pontuarContatos compara dois contatos e retorna a pontuação e os motivos da suspeita.
+func pontuarContatos(a, b contatoNormalizado) (int, []string) {
+	pontos := 0
+	var motivos []string
+	if a.email != "" && a.email == b.email {
+		pontos += 70
+		motivos = append(motivos, "mesmo e-mail")
+	}
+	if compartilhaTelefone(a.telefones, b.telefones) {
+		pontos += 30
+		motivos = append(motivos, "mesmo telefone")
+	}
+	if a.nome != "" && b.nome != "" {
+		if a.nome == b.nome {
+			pontos += 30
+			motivos = append(motivos, "mesmo nome")
+		} else if normalizacao.Similaridade(a.nome, b.nome) >= 0.85 {
+			pontos += 20
+			motivos = append(motivos, "nomes semelhantes")
+		}
+	}
+	return min(pontos, 100), motivos
+}
+
+func compartilhaTelefone(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// chavesEmpresa retorna as chaves de bloqueio usadas para limitar as comparações
+// a pares que compartilham ao menos um atributo.
+func chavesEmpresa(e empresaNormalizada) []string {
+	var chaves []string
+	if e.raiz != "" {
+		chaves = append(chaves, "cnpj:"+e.raiz)
+	}
+	if e.telefone != "" {
+		chaves = append(chaves, "tel:"+e.telefone)
+	}
+	if palavras := strings.Fields(e.nome); len(palavras) > 0 {
+		chaves = append(chaves, "nome:"+palavras[0])
+	}
+	return chaves
+}
+
+func chavesContato(c contatoNormalizado) []string {
+	var chaves []string
+	if c.email != "" {
+		chaves = append(chaves, "email:"+c.email)
+	}
+	for _, t := range c.telefones {
+		chaves = append(chaves, "tel:"+t)
+	}
+	if palavras := strings.Fields(c.nome); len(palavras) > 0 {
+		chaves = append(chaves, "nome:"+palavras[0])
+	}
+	return chaves
+}
+
+// grupo acumula os índices e a avaliação de um cluster durante o agrupamento.
+type grupo struct {
+	indices   []int
+	pontuacao int
+	motivos   map[string]bool
+}
+
+// agrupar compara os pares de registros que compartilham chaves de bloqueio e
+// une em um mesmo grupo todos os pares com pontuação igual ou superior ao limiar.
+func agrupar(n int, chaves func(i int) []string, pontuar func(i, j int) (int, []string), limiar int) []grupo {
+	pai := make([]int, n)
+	for i := range pai {
+		pai[i] = i
+	}
+	var raiz func(i int) int
+	raiz = func(i int) int {
+		if pai[i] != i {
+			pai[i] = raiz(pai[i])
+		}
+		return pai[i]
+	}
+
+	blocos := make(map[string][]int)
+	for i := 0; i < n; i++ {
+		for _, k := range chaves(i) {
+			blocos[k] = append(blocos[k], i)
+		}
+	}
+
+	type par struct{ i, j int }
+	avaliados := make(map[par]bool)
+	pontuacoes := make(map[int]int)
+	motivos := make(map[int]map[string]bool)
+	for _, indices := range blocos {
+		for x := 0; x < len(indices); x++ {
+			for y := x + 1; y < len(indices); y++ {
+				p := par{indices[x], indices[y]}
+				if avaliados[p] {
+					continue
+				}
+				avaliados[p] = true
+				pontos, ms := pontuar(p.i, p.j)
+				if pontos < limiar {
+					continue
+				}
+				ri, rj := raiz(p.i), raiz(p.j)
+				if ri != rj {
+					pai[rj] = ri
+					pontuacoes[ri] = max(pontuacoes[ri], pontuacoes[rj])
+					if motivos[ri] == nil {
+						motivos[ri] = make(map[string]bool)
+					}
+					for m := range motivos[rj] {
+						motivos[ri][m] = true
+					}
+				}
+				pontuacoes[ri] = max(pontuacoes[ri], pontos)
+				if motivos[ri] == nil {
+					motivos[ri] = make(map[string]bool)
+				}
+				for _, m := range ms {
+					motivos[ri][m] = true
+				}
+			}
+		}
+	}
+
+	porRaiz := make(map[int]*grupo)
+	for i := 0; i < n; i++ {
+		r := raiz(i)
+		if _, ok := pontuacoes[r]; !ok {
+			continue
+		}
+		g, ok := porRaiz[r]
+		if !ok {
+			g = &grupo{pontuacao: pontuacoes[r], motivos: motivos[r]}
+			porRaiz[r] = g
+		}
+		g.indices = append(g.indices, i)
+	}
+
+	grupos := make([]grupo, 0, len(porRaiz))
+	for _, g := range porRaiz {
+		grupos = append(grupos, *g)
+	}
+	sort.Slice(grupos, func(a, b int) bool {
+		if grupos[a].pontuacao != grupos[b].pontuacao {
+			return grupos[a].pontuacao > grupos[b].pontuacao
+		}
+		return grupos[a].indices[0] < grupos[b].indices[0]
+	})
+	return grupos
+}
+
+func listaMotivos(m map[string]bool) []string {
+	motivos := make([]string, 0, len(m))
+	for k := range m {
+		motivos = append(motivos, k)
+	}
+	sort.Strings(motivos)
+	return motivos
+}
@@ -0,0 +1,343 @@
+package duplicidade
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"my-crm-backend/internal/auditoria"
+	"my-crm-backend/internal/contato"
+	"my-crm-backend/internal/empresa"
+	"my-crm-backend/internal/normalizacao"
+	"my-crm-backend/internal/telefone"
+)
+
+// Repository define as operações de detecção e mesclagem de registros duplicados.
+type Repository interface {
+	ClustersEmpresas(limiar int) ([]Cluster, error)
+	ClustersContatos(limiar int) ([]Cluster, error)
+	MesclarEmpresas(p PedidoMesclagem) (ResultadoMesclagem, error)
+	MesclarContatos(p PedidoMesclagem) (ResultadoMesclagem, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NovoRepositorio cria e retorna um repositório baseado em GORM.
+func NovoRepositorio(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// ClustersEmpresas lista os grupos de empresas suspeitas de duplicidade.
+func (r *repository) ClustersEmpresas(limiar int) ([]Cluster, error) {
+	var empresas []empresa.Empresa
+	if err := r.db.Find(&empresas).Error; err != nil {
+		return nil, err
+	}
+	normalizadas := make([]empresaNormalizada, len(empresas))
+	for i, e := range empresas {
+		normalizadas[i] = empresaNormalizada{
+			Empresa:  e,
+			nome:     normalizacao.NomeEmpresa(e.Nome),
+			raiz:     normalizacao.RaizCNPJ(e.CNPJMatriz),
+			telefone: normalizacao.Telefone(e.TelefoneMatriz),
+		}
+	}
+	grupos := agrupar(len(normalizadas),
+		func(i int) []string { return chavesEmpresa(normalizadas[i]) },
+		func(i, j int) (int, []string) { return pontuarEmpresas(normalizadas[i], normalizadas[j]) },
+		limiar)
+
+	clusters := make([]Cluster, 0, len(grupos))
+	for _, g := range grupos {
+		c := Cluster{Pontuacao: g.pontuacao, Motivos: listaMotivos(g.motivos)}
+		for _, i := range g.indices {
+			e := normalizadas[i].Empresa
+			c.Membros = append(c.Membros, Membro{ID: e.ID, Nome: e.Nome, Documento: e.CNPJMatriz})
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters, nil
+}
+
+// ClustersContatos lista os grupos de contatos suspeitos de duplicidade.
+func (r *repository) ClustersContatos(limiar int) ([]Cluster, error) {
+	var contatos []contato.Contato
+	if err := r.db.Find(&contatos).Error; err != nil {
+		return nil, err
+	}
+	normalizados := make([]contatoNormalizado, len(contatos))
+	for i, ct := range contatos {
+		normalizados[i] = contatoNormalizado{
+			Contato:   ct,
+			nome:      normalizacao.Texto(ct.Nome),
+			email:     normalizacao.Email(ct.Email),
+			telefones: telefonesContato(ct),
+		}
+	}
+	grupos := agrupar(len(normalizados),
+		func(i int) []string { return chavesContato(normalizados[i]) },
+		func(i, j int) (int, []string) { return pontuarContatos(normalizados[i], normalizados[j]) },
+		limiar)
+
+	clusters := make([]Cluster, 0, len(grupos))
+	for _, g := range grupos {
+		c := Cluster{Pontuacao: g.pontuacao, Motivos: listaMotivos(g.motivos)}
+		for _, i := range g.indices {
+			ct := normalizados[i].Contato
+			c.Membros = append(c.Membros, Membro{ID: ct.ID, Nome: ct.Nome, Documento: ct.Email})
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters, nil
+}
+
+// telefonesContato extrai os telefones normalizados do campo JSON do contato.
+func telefonesContato(ct contato.Contato) []string {
+	var telefones []string
+	for _, t := range telefone.Decodificar(ct.Telefones) {
+		if n := normalizacao.Telefone(t.Numero); n != "" {
+			telefones = append(telefones, n)
+		}
+	}
+	return telefones
+}
+
+// MesclarEmpresas reaponta negociações, tarefas, anotações, anexos e contatos das empresas
+// duplicadas para a sobrevivente, preenche os campos vazios da sobrevivente com os
+// dados das duplicadas, remove as duplicadas e registra a operação na auditoria.
+func (r *repository) MesclarEmpresas(p PedidoMesclagem) (ResultadoMesclagem, error) {
+	if err := validarPedido(&p); err != nil {
+		return ResultadoMesclagem{}, err
+	}
+	resultado := ResultadoMesclagem{SobreviventeID: p.SobreviventeID, Mescladas: p.DuplicadasIDs, Reapontados: map[string]int64{}}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var sobrevivente empresa.Empresa
+		if err := tx.First(&sobrevivente, p.SobreviventeID).Error; err != nil {
+			return errors.New("empresa sobrevivente não encontrada")
+		}
+		var duplicadas []empresa.Empresa
+		if err := tx.Find(&duplicadas, p.DuplicadasIDs).Error; err != nil {
+			return err
+		}
+		if len(duplicadas) != len(p.DuplicadasIDs) {
+			return errors.New("uma ou mais empresas duplicadas não foram encontradas")
+		}
+
+		nomes := make([]string, 0, len(duplicadas))
+		for _, d := range duplicadas {
+			nomes = append(nomes, d.Nome)
+			preencherEmpresa(&sobrevivente, d)
+			if sobrevivente.MatrizID != nil && *sobrevivente.MatrizID == d.ID {
+				// A sobrevivente era filial de uma duplicada: passa a ser a matriz.
+				sobrevivente.MatrizID = nil
+			}
+		}
+		if err := tx.Save(&sobrevivente).Error; err != nil {
+			return err
+		}
+
+		reapontamentos := []struct {
+			tabela  string
+			coluna  string
+			valores map[string]interface{}
+		}{
+			{"negociacoes", "empresa_id", map[string]interface{}{"empresa_id": sobrevivente.ID}},
+			{"tarefas", "empresa_id", map[string]interface{}{"empresa_id": sobrevivente.ID, "empresa_negociacao": sobrevivente.Nome}},
+			{"anotacoes", "entidade = 'empresa' AND entidade_id", map[string]interface{}{"entidade_id": sobrevivente.ID}},
+			{"anexos", "entidade = 'empresa' AND entidade_id", map[string]interface{}{"entidade_id": sobrevivente.ID}},
+		}
+		for _, rp := range reapontamentos {
+			res := tx.Table(rp.tabela).Where(rp.coluna+" IN ?", p.DuplicadasIDs).Updates(rp.valores)
+			if res.Error != nil {
+				return res.Error
+			}
+			resultado.Reapontados[rp.tabela] = res.RowsAffected
+		}
+		res := tx.Model(&empresa.Empresa{}).
+			Where("matriz_id IN ?", p.DuplicadasIDs).
+			Where("id <> ?", sobrevivente.ID).
+			Update("matriz_id", sobrevivente.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		resultado.Reapontados["filiais"] = res.RowsAffected
+
+		res = tx.Model(&contato.Contato{}).Where("empresa IN ?", nomes).Update("empresa", sobrevivente.Nome)
+		if res.Error != nil {
+			return res.Error
+		}
+		resultado.Reapontados["contatos"] = res.RowsAffected
+
+		res = tx.Model(&contato.VinculoEmpresa{}).Where("empresa_id IN ?", p.DuplicadasIDs).Update("empresa_id", sobrevivente.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		resultado.Reapontados["contato_empresas"] = res.RowsAffected
+
+		if err := tx.Delete(&empresa.Empresa{}, p.DuplicadasIDs).Error; err != nil {
+			return err
+		}
+		return auditoria.Registrar(tx, "empresa", sobrevivente.ID, "mesclagem", p.Usuario, resultado)
+	})
+	if err != nil {
+		return ResultadoMesclagem{}, err
+	}
+	return resultado, nil
+}
+
+// MesclarContatos reaponta as negociações, anotações e anexos dos contatos duplicados para o sobrevivente,
+// preenche os campos vazios do sobrevivente, remove os duplicados e registra a auditoria.
+func (r *repository) MesclarContatos(p PedidoMesclagem) (ResultadoMesclagem, error) {
+	if err := validarPedido(&p); err != nil {
+		return ResultadoMesclagem{}, err
+	}
+	resultado := ResultadoMesclagem{SobreviventeID: p.SobreviventeID, Mescladas: p.DuplicadasIDs, Reapontados: map[string]int64{}}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var sobrevivente contato.Contato
+		if err := tx.First(&sobrevivente, p.SobreviventeID).Error; err != nil {
+			return errors.New("contato sobrevivente não encontrado")
+		}
+		var duplicados []contato.Contato
+		if err := tx.Find(&duplicados, p.DuplicadasIDs).Error; err != nil {
+			return err
+		}
+		if len(duplicados) != len(p.DuplicadasIDs) {
+			return errors.New("um ou mais contatos duplicados não foram encontrados")
+		}
+
+		for _, d := range duplicados {
+			preencherContato(&sobrevivente, d)
+		}
+		if err := tx.Save(&sobrevivente).Error; err != nil {
+			return err
+		}
+
+		res := tx.Table("negociacoes").Where("contato_id IN ?", p.DuplicadasIDs).Update("contato_id", sobrevivente.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		resultado.Reapontados["negociacoes"] = res.RowsAffected
+
+		// Participações: descarta as que colidiriam na mesma negociação e reaponta as demais.
+		if err := tx.Exec(`DELETE FROM negociacao_contatos d
+			WHERE d.contato_id IN ? AND EXISTS (
+				SELECT 1 FROM negociacao_contatos o
+				WHERE o.negociacao_id = d.negociacao_id
+				  AND (o.contato_id = ? OR (o.contato_id IN ? AND o.id < d.id)))`,
+			p.DuplicadasIDs, sobrevivente.ID, p.DuplicadasIDs).Error; err != nil {
+			return err
+		}
+		res = tx.Table("negociacao_contatos").Where("contato_id IN ?", p.DuplicadasIDs).Update("contato_id", sobrevivente.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		resultado.Reapontados["negociacao_contatos"] = res.RowsAffected
+
+		res = tx.Model(&contato.VinculoEmpresa{}).Where("contato_id IN ?", p.DuplicadasIDs).Update("contato_id", sobrevivente.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		resultado.Reapontados["contato_empresas"] = res.RowsAffected
+
+		res = tx.Table("anotacoes").Where("entidade = 'contato' AND entidade_id IN ?", p.DuplicadasIDs).Update("entidade_id", sobrevivente.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		resultado.Reapontados["anotacoes"] = res.RowsAffected
+
+		res = tx.Table("anexos").Where("entidade = 'contato' AND entidade_id IN ?", p.DuplicadasIDs).Update("entidade_id", sobrevivente.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		resultado.Reapontados["anexos"] = res.RowsAffected
+
+		if err := tx.Delete(&contato.Contato{}, p.DuplicadasIDs).Error; err != nil {
+			return err
+		}
+		return auditoria.Registrar(tx, "contato", sobrevivente.ID, "mesclagem", p.Usuario, resultado)
+	})
+	if err != nil {
+		return ResultadoMesclagem{}, err
+	}
+	return resultado, nil
+}
+
+// validarPedido confere o pedido de mesclagem e remove os IDs repetidos de
+// duplicadas_ids. O sobrevivente não pode estar entre as duplicadas.
+func validarPedido(p *PedidoMesclagem) error {
+	if p.SobreviventeID == 0 || len(p.DuplicadasIDs) == 0 {
+		return errors.New("sobrevivente_id e duplicadas_ids são obrigatórios")
+	}
+	vistos := make(map[int]bool, len(p.DuplicadasIDs))
+	unicas := make([]int, 0, len(p.DuplicadasIDs))
+	for _, id := range p.DuplicadasIDs {
+		if id == p.SobreviventeID {
+			return fmt.Errorf("o registro %d não pode ser sobrevivente e duplicado ao mesmo tempo", id)
+		}
+		if !vistos[id] {
+			vistos[id] = true
+			unicas = append(unicas, id)
+		}
+	}
+	p.DuplicadasIDs = unicas
+	return nil
+}
+
+// preencherEmpresa copia para s os campos que estão vazios em s e preenchidos em d.
+func preencherEmpresa(s *empresa.Empresa, d empresa.Empresa) {
+	campos := []struct{ destino, origem *string }{
+		{&s.Segmento, &d.Segmento},
+		{&s.URL, &d.URL},
+		{&s.Resumo, &d.Resumo},
+		{&s.TamanhoEmpresa, &d.TamanhoEmpresa},
+		{&s.FaixaFaturamento, &d.FaixaFaturamento},
+		{&s.CNPJMatriz, &d.CNPJMatriz},
+		{&s.RazaoSocial, &d.RazaoSocial},
+		{&s.TelefoneMatriz, &d.TelefoneMatriz},
+		{&s.LinkedinEmpresa, &d.LinkedinEmpresa},
+	}
+	for _, c := range campos {
+		if *c.destino == "" {
+			*c.destino = *c.origem
+		}
+	}
+	// O endereço é copiado por inteiro para não misturar partes de endereços diferentes.
+	if s.CEP == "" && s.Logradouro == "" && s.Cidade == "" {
+		s.DefinirEndereco(d.Endereco())
+	}
+	if s.ClienteID == 0 {
+		s.ClienteID = d.ClienteID
+	}
+	if s.GrupoEconomicoID == nil {
+		s.GrupoEconomicoID = d.GrupoEconomicoID
+	}
+	s.ClienteDaBase = s.ClienteDaBase || d.ClienteDaBase
+}
+
+// preencherContato copia para s os campos que estão vazios em s e preenchidos em d.
+func preencherContato(s *contato.Contato, d contato.Contato) {
+	campos := []struct{ destino, origem *string }{
+		{&s.Cargo, &d.Cargo},
+		{&s.Email, &d.Email},
+		{&s.Empresa, &d.Empresa},
+		{&s.InformacoesAdicionais, &d.InformacoesAdicionais},
+		{&s.LinkedIn, &d.LinkedIn},
+	}
+	for _, c := range campos {
+		if *c.destino == "" {
+			*c.destino = *c.origem
+		}
+	}
+	if len(s.Telefones) == 0 {
+		s.Telefones = d.Telefones
+	}
+	if len(s.CamposPersonalizados) == 0 {
+		s.CamposPersonalizados = d.CamposPersonalizados
+	}
+	s.EDecisor = s.EDecisor || d.EDecisor
+}
@@ -4,17 +4,24 @@ import (
 	"net/http"
 	"strconv"
 
+	"my-crm-backend/internal/anotacao"
+	"my-crm-backend/internal/campopersonalizado"
+	"my-crm-backend/internal/endereco"
+	"my-crm-backend/internal/telefone"
+
 	"github.com/gin-gonic/gin"
 )
 
 // Handler define os manipuladores HTTP para as operações de empresa.
 type Handler struct {
-	repo Repository
+	repo      Repository
+	campos    campopersonalizado.Validador
+	enderecos endereco.Completador
 }
 
 // NovoHandler cria e retorna um novo handler para Empresa.
-func NovoHandler(repo Repository) *Handler {
-	return &Handler{repo: repo}
+func NovoHandler(repo Repository, campos campopersonalizado.Validador, enderecos endereco.Completador) *Handler {
+	return &Handler{repo: repo, campos: campos, enderecos: enderecos}
 }
 
 // CriarEmpresa insere uma nova empresa no banco de dados.
@@ -30,6 +37,24 @@ func (h *Handler) CriarEmpresa(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Nome da empresa e CNPJ Matriz são obrigatórios"})
 		return
 	}
+	campos, err := h.campos.Validar(campopersonalizado.EntidadeEmpresa, e.CamposPersonalizados)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	e.CamposPersonalizados = campos
+	if e.TelefoneMatriz != "" {
+		if e.TelefoneMatriz, _, err = telefone.Normalizar(e.TelefoneMatriz); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	end := e.Endereco()
+	if err := h.enderecos.Completar(&end); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	e.DefinirEndereco(end)
 
 	novaEmpresa, err := h.repo.Adicionar(e)
 	if err != nil {
@@ -40,8 +65,19 @@ func (h *Handler) CriarEmpresa(c *gin.Context) {
 }
 
 // ListarEmpresas retorna todas as empresas cadastradas.
+// Aceita filtros e ordenação por campos personalizados (ex.: ?cp.setor=varejo&ordenar=-cp.funcionarios).
 func (h *Handler) ListarEmpresas(c *gin.Context) {
-	empresas, err := h.repo.Listar()
+	defs, err := h.campos.Definicoes(campopersonalizado.EntidadeEmpresa)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	filtro, err := campopersonalizado.LerFiltro(c.Request.URL.Query(), defs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	empresas, err := h.repo.Listar(filtro)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -80,6 +116,33 @@ func (h *Handler) AtualizarEmpresa(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if len(updated.CamposPersonalizados) > 0 {
+		campos, err := h.campos.Validar(campopersonalizado.EntidadeEmpresa, updated.CamposPersonalizados)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updated.CamposPersonalizados = campos
+	}
+	if updated.TelefoneMatriz != "" {
+		if updated.TelefoneMatriz, _, err = telefone.Normalizar(updated.TelefoneMatriz); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	// O endereço enviado é conferido junto com o que a empresa já tem: uma nova
+	// cidade é validada contra o CEP gravado, e um novo CEP corrige a cidade.
+	atual, err := h.repo.ObterPorID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	end := endereco.Mesclar(atual.Endereco(), updated.Endereco())
+	if err := h.enderecos.Completar(&end); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	updated.DefinirEndereco(end)
 
 	empresaAtualizada, err := h.repo.Atualizar(id, updated)
 	if err != nil {
@@ -105,9 +168,10 @@ func (h *Handler) DeletarEmpresa(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
-// AdicionarAnotacao adiciona uma nova anotação à empresa.
-// Espera receber um JSON no formato: {"anotacao": "texto da anotação"}.
-// Caso necessário, é possível adaptar para receber também o campo "assunto".
+// AdicionarAnotacao adiciona uma nova anotação à empresa e retorna a empresa com
+// as anotações atualizadas.
+// Espera receber um JSON no formato: {"assunto": "...", "anotacao": "texto da anotação", "autor": "...", "fixada": false}.
+// Sem assunto, a anotação é gravada como "Nova anotação".
 func (h *Handler) AdicionarAnotacao(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -116,20 +180,57 @@ func (h *Handler) AdicionarAnotacao(c *gin.Context) {
 		return
 	}
 
-	var payload struct {
-		Anotacao string `json:"anotacao"`
-		// Se desejar que o "assunto" seja passado pela requisição, adicione:
-		// Assunto string `json:"assunto"`
-	}
+	var payload anotacao.Anotacao
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if payload.Assunto == "" {
+		payload.Assunto = "Nova anotação"
+	}
 
-	empresa, err := h.repo.AdicionarAnotacao(id, payload.Anotacao)
+	empresa, err := h.repo.AdicionarAnotacao(id, payload)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, empresa)
 }
+
+// ListarFiliais retorna as filiais de uma matriz.
+func (h *Handler) ListarFiliais(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	filiais, err := h.repo.ListarFiliais(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, filiais)
+}
+
+// DefinirMatriz vincula a empresa a uma matriz.
+// Espera receber um JSON com: {"matriz_id": 1}. Envie {"matriz_id": null} para desvincular.
+func (h *Handler) DefinirMatriz(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	var payload struct {
+		MatrizID *int `json:"matriz_id"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
+		return
+	}
+	empresa, err := h.repo.DefinirMatriz(id, payload.MatrizID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, empresa)
+}
@@ -4,7 +4,9 @@ import (
 	"time"
 
 	"my-crm-backend/internal/anotacao"
+	"my-crm-backend/internal/endereco"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -20,17 +22,55 @@ type Empresa struct {
 	RazaoSocial      string `json:"razao_social,omitempty"`
 	TelefoneMatriz   string `json:"telefone_matriz,omitempty"`
 	CEP              string `json:"cep,omitempty"`
+	Logradouro       string `json:"logradouro,omitempty"`
+	Numero           string `json:"numero,omitempty"`
+	Complemento      string `json:"complemento,omitempty"`
+	Bairro           string `json:"bairro,omitempty"`
 	Cidade           string `json:"cidade,omitempty"`
 	Estado           string `json:"estado,omitempty"`
 	ClienteDaBase    bool   `json:"cliente_da_base"`
 	ClienteID        int    `json:"cliente_id"`
 	LinkedinEmpresa  string `json:"linkedin_empresa,omitempty"`
-	Grupo            string `json:"grupo,omitempty"`
+
+	CamposPersonalizados datatypes.JSON `json:"campos_personalizados,omitempty"`
+
+	// Pontuação pelas regras de pontuação; calculada pelo CRM, nunca gravada pela API.
+	Pontuacao int `json:"pontuacao" gorm:"<-:false;not null;default:0;index"`
+
+	// Hierarquia: uma filial aponta para a sua matriz (mesma raiz de CNPJ)
+	// e qualquer empresa pode pertencer a um grupo econômico.
+	MatrizID         *int      `json:"matriz_id,omitempty" gorm:"index"`
+	Filiais          []Empresa `json:"filiais,omitempty" gorm:"foreignKey:MatrizID"`
+	GrupoEconomicoID *int      `json:"grupo_economico_id,omitempty" gorm:"index"`
 
 	// Associação com Anotações (não gera ciclo, pois anotacao não importa empresa)
-	Anotacoes []anotacao.Anotacao `json:"anotacoes,omitempty" gorm:"foreignKey:EmpresaID"`
+	Anotacoes []anotacao.Anotacao `json:"anotacoes,omitempty" gorm:"polymorphicType:Entidade;polymorphicId:EntidadeID;polymorphicValue:empresa"`
 
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
+
+// Endereco retorna os campos de endereço da empresa na estrutura compartilhada.
+func (e Empresa) Endereco() endereco.Endereco {
+	return endereco.Endereco{
+		CEP:         e.CEP,
+		Logradouro:  e.Logradouro,
+		Numero:      e.Numero,
+		Complemento: e.Complemento,
+		Bairro:      e.Bairro,
+		Cidade:      e.Cidade,
+		Estado:      e.Estado,
+	}
+}
+
+// DefinirEndereco copia para a empresa os campos da estrutura de endereço.
+func (e *Empresa) DefinirEndereco(end endereco.Endereco) {
+	e.CEP = end.CEP
+	e.Logradouro = end.Logradouro
+	e.Numero = end.Numero
+	e.Complemento = end.Complemento
+	e.Bairro = end.Bairro
+	e.Cidade = end.Cidade
+	e.Estado = end.Estado
+}
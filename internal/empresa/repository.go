@@ -2,21 +2,25 @@ package empresa
 
 import (
 	"errors"
-	"time"
 
 	"gorm.io/gorm"
 
 	"my-crm-backend/internal/anotacao"
+	"my-crm-backend/internal/campopersonalizado"
+	"my-crm-backend/internal/evento"
+	"my-crm-backend/internal/normalizacao"
 )
 
 // Repository define as operações básicas para manipular empresas.
 type Repository interface {
 	Adicionar(e Empresa) (Empresa, error)
-	Listar() ([]Empresa, error)
+	Listar(filtro campopersonalizado.Filtro) ([]Empresa, error)
 	ObterPorID(id int) (*Empresa, error)
 	Atualizar(id int, updated Empresa) (Empresa, error)
 	Deletar(id int) error
-	AdicionarAnotacao(id int, anotacaoText string) (Empresa, error)
+	AdicionarAnotacao(id int, a anotacao.Anotacao) (Empresa, error)
+	ListarFiliais(id int) ([]Empresa, error)
+	DefinirMatriz(id int, matrizID *int) (Empresa, error)
 }
 
 type repository struct {
@@ -28,15 +32,29 @@ func NovoRepositorio(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
+// Adicionar insere uma nova empresa. Se a empresa for uma filial (CNPJ com ordem
+// diferente de 0001) e a matriz não for informada, vincula-a à matriz de mesma raiz, se existir.
 func (r *repository) Adicionar(e Empresa) (Empresa, error) {
-	err := r.db.Create(&e).Error
+	if e.MatrizID != nil {
+		if err := r.validarMatriz(0, e.CNPJMatriz, *e.MatrizID); err != nil {
+			return Empresa{}, err
+		}
+	} else if matriz, ok := r.buscarMatriz(e.CNPJMatriz); ok {
+		e.MatrizID = &matriz.ID
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&e).Error; err != nil {
+			return err
+		}
+		return evento.Publicar(tx, evento.EmpresaCriada, "empresa", e.ID, "", e)
+	})
 	return e, err
 }
 
-func (r *repository) Listar() ([]Empresa, error) {
+func (r *repository) Listar(filtro campopersonalizado.Filtro) ([]Empresa, error) {
 	var empresas []Empresa
-	err := r.db.
-		Preload("Anotacoes").
+	err := filtro.Aplicar(r.db).
+		Preload("Anotacoes", anotacao.Ordenadas).
 		// Removi o Preload("Negociacoes") pois essa associação não está definida no model.
 		Find(&empresas).Error
 	return empresas, err
@@ -45,7 +63,7 @@ func (r *repository) Listar() ([]Empresa, error) {
 func (r *repository) ObterPorID(id int) (*Empresa, error) {
 	var empresa Empresa
 	err := r.db.
-		Preload("Anotacoes").
+		Preload("Anotacoes", anotacao.Ordenadas).
 		// Se houver relacionamento com Negociacoes, acrescente: Preload("Negociacoes").
 		First(&empresa, id).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -65,8 +83,33 @@ func (r *repository) Atualizar(id int, updated Empresa) (Empresa, error) {
 		return Empresa{}, err
 	}
 
+	if updated.MatrizID != nil {
+		cnpj := updated.CNPJMatriz
+		if cnpj == "" {
+			cnpj = empresa.CNPJMatriz
+		}
+		if err := r.validarMatriz(id, cnpj, *updated.MatrizID); err != nil {
+			return Empresa{}, err
+		}
+	}
+
 	updated.ID = id
-	err := r.db.Model(&empresa).Updates(updated).Error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&empresa).Updates(updated).Error; err != nil {
+			return err
+		}
+		if updated.Nome != "" && updated.Nome != empresa.Nome {
+			// Mantém o nome desnormalizado nas tarefas da empresa.
+			if err := tx.Table("tarefas").Where("empresa_id = ?", id).Update("empresa_negociacao", updated.Nome).Error; err != nil {
+				return err
+			}
+		}
+		var depois Empresa
+		if err := tx.First(&depois, id).Error; err != nil {
+			return err
+		}
+		return evento.Publicar(tx, evento.EmpresaAtualizada, "empresa", id, "", depois)
+	})
 	return updated, err
 }
 
@@ -76,7 +119,7 @@ func (r *repository) Deletar(id int) error {
 
 // AdicionarAnotacao adiciona uma nova anotação à empresa identificada pelo id.
 // Aqui, a operação é realizada dentro de uma transação para garantir a consistência.
-func (r *repository) AdicionarAnotacao(id int, anotacaoText string) (Empresa, error) {
+func (r *repository) AdicionarAnotacao(id int, a anotacao.Anotacao) (Empresa, error) {
 	var empresa Empresa
 
 	// Executa a operação em uma transação.
@@ -85,19 +128,11 @@ func (r *repository) AdicionarAnotacao(id int, anotacaoText string) (Empresa, er
 			return err
 		}
 
-		// Cria uma nova anotação. Caso precise receber o "assunto" por parâmetro, ajuste aqui.
-		novaAnotacao := anotacao.Anotacao{
-			Data:      time.Now(),
-			Assunto:   "Nova anotação", // Valor fixo; pode ser parametrizado se necessário.
-			Anotacao:  anotacaoText,
-			EmpresaID: empresa.ID,
-		}
-
-		if err := tx.Create(&novaAnotacao).Error; err != nil {
-			return err
-		}
-
-		return nil
+		a.ID = 0
+		a.Entidade = anotacao.EntidadeEmpresa
+		a.EntidadeID = empresa.ID
+		_, err := anotacao.NovoRepositorio(tx).Adicionar(a)
+		return err
 	})
 
 	if err != nil {
@@ -106,10 +141,87 @@ func (r *repository) AdicionarAnotacao(id int, anotacaoText string) (Empresa, er
 
 	// Recarrega a empresa com as associações atualizadas.
 	if err := r.db.
-		Preload("Anotacoes").
+		Preload("Anotacoes", anotacao.Ordenadas).
 		First(&empresa, id).Error; err != nil {
 		return Empresa{}, errors.New("empresa not found after updating anotacoes")
 	}
 
 	return empresa, nil
 }
+
+// ListarFiliais retorna as filiais vinculadas à matriz identificada pelo id.
+func (r *repository) ListarFiliais(id int) ([]Empresa, error) {
+	if _, err := r.ObterPorID(id); err != nil {
+		return nil, err
+	}
+	var filiais []Empresa
+	err := r.db.Where("matriz_id = ?", id).Order("cnpj_matriz").Find(&filiais).Error
+	return filiais, err
+}
+
+// DefinirMatriz vincula a empresa à matriz informada ou, se matrizID for nil, remove o vínculo.
+func (r *repository) DefinirMatriz(id int, matrizID *int) (Empresa, error) {
+	var empresa Empresa
+	if err := r.db.First(&empresa, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Empresa{}, errors.New("empresa not found")
+		}
+		return Empresa{}, err
+	}
+	if matrizID != nil {
+		if err := r.validarMatriz(id, empresa.CNPJMatriz, *matrizID); err != nil {
+			return Empresa{}, err
+		}
+	}
+	if err := r.db.Model(&empresa).Update("matriz_id", matrizID).Error; err != nil {
+		return Empresa{}, err
+	}
+	empresa.MatrizID = matrizID
+	return empresa, nil
+}
+
+// validarMatriz garante que a matriz existe, não é ela mesma uma filial e compartilha a raiz do CNPJ,
+// e que a empresa (id 0 para uma nova) não tem filiais, o que criaria mais de um nível na hierarquia.
+func (r *repository) validarMatriz(id int, cnpj string, matrizID int) error {
+	if matrizID == id {
+		return errors.New("uma empresa não pode ser matriz de si mesma")
+	}
+	if id != 0 {
+		var filiais int64
+		if err := r.db.Model(&Empresa{}).Where("matriz_id = ?", id).Count(&filiais).Error; err != nil {
+			return err
+		}
+		if filiais > 0 {
+			return errors.New("uma empresa com filiais não pode ser vinculada a outra matriz")
+		}
+	}
+	var matriz Empresa
+	if err := r.db.First(&matriz, matrizID).Error; err != nil {
+		return errors.New("matriz not found")
+	}
+	if matriz.MatrizID != nil {
+		return errors.New("a matriz informada é uma filial")
+	}
+	raiz := normalizacao.RaizCNPJ(cnpj)
+	if raiz == "" || raiz != normalizacao.RaizCNPJ(matriz.CNPJMatriz) {
+		return errors.New("a filial deve compartilhar a raiz do CNPJ com a matriz")
+	}
+	return nil
+}
+
+// buscarMatriz procura a matriz (ordem 0001) com a mesma raiz de CNPJ de uma filial.
+func (r *repository) buscarMatriz(cnpj string) (Empresa, bool) {
+	digitos := normalizacao.Digitos(cnpj)
+	if len(digitos) != 14 || digitos[8:12] == "0001" {
+		return Empresa{}, false
+	}
+	var matriz Empresa
+	err := r.db.
+		Where("matriz_id IS NULL").
+		Where("regexp_replace(cnpj_matriz, '[^0-9]', '', 'g') LIKE ?", digitos[:8]+"0001%").
+		First(&matriz).Error
+	if err != nil {
+		return Empresa{}, false
+	}
+	return matriz, true
+}
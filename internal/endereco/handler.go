@@ -0,0 +1,53 @@
+package endereco
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler define os manipuladores HTTP para a base local de CEPs.
+type Handler struct {
+	repo Repository
+}
+
+// NovoHandler cria e retorna um novo handler para CEPs.
+func NovoHandler(repo Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+// Buscar consulta um CEP na base local: GET /api/ceps/:cep
+func (h *Handler) Buscar(c *gin.Context) {
+	reg, err := h.repo.Buscar(c.Param("cep"))
+	if errors.Is(err, ErrCEPNaoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, reg)
+}
+
+// Importar carrega um arquivo CSV de CEPs (colunas cep, logradouro, bairro, cidade, uf).
+// Aceita o arquivo no campo multipart "arquivo" ou o CSV diretamente no corpo da requisição.
+func (h *Handler) Importar(c *gin.Context) {
+	corpo := c.Request.Body
+	if arquivo, err := c.FormFile("arquivo"); err == nil {
+		f, err := arquivo.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer f.Close()
+		corpo = f
+	}
+	total, err := h.repo.Importar(corpo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "importados": total})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"importados": total})
+}
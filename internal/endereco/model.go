@@ -0,0 +1,82 @@
+package endereco
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Endereco é a estrutura de endereço compartilhada por empresas e clientes.
+// Pode ser incorporada em um model com gorm:"embedded".
+type Endereco struct {
+	CEP         string `json:"cep,omitempty"` // Formato 00000-000
+	Logradouro  string `json:"logradouro,omitempty"`
+	Numero      string `json:"numero,omitempty"`
+	Complemento string `json:"complemento,omitempty"`
+	Bairro      string `json:"bairro,omitempty"`
+	Cidade      string `json:"cidade,omitempty"`
+	Estado      string `json:"estado,omitempty"` // Sigla da UF
+}
+
+// UnmarshalJSON aceita, além do objeto, o formato legado em que o endereço era
+// um texto livre; nesse caso o texto é guardado em Logradouro.
+func (e *Endereco) UnmarshalJSON(dados []byte) error {
+	var texto string
+	if err := json.Unmarshal(dados, &texto); err == nil {
+		*e = Endereco{Logradouro: texto}
+		return nil
+	}
+	type endereco Endereco
+	var aux endereco
+	if err := json.Unmarshal(dados, &aux); err != nil {
+		return err
+	}
+	*e = Endereco(aux)
+	return nil
+}
+
+// Mesclar aplica um endereço parcial, vindo de uma alteração, sobre o atual:
+// os campos vazios mantêm os valores atuais. Quando o CEP muda, o logradouro,
+// o bairro, a cidade e a UF atuais são descartados, para que Completar os
+// preencha a partir do novo CEP.
+func Mesclar(atual, alteracao Endereco) Endereco {
+	if alteracao.CEP != "" {
+		novo, _ := DigitosCEP(alteracao.CEP)
+		anterior, _ := DigitosCEP(atual.CEP)
+		if novo != anterior {
+			atual = Endereco{Numero: atual.Numero, Complemento: atual.Complemento}
+		}
+	}
+	campos := []struct {
+		destino *string
+		valor   string
+	}{
+		{&atual.CEP, alteracao.CEP},
+		{&atual.Logradouro, alteracao.Logradouro},
+		{&atual.Numero, alteracao.Numero},
+		{&atual.Complemento, alteracao.Complemento},
+		{&atual.Bairro, alteracao.Bairro},
+		{&atual.Cidade, alteracao.Cidade},
+		{&atual.Estado, alteracao.Estado},
+	}
+	for _, c := range campos {
+		if c.valor != "" {
+			*c.destino = c.valor
+		}
+	}
+	return atual
+}
+
+// RegistroCEP é uma linha da base local de CEPs.
+type RegistroCEP struct {
+	CEP        string    `json:"cep" gorm:"primaryKey;size:8"` // Apenas dígitos
+	Logradouro string    `json:"logradouro,omitempty"`
+	Bairro     string    `json:"bairro,omitempty"`
+	Cidade     string    `json:"cidade"`
+	UF         string    `json:"uf" gorm:"size:2"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (RegistroCEP) TableName() string {
+	return "ceps"
+}
@@ -0,0 +1,186 @@
+package endereco
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"my-crm-backend/internal/normalizacao"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Completador valida e completa endereços a partir da base local de CEPs.
+type Completador interface {
+	Completar(e *Endereco) error
+}
+
+// Repository define as operações sobre a base local de CEPs.
+type Repository interface {
+	Completador
+	Buscar(cep string) (*RegistroCEP, error)
+	Importar(r io.Reader) (int, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NovoRepositorio cria e retorna um repositório baseado em GORM.
+func NovoRepositorio(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// ErrCEPNaoEncontrado indica que o CEP é válido, mas não consta na base local.
+var ErrCEPNaoEncontrado = errors.New("CEP não encontrado na base local")
+
+// Buscar consulta um CEP na base local.
+func (r *repository) Buscar(cep string) (*RegistroCEP, error) {
+	digitos, err := DigitosCEP(cep)
+	if err != nil {
+		return nil, err
+	}
+	var reg RegistroCEP
+	if err := r.db.First(&reg, "cep = ?", digitos).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCEPNaoEncontrado
+		}
+		return nil, err
+	}
+	return &reg, nil
+}
+
+// Completar valida o CEP e a UF do endereço e, quando o CEP consta na base local,
+// preenche cidade, UF, logradouro e bairro vazios. Cidade ou UF informadas que
+// divergem do CEP resultam em erro. CEPs ausentes da base são aceitos sem conferência.
+func (r *repository) Completar(e *Endereco) error {
+	if e.Estado != "" {
+		if !UFValida(e.Estado) {
+			return fmt.Errorf("UF inválida: %s", e.Estado)
+		}
+		e.Estado = strings.ToUpper(strings.TrimSpace(e.Estado))
+	}
+	if e.CEP == "" {
+		return nil
+	}
+	digitos, err := DigitosCEP(e.CEP)
+	if err != nil {
+		return err
+	}
+	e.CEP = FormatarCEP(digitos)
+
+	reg, err := r.Buscar(digitos)
+	if errors.Is(err, ErrCEPNaoEncontrado) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if e.Estado != "" && e.Estado != reg.UF {
+		return fmt.Errorf("o CEP %s pertence a %s/%s, não a UF %s", e.CEP, reg.Cidade, reg.UF, e.Estado)
+	}
+	if e.Cidade != "" && normalizacao.Texto(e.Cidade) != normalizacao.Texto(reg.Cidade) {
+		return fmt.Errorf("o CEP %s pertence a %s/%s, não a %s", e.CEP, reg.Cidade, reg.UF, e.Cidade)
+	}
+	e.Estado = reg.UF
+	e.Cidade = reg.Cidade
+	if e.Logradouro == "" {
+		e.Logradouro = reg.Logradouro
+	}
+	if e.Bairro == "" {
+		e.Bairro = reg.Bairro
+	}
+	return nil
+}
+
+// Importar carrega um arquivo CSV (separado por ";" ou ",") com as colunas
+// cep, logradouro, bairro, cidade e uf, nessa ordem. Uma linha de cabeçalho é
+// ignorada. CEPs já existentes são atualizados. Retorna a quantidade de linhas gravadas.
+func (r *repository) Importar(arquivo io.Reader) (int, error) {
+	entrada := bufio.NewReader(arquivo)
+	primeira, _ := entrada.Peek(4096)
+	if i := bytes.IndexByte(primeira, '\n'); i >= 0 {
+		primeira = primeira[:i]
+	}
+	leitor := csv.NewReader(entrada)
+	if bytes.Count(primeira, []byte(";")) > bytes.Count(primeira, []byte(",")) {
+		leitor.Comma = ';'
+	}
+	leitor.FieldsPerRecord = -1
+	leitor.LazyQuotes = true
+
+	var (
+		lote  []RegistroCEP
+		total int
+		linha int
+	)
+	gravar := func() error {
+		if len(lote) == 0 {
+			return nil
+		}
+		// O mesmo CEP duas vezes no lote faria o ON CONFLICT falhar: vale a última linha.
+		posicao := make(map[string]int, len(lote))
+		unicos := make([]RegistroCEP, 0, len(lote))
+		for _, reg := range lote {
+			if i, ok := posicao[reg.CEP]; ok {
+				unicos[i] = reg
+				continue
+			}
+			posicao[reg.CEP] = len(unicos)
+			unicos = append(unicos, reg)
+		}
+		err := r.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "cep"}},
+			DoUpdates: clause.AssignmentColumns([]string{"logradouro", "bairro", "cidade", "uf", "updated_at"}),
+		}).CreateInBatches(unicos, 1000).Error
+		if err == nil {
+			total += len(unicos)
+		}
+		lote = lote[:0]
+		return err
+	}
+
+	for {
+		campos, err := leitor.Read()
+		if err == io.EOF {
+			break
+		}
+		linha++
+		if err != nil {
+			return total, fmt.Errorf("linha %d: %v", linha, err)
+		}
+		if len(campos) < 5 {
+			return total, fmt.Errorf("linha %d: esperadas as colunas cep, logradouro, bairro, cidade, uf", linha)
+		}
+		digitos, err := DigitosCEP(campos[0])
+		if err != nil {
+			if linha == 1 {
+				continue // cabeçalho
+			}
+			return total, fmt.Errorf("linha %d: %v", linha, err)
+		}
+		uf := strings.ToUpper(strings.TrimSpace(campos[4]))
+		if !UFValida(uf) {
+			return total, fmt.Errorf("linha %d: UF inválida: %s", linha, campos[4])
+		}
+		lote = append(lote, RegistroCEP{
+			CEP:        digitos,
+			Logradouro: strings.TrimSpace(campos[1]),
+			Bairro:     strings.TrimSpace(campos[2]),
+			Cidade:     strings.TrimSpace(campos[3]),
+			UF:         uf,
+		})
+		if len(lote) >= 5000 {
+			if err := gravar(); err != nil {
+				return total, err
+			}
+		}
+	}
+	return total, gravar()
+}
@@ -0,0 +1,38 @@
+package endereco
+
+import (
+	"errors"
+	"strings"
+
+	"my-crm-backend/internal/normalizacao"
+)
+
+// ufs lista as siglas das unidades federativas.
+var ufs = map[string]bool{
+	"AC": true, "AL": true, "AP": true, "AM": true, "BA": true, "CE": true, "DF": true,
+	"ES": true, "GO": true, "MA": true, "MT": true, "MS": true, "MG": true, "PA": true,
+	"PB": true, "PR": true, "PE": true, "PI": true, "RJ": true, "RN": true, "RS": true,
+	"RO": true, "RR": true, "SC": true, "SP": true, "SE": true, "TO": true,
+}
+
+// DigitosCEP valida o formato do CEP e retorna os seus oito dígitos.
+func DigitosCEP(cep string) (string, error) {
+	limpo := strings.NewReplacer("-", "", ".", "", " ", "").Replace(strings.TrimSpace(cep))
+	if len(limpo) != 8 || normalizacao.Digitos(limpo) != limpo {
+		return "", errors.New("CEP inválido: use o formato 00000-000")
+	}
+	if limpo == "00000000" {
+		return "", errors.New("CEP inválido")
+	}
+	return limpo, nil
+}
+
+// FormatarCEP converte os oito dígitos do CEP no formato 00000-000.
+func FormatarCEP(digitos string) string {
+	return digitos[:5] + "-" + digitos[5:]
+}
+
+// UFValida indica se a sigla corresponde a uma unidade federativa.
+func UFValida(uf string) bool {
+	return ufs[strings.ToUpper(strings.TrimSpace(uf))]
+}
@@ -0,0 +1,133 @@
+package enriquecimento
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"my-crm-backend/internal/empresa"
+	"my-crm-backend/internal/endereco"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tempoConsulta limita a duração de uma consulta ao provedor.
+const tempoConsulta = 15 * time.Second
+
+// Handler define os manipuladores HTTP para revisar e aplicar o enriquecimento de empresas.
+type Handler struct {
+	registro  *Registro
+	empresas  empresa.Repository
+	enderecos endereco.Completador
+}
+
+// NovoHandler cria e retorna um novo handler de enriquecimento.
+func NovoHandler(registro *Registro, empresas empresa.Repository, enderecos endereco.Completador) *Handler {
+	return &Handler{registro: registro, empresas: empresas, enderecos: enderecos}
+}
+
+// ListarProvedores retorna os nomes dos provedores disponíveis.
+func (h *Handler) ListarProvedores(c *gin.Context) {
+	c.JSON(http.StatusOK, h.registro.Nomes())
+}
+
+// Propor consulta o provedor pelo CNPJ da empresa e retorna as alterações sugeridas,
+// sem gravá-las: GET /api/empresas/:id/enriquecimento?provedor=nome
+func (h *Handler) Propor(c *gin.Context) {
+	e, dados, provedor, ok := h.consultar(c, c.Query("provedor"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, Proposta{
+		EmpresaID:  e.ID,
+		Provedor:   provedor,
+		CNPJ:       e.CNPJMatriz,
+		Alteracoes: Comparar(*e, *dados),
+	})
+}
+
+// Aplicar grava na empresa os valores propostos pelo provedor.
+// Espera receber um JSON com: {"provedor": "arquivo", "campos": ["razao_social", "segmento"]}.
+// Sem "campos", todas as alterações propostas são aplicadas.
+func (h *Handler) Aplicar(c *gin.Context) {
+	var payload struct {
+		Provedor string   `json:"provedor"`
+		Campos   []string `json:"campos"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
+		return
+	}
+	e, dados, _, ok := h.consultar(c, payload.Provedor)
+	if !ok {
+		return
+	}
+
+	aplicados, err := Aplicar(e, *dados, payload.Campos)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(aplicados) == 0 {
+		c.JSON(http.StatusOK, gin.H{"aplicados": []string{}, "empresa": e})
+		return
+	}
+	end := e.Endereco()
+	if err := h.enderecos.Completar(&end); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	e.DefinirEndereco(end)
+
+	e.Anotacoes = nil
+	e.Filiais = nil
+	if _, err := h.empresas.Atualizar(e.ID, *e); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	atualizada, err := h.empresas.ObterPorID(e.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"aplicados": aplicados, "empresa": atualizada})
+}
+
+// consultar carrega a empresa do parâmetro :id e consulta o provedor pelo seu CNPJ.
+// Em caso de erro, escreve a resposta e retorna ok falso.
+func (h *Handler) consultar(c *gin.Context, nome string) (*empresa.Empresa, *Dados, string, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return nil, nil, "", false
+	}
+	provedor, err := h.registro.Obter(nome)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, nil, "", false
+	}
+	e, err := h.empresas.ObterPorID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, nil, "", false
+	}
+	if e.CNPJMatriz == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empresa sem CNPJ"})
+		return nil, nil, "", false
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), tempoConsulta)
+	defer cancel()
+	dados, err := provedor.Consultar(ctx, e.CNPJMatriz)
+	if errors.Is(err, ErrNaoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, nil, "", false
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return nil, nil, "", false
+	}
+	return e, dados, provedor.Nome(), true
+}
@@ -0,0 +1,57 @@
+package enriquecimento
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"my-crm-backend/internal/normalizacao"
+)
+
+// ProvedorMemoria responde a partir de um mapa em memória. É útil em testes e
+// em ambientes de desenvolvimento sem acesso a provedores reais.
+type ProvedorMemoria struct {
+	nome  string
+	dados map[string]Dados
+}
+
+// NovoProvedorMemoria cria um provedor com os dados informados, indexados por CNPJ
+// (com ou sem pontuação).
+func NovoProvedorMemoria(nome string, dados map[string]Dados) *ProvedorMemoria {
+	p := &ProvedorMemoria{nome: nome, dados: make(map[string]Dados, len(dados))}
+	for cnpj, d := range dados {
+		p.dados[normalizacao.Digitos(cnpj)] = d
+	}
+	return p
+}
+
+// Nome retorna o nome do provedor.
+func (p *ProvedorMemoria) Nome() string {
+	return p.nome
+}
+
+// Consultar retorna os dados do CNPJ ou ErrNaoEncontrado.
+func (p *ProvedorMemoria) Consultar(ctx context.Context, cnpj string) (*Dados, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	d, ok := p.dados[normalizacao.Digitos(cnpj)]
+	if !ok {
+		return nil, ErrNaoEncontrado
+	}
+	return &d, nil
+}
+
+// NovoProvedorArquivo cria um provedor a partir de um arquivo JSON no formato
+// {"12345678000190": {"razao_social": "...", "segmento": "...", ...}}.
+func NovoProvedorArquivo(nome, caminho string) (*ProvedorMemoria, error) {
+	conteudo, err := os.ReadFile(caminho)
+	if err != nil {
+		return nil, err
+	}
+	var dados map[string]Dados
+	if err := json.Unmarshal(conteudo, &dados); err != nil {
+		return nil, err
+	}
+	return NovoProvedorMemoria(nome, dados), nil
+}
@@ -0,0 +1,80 @@
+package enriquecimento
+
+import (
+	"fmt"
+
+	"my-crm-backend/internal/empresa"
+)
+
+// Alteracao é a diferença entre o valor atual de um campo da empresa e o valor proposto.
+type Alteracao struct {
+	Campo    string `json:"campo"`
+	Atual    string `json:"atual"`
+	Proposto string `json:"proposto"`
+}
+
+// Proposta reúne as alterações sugeridas por um provedor para uma empresa.
+type Proposta struct {
+	EmpresaID  int         `json:"empresa_id"`
+	Provedor   string      `json:"provedor"`
+	CNPJ       string      `json:"cnpj"`
+	Alteracoes []Alteracao `json:"alteracoes"`
+}
+
+// camposEmpresa associa o nome JSON de cada campo enriquecível ao campo da empresa e ao valor proposto.
+func camposEmpresa(e *empresa.Empresa, d Dados) []struct {
+	nome     string
+	destino  *string
+	proposto string
+} {
+	return []struct {
+		nome     string
+		destino  *string
+		proposto string
+	}{
+		{"razao_social", &e.RazaoSocial, d.RazaoSocial},
+		{"segmento", &e.Segmento, d.Segmento},
+		{"tamanho_empresa", &e.TamanhoEmpresa, d.TamanhoEmpresa},
+		{"cep", &e.CEP, d.CEP},
+		{"logradouro", &e.Logradouro, d.Logradouro},
+		{"numero", &e.Numero, d.Numero},
+		{"bairro", &e.Bairro, d.Bairro},
+		{"cidade", &e.Cidade, d.Cidade},
+		{"estado", &e.Estado, d.Estado},
+	}
+}
+
+// Comparar lista os campos em que o provedor propõe um valor diferente do atual.
+func Comparar(e empresa.Empresa, d Dados) []Alteracao {
+	alteracoes := []Alteracao{}
+	for _, c := range camposEmpresa(&e, d) {
+		if c.proposto != "" && c.proposto != *c.destino {
+			alteracoes = append(alteracoes, Alteracao{Campo: c.nome, Atual: *c.destino, Proposto: c.proposto})
+		}
+	}
+	return alteracoes
+}
+
+// Aplicar copia para e os valores propostos dos campos escolhidos (todos, se campos
+// estiver vazio) e retorna os nomes dos campos efetivamente alterados.
+func Aplicar(e *empresa.Empresa, d Dados, campos []string) ([]string, error) {
+	escolhidos := make(map[string]bool, len(campos))
+	for _, c := range campos {
+		escolhidos[c] = true
+	}
+	var aplicados []string
+	for _, c := range camposEmpresa(e, d) {
+		if len(escolhidos) > 0 && !escolhidos[c.nome] {
+			continue
+		}
+		delete(escolhidos, c.nome)
+		if c.proposto != "" && c.proposto != *c.destino {
+			*c.destino = c.proposto
+			aplicados = append(aplicados, c.nome)
+		}
+	}
+	for c := range escolhidos {
+		return nil, fmt.Errorf("campo não enriquecível: %s", c)
+	}
+	return aplicados, nil
+}
@@ -0,0 +1,82 @@
+package enriquecimento
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrNaoEncontrado indica que o provedor não possui dados para o CNPJ consultado.
+var ErrNaoEncontrado = errors.New("CNPJ não encontrado no provedor")
+
+// Dados são os valores que um provedor propõe para uma empresa.
+// Campos vazios significam que o provedor não tem a informação.
+type Dados struct {
+	RazaoSocial    string `json:"razao_social,omitempty"`
+	Segmento       string `json:"segmento,omitempty"`
+	TamanhoEmpresa string `json:"tamanho_empresa,omitempty"`
+	CEP            string `json:"cep,omitempty"`
+	Logradouro     string `json:"logradouro,omitempty"`
+	Numero         string `json:"numero,omitempty"`
+	Bairro         string `json:"bairro,omitempty"`
+	Cidade         string `json:"cidade,omitempty"`
+	Estado         string `json:"estado,omitempty"`
+}
+
+// Provedor é uma fonte de dados cadastrais de empresas consultada pelo CNPJ (somente dígitos).
+type Provedor interface {
+	Nome() string
+	Consultar(ctx context.Context, cnpj string) (*Dados, error)
+}
+
+// Registro mantém os provedores disponíveis, identificados pelo nome.
+type Registro struct {
+	mu         sync.RWMutex
+	provedores map[string]Provedor
+	padrao     string
+}
+
+// NovoRegistro cria um registro vazio de provedores.
+func NovoRegistro() *Registro {
+	return &Registro{provedores: make(map[string]Provedor)}
+}
+
+// Registrar adiciona um provedor. O primeiro provedor registrado passa a ser o padrão.
+func (r *Registro) Registrar(p Provedor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.provedores[p.Nome()] = p
+	if r.padrao == "" {
+		r.padrao = p.Nome()
+	}
+}
+
+// Obter retorna o provedor pelo nome; nome vazio retorna o provedor padrão.
+func (r *Registro) Obter(nome string) (Provedor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if nome == "" {
+		nome = r.padrao
+	}
+	p, ok := r.provedores[nome]
+	if !ok {
+		if nome == "" {
+			return nil, errors.New("nenhum provedor de enriquecimento configurado")
+		}
+		return nil, errors.New("provedor de enriquecimento desconhecido: " + nome)
+	}
+	return p, nil
+}
+
+// Nomes lista os provedores registrados em ordem alfabética.
+func (r *Registro) Nomes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	nomes := make([]string, 0, len(r.provedores))
+	for n := range r.provedores {
+		nomes = append(nomes, n)
+	}
+	sort.Strings(nomes)
+	return nomes
+}
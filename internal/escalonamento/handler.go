@@ -0,0 +1,98 @@
+package escalonamento
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler define os manipuladores HTTP para as regras de escalonamento.
+type Handler struct {
+	repo        Repository
+	verificador *Verificador
+}
+
+// NovoHandler cria e retorna um novo handler de escalonamento.
+func NovoHandler(repo Repository, verificador *Verificador) *Handler {
+	return &Handler{repo: repo, verificador: verificador}
+}
+
+// Criar insere uma nova regra de escalonamento.
+// Espera receber um JSON como: {"nome": "Avisar gestor", "dias_atraso": 2, "acao": "notificar_gestor"}
+func (h *Handler) Criar(c *gin.Context) {
+	var r Regra
+	if err := c.ShouldBindJSON(&r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := Validar(r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	criada, err := h.repo.Adicionar(r)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, criada)
+}
+
+// Listar retorna todas as regras de escalonamento.
+func (h *Handler) Listar(c *gin.Context) {
+	regras, err := h.repo.Listar()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, regras)
+}
+
+// Atualizar substitui uma regra de escalonamento.
+func (h *Handler) Atualizar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	var r Regra
+	if err := c.ShouldBindJSON(&r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := Validar(r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	atualizada, err := h.repo.Atualizar(id, r)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, atualizada)
+}
+
+// Deletar remove uma regra de escalonamento.
+func (h *Handler) Deletar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	if err := h.repo.Deletar(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Verificar executa imediatamente a verificação de atrasos e escalonamentos.
+func (h *Handler) Verificar(c *gin.Context) {
+	resultado, err := h.verificador.Verificar(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resultado)
+}
@@ -0,0 +1,33 @@
+package escalonamento
+
+import (
+	"time"
+
+	"my-crm-backend/internal/tarefa"
+)
+
+// Regra define o que fazer com tarefas atrasadas há pelo menos DiasAtraso dias.
+type Regra struct {
+	ID         int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	Nome       string `json:"nome"`
+	DiasAtraso int    `json:"dias_atraso"`
+	Acao       string `json:"acao"`              // notificar_gestor, reatribuir ou aumentar_prioridade
+	Destino    string `json:"destino,omitempty"` // Login notificado ou novo responsável; vazio usa o gestor do responsável
+	Tipo       string `json:"tipo,omitempty"`    // Restringe a regra a um tipo de tarefa; vazio vale para todos
+	Pausada    bool   `json:"pausada"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Regra) TableName() string {
+	return "regras_escalonamento"
+}
+
+// Resultado resume uma execução da verificação de atrasos.
+type Resultado struct {
+	Marcadas       int64                  `json:"marcadas"`
+	Liberadas      int64                  `json:"liberadas"`
+	Escalonamentos []tarefa.Escalonamento `json:"escalonamentos"`
+}
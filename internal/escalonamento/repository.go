@@ -0,0 +1,81 @@
+package escalonamento
+
+import (
+	"errors"
+	"fmt"
+
+	"my-crm-backend/internal/tarefa"
+
+	"gorm.io/gorm"
+)
+
+// Repository define as operações básicas para manipular regras de escalonamento.
+type Repository interface {
+	Adicionar(r Regra) (Regra, error)
+	Listar() ([]Regra, error)
+	ObterPorID(id int) (*Regra, error)
+	Atualizar(id int, updated Regra) (Regra, error)
+	Deletar(id int) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NovoRepositorio cria e retorna um repositório baseado em GORM.
+func NovoRepositorio(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Validar confere a ação e o atraso mínimo da regra.
+func Validar(r Regra) error {
+	switch r.Acao {
+	case tarefa.AcaoNotificarGestor, tarefa.AcaoReatribuir, tarefa.AcaoAumentarPrioridade:
+	default:
+		return fmt.Errorf("ação inválida: %q (use %s, %s ou %s)", r.Acao,
+			tarefa.AcaoNotificarGestor, tarefa.AcaoReatribuir, tarefa.AcaoAumentarPrioridade)
+	}
+	if r.DiasAtraso < 0 {
+		return errors.New("dias_atraso não pode ser negativo")
+	}
+	return nil
+}
+
+// Adicionar insere uma nova regra.
+func (r *repository) Adicionar(regra Regra) (Regra, error) {
+	err := r.db.Create(&regra).Error
+	return regra, err
+}
+
+// Listar retorna todas as regras, da menor para a maior tolerância de atraso.
+func (r *repository) Listar() ([]Regra, error) {
+	var regras []Regra
+	err := r.db.Order("dias_atraso, id").Find(&regras).Error
+	return regras, err
+}
+
+// ObterPorID busca uma regra pelo ID.
+func (r *repository) ObterPorID(id int) (*Regra, error) {
+	var regra Regra
+	if err := r.db.First(&regra, id).Error; err != nil {
+		return nil, errors.New("regra de escalonamento não encontrada")
+	}
+	return &regra, nil
+}
+
+// Atualizar substitui os dados de uma regra existente.
+func (r *repository) Atualizar(id int, updated Regra) (Regra, error) {
+	var regra Regra
+	if err := r.db.First(&regra, id).Error; err != nil {
+		return Regra{}, errors.New("regra de escalonamento não encontrada")
+	}
+	updated.ID = id
+	updated.CreatedAt = regra.CreatedAt
+	err := r.db.Model(&regra).Select("*").Omit("id", "created_at").Updates(updated).Error
+	return updated, err
+}
+
+// Deletar remove uma regra pelo ID. Os escalonamentos já registrados nas tarefas são mantidos.
+func (r *repository) Deletar(id int) error {
+	return r.db.Delete(&Regra{}, id).Error
+}
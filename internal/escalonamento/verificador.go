@@ -0,0 +1,203 @@
+package escalonamento
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"my-crm-backend/internal/notificacao"
+	"my-crm-backend/internal/tarefa"
+	"my-crm-backend/internal/usuario"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errJaAplicado desfaz a transação de um escalonamento que outra verificação
+// simultânea já registrou, para que a ação não seja executada duas vezes.
+var errJaAplicado = errors.New("escalonamento já aplicado")
+
+// Verificador marca as tarefas atrasadas e aplica as regras de escalonamento.
+type Verificador struct {
+	db       *gorm.DB
+	tarefas  tarefa.Repository
+	usuarios usuario.Repository
+}
+
+// NovoVerificador cria um verificador de atrasos.
+func NovoVerificador(db *gorm.DB, tarefas tarefa.Repository, usuarios usuario.Repository) *Verificador {
+	return &Verificador{db: db, tarefas: tarefas, usuarios: usuarios}
+}
+
+// Executar roda a verificação a cada intervalo até o contexto ser cancelado.
+func (v *Verificador) Executar(ctx context.Context, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+	for {
+		resultado, err := v.Verificar(time.Now())
+		if err != nil {
+			log.Printf("Erro na verificação de tarefas atrasadas: %v", err)
+		} else if resultado.Marcadas > 0 || len(resultado.Escalonamentos) > 0 {
+			log.Printf("Tarefas atrasadas: %d marcadas, %d liberadas, %d escalonamentos",
+				resultado.Marcadas, resultado.Liberadas, len(resultado.Escalonamentos))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Verificar atualiza o indicador de atraso das tarefas e aplica, uma única vez
+// por tarefa ou ocorrência de série, cada regra ativa cujo atraso mínimo já foi
+// atingido.
+func (v *Verificador) Verificar(agora time.Time) (Resultado, error) {
+	resultado := Resultado{Escalonamentos: []tarefa.Escalonamento{}}
+	var err error
+	resultado.Marcadas, resultado.Liberadas, err = v.tarefas.MarcarAtrasadas(agora)
+	if err != nil {
+		return resultado, err
+	}
+
+	var regras []Regra
+	if err := v.db.Where("NOT pausada").Order("dias_atraso, id").Find(&regras).Error; err != nil {
+		return resultado, err
+	}
+	for _, regra := range regras {
+		query := v.db.Preload("Ocorrencias").Preload("Escalonamentos", "regra_id = ?", regra.ID).
+			Where("atrasada").Order("fim, id")
+		if regra.Tipo != "" {
+			query = query.Where("tipo = ?", regra.Tipo)
+		}
+		var tarefas []tarefa.Tarefa
+		if err := query.Find(&tarefas).Error; err != nil {
+			return resultado, err
+		}
+		limite := agora.Add(-time.Duration(regra.DiasAtraso) * 24 * time.Hour)
+		for _, t := range tarefas {
+			// Numa série, o atraso e o escalonamento são os da ocorrência atrasada.
+			inst, ok := t.InstanciaAtrasada(agora)
+			if !ok || inst.Fim.After(limite) || escalonada(t.Escalonamentos, inst.Ocorrencia) {
+				continue
+			}
+			e, err := v.aplicar(regra, inst, agora)
+			if err != nil {
+				return resultado, fmt.Errorf("tarefa %d, regra %d: %w", t.ID, regra.ID, err)
+			}
+			if e != nil {
+				resultado.Escalonamentos = append(resultado.Escalonamentos, *e)
+			}
+		}
+	}
+	return resultado, nil
+}
+
+// escalonada informa se a regra já foi aplicada à tarefa ou à ocorrência.
+func escalonada(escalonamentos []tarefa.Escalonamento, ocorrencia *time.Time) bool {
+	for _, e := range escalonamentos {
+		if e.Ocorrencia == nil || ocorrencia == nil {
+			if e.Ocorrencia == ocorrencia {
+				return true
+			}
+			continue
+		}
+		if e.Ocorrencia.Equal(*ocorrencia) {
+			return true
+		}
+	}
+	return false
+}
+
+// aplicar executa a ação da regra sobre a tarefa, ou a ocorrência atrasada da
+// série, e registra o escalonamento. Retorna nil quando não havia a quem
+// notificar ou reatribuir, sem registrar o escalonamento para que a regra seja
+// tentada de novo na próxima verificação, ou quando outra verificação já o aplicou.
+func (v *Verificador) aplicar(regra Regra, t tarefa.Tarefa, agora time.Time) (*tarefa.Escalonamento, error) {
+	e := tarefa.Escalonamento{
+		TarefaID:   t.ID,
+		RegraID:    regra.ID,
+		Ocorrencia: t.Ocorrencia,
+		Acao:       regra.Acao,
+		DiasAtraso: int(agora.Sub(t.Fim) / (24 * time.Hour)),
+		Data:       agora,
+	}
+	destino := regra.Destino
+	if destino == "" {
+		if u, err := v.usuarios.ObterPorLogin(t.Responsavel); err == nil {
+			destino = u.Gestor
+		}
+	}
+
+	switch regra.Acao {
+	case tarefa.AcaoNotificarGestor:
+		if destino == "" {
+			return nil, nil
+		}
+	case tarefa.AcaoReatribuir:
+		if destino == "" || destino == t.Responsavel {
+			return nil, nil
+		}
+	}
+
+	err := v.db.Transaction(func(tx *gorm.DB) error {
+		switch regra.Acao {
+		case tarefa.AcaoNotificarGestor:
+			e.Detalhes = "notificado: " + destino
+			if err := notificacao.Notificar(tx, notificacao.Notificacao{
+				Usuario:    destino,
+				Tipo:       "tarefa_atrasada",
+				Titulo:     fmt.Sprintf("Tarefa atrasada há %d dia(s): %s", e.DiasAtraso, t.Assunto),
+				Mensagem:   fmt.Sprintf("Responsável: %s. Negociação: %s.", t.Responsavel, t.Negociacao),
+				Entidade:   "tarefa",
+				EntidadeID: t.ID,
+			}); err != nil {
+				return err
+			}
+		case tarefa.AcaoReatribuir:
+			e.Detalhes = fmt.Sprintf("reatribuída de %s para %s", t.Responsavel, destino)
+			if err := tx.Model(&tarefa.Tarefa{}).Where("id = ?", t.ID).Update("responsavel", destino).Error; err != nil {
+				return err
+			}
+			for _, n := range []notificacao.Notificacao{
+				{Usuario: destino, Tipo: "tarefa_reatribuida", Titulo: "Tarefa atrasada reatribuída a você: " + t.Assunto},
+				{Usuario: t.Responsavel, Tipo: "tarefa_reatribuida", Titulo: "Tarefa atrasada reatribuída a " + destino + ": " + t.Assunto},
+			} {
+				if n.Usuario == "" {
+					continue
+				}
+				n.Entidade, n.EntidadeID = "tarefa", t.ID
+				if err := notificacao.Notificar(tx, n); err != nil {
+					return err
+				}
+			}
+		case tarefa.AcaoAumentarPrioridade:
+			atual := t.Prioridade
+			if atual == "" {
+				atual = tarefa.PrioridadeNormal
+			}
+			nova := tarefa.ProximaPrioridade(atual)
+			e.Detalhes = fmt.Sprintf("prioridade de %s para %s", atual, nova)
+			if err := tx.Model(&tarefa.Tarefa{}).Where("id = ?", t.ID).Update("prioridade", nova).Error; err != nil {
+				return err
+			}
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&e)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errJaAplicado
+		}
+		return nil
+	})
+	if errors.Is(err, errJaAplicado) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
@@ -0,0 +1,169 @@
+package evento
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Tratador processa um evento dentro da transação informada. Um erro desfaz
+// apenas o que o tratador gravou, e o evento volta a ser entregue a ele depois.
+type Tratador func(tx *gorm.DB, e Evento) error
+
+type assinatura struct {
+	nome   string
+	tipos  []string
+	tratar Tratador
+}
+
+func (a assinatura) recebe(tipo string) bool {
+	if len(a.tipos) == 0 {
+		return true
+	}
+	for _, t := range a.tipos {
+		if t == tipo {
+			return true
+		}
+	}
+	return false
+}
+
+// Barramento entrega os eventos da caixa de saída aos assinantes do processo,
+// pelo menos uma vez e na ordem de publicação; um evento com falha é adiado sem
+// bloquear os seguintes. Cada tratamento é confirmado na mesma transação que
+// registra o consumo, então os efeitos gravados no banco por um assinante
+// acontecem uma única vez.
+type Barramento struct {
+	db            *gorm.DB
+	assinaturas   []assinatura
+	maxTentativas int
+}
+
+// NovoBarramento cria o barramento de eventos. Um evento cujos assinantes
+// falham maxTentativas vezes é marcado como falhou (padrão: 10).
+func NovoBarramento(db *gorm.DB, maxTentativas int) *Barramento {
+	if maxTentativas <= 0 {
+		maxTentativas = 10
+	}
+	return &Barramento{db: db, maxTentativas: maxTentativas}
+}
+
+// Assinar registra um tratador para os tipos de evento informados (todos, se
+// nenhum for informado). O nome identifica o consumo e deve ser único e estável
+// entre reinícios. Deve ser chamado antes de Executar.
+func (b *Barramento) Assinar(nome string, tratar Tratador, tipos ...string) {
+	b.assinaturas = append(b.assinaturas, assinatura{nome: nome, tipos: tipos, tratar: tratar})
+}
+
+// Executar processa a fila a cada intervalo até o contexto ser cancelado.
+func (b *Barramento) Executar(ctx context.Context, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+	for {
+		if _, err := b.Processar(ctx); err != nil {
+			log.Printf("Erro no despacho de eventos: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Processar entrega os eventos pendentes até esvaziar a fila ou o contexto
+// ser cancelado e retorna quantos foram processados.
+func (b *Barramento) Processar(ctx context.Context) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		achou, err := b.processarProximo(time.Now())
+		if err != nil || !achou {
+			return total, err
+		}
+		total++
+	}
+	return total, nil
+}
+
+// processarProximo trava o próximo evento pendente e o entrega aos assinantes
+// que ainda não o consumiram, cada um em um savepoint próprio.
+func (b *Barramento) processarProximo(agora time.Time) (bool, error) {
+	achou := false
+	err := b.db.Transaction(func(tx *gorm.DB) error {
+		var e Evento
+		// SKIP LOCKED permite várias instâncias da API dividindo a mesma fila.
+		res := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND proxima_tentativa <= ?", StatusPendente, agora).
+			Order("id").Limit(1).Find(&e)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		achou = true
+
+		var consumidos []string
+		if err := tx.Model(&Consumo{}).Where("evento_id = ?", e.ID).Pluck("assinante", &consumidos).Error; err != nil {
+			return err
+		}
+		var falhas []string
+		for _, a := range b.assinaturas {
+			if !a.recebe(e.Tipo) || contem(consumidos, a.nome) {
+				continue
+			}
+			err := tx.Transaction(func(sp *gorm.DB) error {
+				if err := a.tratar(sp, e); err != nil {
+					return err
+				}
+				return sp.Create(&Consumo{EventoID: e.ID, Assinante: a.nome, Data: agora}).Error
+			})
+			if err != nil {
+				log.Printf("Evento %d (%s): assinante %s falhou: %v", e.ID, e.Tipo, a.nome, err)
+				falhas = append(falhas, fmt.Sprintf("%s: %v", a.nome, err))
+			}
+		}
+
+		if len(falhas) == 0 {
+			return tx.Model(&e).Updates(map[string]interface{}{
+				"status":        StatusProcessado,
+				"processado_em": agora,
+				"ultimo_erro":   "",
+			}).Error
+		}
+		campos := map[string]interface{}{
+			"tentativas":        e.Tentativas + 1,
+			"ultimo_erro":       strings.Join(falhas, "; "),
+			"proxima_tentativa": agora.Add(espera(e.Tentativas + 1)),
+		}
+		if e.Tentativas+1 >= b.maxTentativas {
+			campos["status"] = StatusFalhou
+		}
+		return tx.Model(&e).Updates(campos).Error
+	})
+	return achou, err
+}
+
+// espera calcula o intervalo até a próxima tentativa depois de n falhas:
+// 5s, 10s, 20s... limitado a 1h.
+func espera(n int) time.Duration {
+	d := 5 * time.Second
+	for i := 1; i < n && d < time.Hour; i++ {
+		d *= 2
+	}
+	if d > time.Hour {
+		d = time.Hour
+	}
+	return d
+}
+
+func contem(lista []string, valor string) bool {
+	for _, v := range lista {
+		if v == valor {
+			return true
+		}
+	}
+	return false
+}
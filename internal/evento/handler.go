@@ -0,0 +1,78 @@
+package evento
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler define os manipuladores HTTP para consulta da caixa de saída de eventos.
+type Handler struct {
+	repo Repository
+}
+
+// NovoHandler cria e retorna um novo handler de eventos.
+func NovoHandler(repo Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+// Listar retorna os eventos mais recentes.
+// Aceita os filtros opcionais ?tipo=negociacao.ganha&status=falhou&entidade=negociacao&entidade_id=1.
+func (h *Handler) Listar(c *gin.Context) {
+	filtro := Filtro{
+		Tipo:     c.Query("tipo"),
+		Status:   c.Query("status"),
+		Entidade: c.Query("entidade"),
+	}
+	if v := c.Query("entidade_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "entidade_id inválido"})
+			return
+		}
+		filtro.EntidadeID = id
+	}
+	eventos, err := h.repo.Listar(filtro)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, eventos)
+}
+
+// ListarTipos retorna os tipos de eventos de domínio.
+func (h *Handler) ListarTipos(c *gin.Context) {
+	c.JSON(http.StatusOK, Tipos)
+}
+
+// Obter retorna um evento com os assinantes que já o consumiram.
+func (h *Handler) Obter(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	e, err := h.repo.ObterPorID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, e)
+}
+
+// Reprocessar devolve um evento à fila, por exemplo depois de corrigida a
+// causa das falhas de um assinante.
+func (h *Handler) Reprocessar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	e, err := h.repo.Reprocessar(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, e)
+}
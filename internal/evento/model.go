@@ -0,0 +1,95 @@
+package evento
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Tipos de eventos de domínio publicados pelos repositórios.
+const (
+	NegociacaoCriada                 = "negociacao.criada"
+	NegociacaoAtualizada             = "negociacao.atualizada"
+	NegociacaoEtapaAlterada          = "negociacao.etapa_alterada"
+	NegociacaoStatusAlterado         = "negociacao.status_alterado"
+	NegociacaoValoresAlterados       = "negociacao.valores_alterados"
+	NegociacaoGanha                  = "negociacao.ganha"
+	NegociacaoPerdida                = "negociacao.perdida"
+	NegociacaoParticipantesAlterados = "negociacao.participantes_alterados"
+	TarefaCriada                     = "tarefa.criada"
+	TarefaConcluida                  = "tarefa.concluida"
+	TarefaAtrasada                   = "tarefa.atrasada"
+	EmpresaCriada                    = "empresa.criada"
+	EmpresaAtualizada                = "empresa.atualizada"
+	AutomacaoDisparada               = "automacao.disparada"
+)
+
+// Tipos lista todos os tipos de eventos de domínio.
+var Tipos = []string{
+	NegociacaoCriada, NegociacaoAtualizada, NegociacaoEtapaAlterada, NegociacaoStatusAlterado, NegociacaoValoresAlterados,
+	NegociacaoGanha, NegociacaoPerdida, NegociacaoParticipantesAlterados,
+	TarefaCriada, TarefaConcluida, TarefaAtrasada,
+	EmpresaCriada, EmpresaAtualizada,
+	AutomacaoDisparada,
+}
+
+// TipoValido informa se o tipo é um evento de domínio conhecido.
+func TipoValido(tipo string) bool {
+	for _, t := range Tipos {
+		if t == tipo {
+			return true
+		}
+	}
+	return false
+}
+
+// Situações de um evento na caixa de saída.
+const (
+	StatusPendente   = "pendente"   // Aguardando entrega aos assinantes
+	StatusProcessado = "processado" // Todos os assinantes o trataram
+	StatusFalhou     = "falhou"     // Esgotou as tentativas; pode ser reprocessado
+)
+
+// Evento é um fato de domínio gravado na caixa de saída (outbox) na mesma
+// transação da alteração que o originou. O despachante o entrega depois aos
+// assinantes do barramento.
+type Evento struct {
+	ID         int            `json:"id" gorm:"primaryKey;autoIncrement"`
+	Tipo       string         `json:"tipo" gorm:"index;not null"`
+	Entidade   string         `json:"entidade" gorm:"index:idx_evento_entidade"`
+	EntidadeID int            `json:"entidade_id" gorm:"index:idx_evento_entidade"`
+	Usuario    string         `json:"usuario,omitempty"`
+	Dados      datatypes.JSON `json:"dados"`
+
+	// Regras de automação cuja execução gerou o evento, da primeira à última;
+	// vazio quando o evento veio de uma operação do usuário.
+	Cadeia datatypes.JSONSlice[int] `json:"cadeia,omitempty"`
+
+	Status           string     `json:"status" gorm:"index:idx_evento_fila,priority:1;not null;default:pendente"`
+	ProximaTentativa time.Time  `json:"proxima_tentativa" gorm:"index:idx_evento_fila,priority:2"`
+	Tentativas       int        `json:"tentativas"`
+	UltimoErro       string     `json:"ultimo_erro,omitempty"`
+	ProcessadoEm     *time.Time `json:"processado_em,omitempty"`
+	Consumos         []Consumo  `json:"consumos,omitempty" gorm:"foreignKey:EventoID"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Evento) TableName() string {
+	return "eventos_outbox"
+}
+
+// Consumo registra que um assinante tratou o evento. É gravado na mesma
+// transação do tratamento, de modo que um evento reprocessado não é tratado de
+// novo por quem já o consumiu.
+type Consumo struct {
+	ID        int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	EventoID  int       `json:"evento_id" gorm:"uniqueIndex:idx_consumo_assinante;not null"`
+	Assinante string    `json:"assinante" gorm:"uniqueIndex:idx_consumo_assinante;size:100;not null"`
+	Data      time.Time `json:"data"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Consumo) TableName() string {
+	return "evento_consumos"
+}
@@ -0,0 +1,120 @@
+package evento
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrEventoNaoEncontrado indica que o evento não existe na caixa de saída.
+var ErrEventoNaoEncontrado = errors.New("evento não encontrado")
+
+type chaveCadeia struct{}
+
+// ComCadeia retorna a conexão marcada com a cadeia de regras de automação em
+// execução. Os eventos publicados por ela registram a cadeia, o que permite às
+// automações detectar laços.
+func ComCadeia(tx *gorm.DB, cadeia []int) *gorm.DB {
+	return tx.WithContext(context.WithValue(tx.Statement.Context, chaveCadeia{}, cadeia))
+}
+
+// Publicar grava o evento na caixa de saída usando a conexão (ou transação)
+// informada. Publicado dentro da transação da alteração, o evento só existe se
+// ela for confirmada, e é entregue aos assinantes pelo menos uma vez.
+func Publicar(tx *gorm.DB, tipo, entidade string, entidadeID int, usuario string, dados interface{}) error {
+	e := Evento{
+		Tipo:             tipo,
+		Entidade:         entidade,
+		EntidadeID:       entidadeID,
+		Usuario:          usuario,
+		Status:           StatusPendente,
+		ProximaTentativa: time.Now(),
+	}
+	if cadeia, ok := tx.Statement.Context.Value(chaveCadeia{}).([]int); ok {
+		e.Cadeia = cadeia
+	}
+	if dados != nil {
+		b, err := json.Marshal(dados)
+		if err != nil {
+			return err
+		}
+		e.Dados = b
+	}
+	return tx.Create(&e).Error
+}
+
+// Filtro restringe a listagem de eventos.
+type Filtro struct {
+	Tipo       string
+	Status     string
+	Entidade   string
+	EntidadeID int
+}
+
+// Repository define as operações de consulta e reprocessamento da caixa de saída.
+type Repository interface {
+	Listar(filtro Filtro) ([]Evento, error)
+	ObterPorID(id int) (*Evento, error)
+	Reprocessar(id int) (Evento, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NovoRepositorio cria e retorna um repositório baseado em GORM.
+func NovoRepositorio(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Listar retorna os últimos 200 eventos que atendem ao filtro, do mais recente ao mais antigo.
+func (r *repository) Listar(filtro Filtro) ([]Evento, error) {
+	eventos := []Evento{}
+	query := r.db.Order("id DESC").Limit(200)
+	if filtro.Tipo != "" {
+		query = query.Where("tipo = ?", filtro.Tipo)
+	}
+	if filtro.Status != "" {
+		query = query.Where("status = ?", filtro.Status)
+	}
+	if filtro.Entidade != "" {
+		query = query.Where("entidade = ?", filtro.Entidade)
+	}
+	if filtro.EntidadeID != 0 {
+		query = query.Where("entidade_id = ?", filtro.EntidadeID)
+	}
+	err := query.Find(&eventos).Error
+	return eventos, err
+}
+
+// ObterPorID busca um evento com os consumos já registrados.
+func (r *repository) ObterPorID(id int) (*Evento, error) {
+	var e Evento
+	if err := r.db.Preload("Consumos").First(&e, id).Error; err != nil {
+		return nil, ErrEventoNaoEncontrado
+	}
+	return &e, nil
+}
+
+// Reprocessar devolve o evento à fila para nova entrega imediata. Os
+// assinantes que já o consumiram não o recebem de novo.
+func (r *repository) Reprocessar(id int) (Evento, error) {
+	var e Evento
+	if err := r.db.First(&e, id).Error; err != nil {
+		return Evento{}, ErrEventoNaoEncontrado
+	}
+	err := r.db.Model(&e).Updates(map[string]interface{}{
+		"status":            StatusPendente,
+		"proxima_tentativa": time.Now(),
+		"tentativas":        0,
+		"processado_em":     nil,
+	}).Error
+	if err != nil {
+		return Evento{}, err
+	}
+	err = r.db.First(&e, id).Error
+	return e, err
+}
@@ -0,0 +1,147 @@
+package grupoeconomico
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler define os manipuladores HTTP para as operações de grupo econômico.
+type Handler struct {
+	repo Repository
+}
+
+// NovoHandler cria e retorna um novo handler para GrupoEconomico.
+func NovoHandler(repo Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+// Criar insere um novo grupo econômico.
+func (h *Handler) Criar(c *gin.Context) {
+	var g GrupoEconomico
+	if err := c.ShouldBindJSON(&g); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if g.Nome == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nome é obrigatório"})
+		return
+	}
+	criado, err := h.repo.Adicionar(g)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrNomeDuplicado) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, criado)
+}
+
+// Listar retorna todos os grupos econômicos.
+func (h *Handler) Listar(c *gin.Context) {
+	grupos, err := h.repo.Listar()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, grupos)
+}
+
+// Obter retorna um grupo econômico pelo ID.
+func (h *Handler) Obter(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	g, err := h.repo.ObterPorID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, g)
+}
+
+// Atualizar modifica um grupo econômico existente.
+func (h *Handler) Atualizar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	var g GrupoEconomico
+	if err := c.ShouldBindJSON(&g); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	atualizado, err := h.repo.Atualizar(id, g)
+	if err != nil {
+		status := http.StatusNotFound
+		if errors.Is(err, ErrNomeDuplicado) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, atualizado)
+}
+
+// Deletar remove um grupo econômico pelo ID.
+func (h *Handler) Deletar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	if err := h.repo.Deletar(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// AdicionarEmpresa inclui uma empresa no grupo: POST /api/grupos/:id/empresas/:empresaId
+func (h *Handler) AdicionarEmpresa(c *gin.Context) {
+	id, empresaID, ok := lerIDs(c)
+	if !ok {
+		return
+	}
+	g, err := h.repo.AdicionarEmpresa(id, empresaID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, g)
+}
+
+// RemoverEmpresa retira uma empresa do grupo: DELETE /api/grupos/:id/empresas/:empresaId
+func (h *Handler) RemoverEmpresa(c *gin.Context) {
+	id, empresaID, ok := lerIDs(c)
+	if !ok {
+		return
+	}
+	g, err := h.repo.RemoverEmpresa(id, empresaID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, g)
+}
+
+func lerIDs(c *gin.Context) (int, int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return 0, 0, false
+	}
+	empresaID, err := strconv.Atoi(c.Param("empresaId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empresaId inválido"})
+		return 0, 0, false
+	}
+	return id, empresaID, true
+}
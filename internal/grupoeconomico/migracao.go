@@ -0,0 +1,48 @@
+package grupoeconomico
+
+import (
+	"strings"
+
+	"my-crm-backend/internal/empresa"
+
+	"gorm.io/gorm"
+)
+
+// MigrarGruposLegados converte o antigo campo texto "grupo" das empresas em
+// registros de GrupoEconômico, vincula as empresas e remove a coluna antiga.
+// É seguro executar a cada inicialização: sem a coluna, nada é feito.
+func MigrarGruposLegados(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&empresa.Empresa{}, "grupo") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		var nomes []string
+		if err := tx.Model(&empresa.Empresa{}).
+			Where("grupo IS NOT NULL AND TRIM(grupo) <> ''").
+			Distinct().
+			Pluck("TRIM(grupo)", &nomes).Error; err != nil {
+			return err
+		}
+		for _, nome := range nomes {
+			var g GrupoEconomico
+			if err := tx.Where("LOWER(nome) = ?", strings.ToLower(nome)).FirstOrCreate(&g, GrupoEconomico{Nome: nome}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&empresa.Empresa{}).
+				Where("TRIM(grupo) = ? AND grupo_economico_id IS NULL", nome).
+				Update("grupo_economico_id", g.ID).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Migrator().DropColumn(&empresa.Empresa{}, "grupo")
+	})
+}
+
+// MigrarIndiceNome troca o índice único do nome, que incluía os grupos
+// removidos, pelo índice parcial dos grupos ativos. É idempotente.
+func MigrarIndiceNome(db *gorm.DB) error {
+	if !db.Migrator().HasIndex(&GrupoEconomico{}, "idx_grupos_economicos_nome") {
+		return nil
+	}
+	return db.Migrator().DropIndex(&GrupoEconomico{}, "idx_grupos_economicos_nome")
+}
@@ -0,0 +1,26 @@
+package grupoeconomico
+
+import (
+	"time"
+
+	"my-crm-backend/internal/empresa"
+
+	"gorm.io/gorm"
+)
+
+// GrupoEconomico reúne empresas que pertencem a um mesmo conglomerado.
+type GrupoEconomico struct {
+	ID        int               `json:"id" gorm:"primaryKey;autoIncrement"`
+	Nome      string            `json:"nome" gorm:"uniqueIndex:idx_grupo_nome_ativo,where:deleted_at IS NULL"`
+	Descricao string            `json:"descricao,omitempty"`
+	Empresas  []empresa.Empresa `json:"empresas,omitempty" gorm:"foreignKey:GrupoEconomicoID"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (GrupoEconomico) TableName() string {
+	return "grupos_economicos"
+}
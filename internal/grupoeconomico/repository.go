@@ -0,0 +1,115 @@
+package grupoeconomico
+
+import (
+	"errors"
+
+	"my-crm-backend/internal/empresa"
+
+	"gorm.io/gorm"
+)
+
+// Repository define as operações básicas para manipular grupos econômicos.
+type Repository interface {
+	Adicionar(g GrupoEconomico) (GrupoEconomico, error)
+	Listar() ([]GrupoEconomico, error)
+	ObterPorID(id int) (*GrupoEconomico, error)
+	Atualizar(id int, updated GrupoEconomico) (GrupoEconomico, error)
+	Deletar(id int) error
+	AdicionarEmpresa(id, empresaID int) (GrupoEconomico, error)
+	RemoverEmpresa(id, empresaID int) (GrupoEconomico, error)
+}
+
+// ErrNomeDuplicado indica que já existe um grupo econômico ativo com o nome.
+var ErrNomeDuplicado = errors.New("já existe um grupo econômico com esse nome")
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NovoRepositorio cria e retorna um repositório baseado em GORM.
+func NovoRepositorio(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Adicionar insere um novo grupo econômico.
+func (r *repository) Adicionar(g GrupoEconomico) (GrupoEconomico, error) {
+	g.Empresas = nil
+	err := r.db.Create(&g).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return GrupoEconomico{}, ErrNomeDuplicado
+	}
+	return g, err
+}
+
+// Listar retorna todos os grupos econômicos com suas empresas.
+func (r *repository) Listar() ([]GrupoEconomico, error) {
+	var grupos []GrupoEconomico
+	err := r.db.Preload("Empresas").Order("nome").Find(&grupos).Error
+	return grupos, err
+}
+
+// ObterPorID busca um grupo econômico pelo ID, incluindo as empresas.
+func (r *repository) ObterPorID(id int) (*GrupoEconomico, error) {
+	var g GrupoEconomico
+	if err := r.db.Preload("Empresas").First(&g, id).Error; err != nil {
+		return nil, errors.New("grupo econômico não encontrado")
+	}
+	return &g, nil
+}
+
+// Atualizar modifica o nome e a descrição de um grupo econômico.
+func (r *repository) Atualizar(id int, updated GrupoEconomico) (GrupoEconomico, error) {
+	var g GrupoEconomico
+	if err := r.db.First(&g, id).Error; err != nil {
+		return GrupoEconomico{}, errors.New("grupo econômico não encontrado")
+	}
+	updated.ID = id
+	updated.Empresas = nil
+	err := r.db.Model(&g).Updates(updated).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return GrupoEconomico{}, ErrNomeDuplicado
+	}
+	return updated, err
+}
+
+// Deletar remove o grupo econômico e desvincula as suas empresas.
+func (r *repository) Deletar(id int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&empresa.Empresa{}).Where("grupo_economico_id = ?", id).Update("grupo_economico_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&GrupoEconomico{}, id).Error
+	})
+}
+
+// AdicionarEmpresa inclui a empresa no grupo econômico.
+func (r *repository) AdicionarEmpresa(id, empresaID int) (GrupoEconomico, error) {
+	return r.alterarEmpresa(id, empresaID, &id)
+}
+
+// RemoverEmpresa retira a empresa do grupo econômico.
+func (r *repository) RemoverEmpresa(id, empresaID int) (GrupoEconomico, error) {
+	return r.alterarEmpresa(id, empresaID, nil)
+}
+
+func (r *repository) alterarEmpresa(id, empresaID int, grupoID *int) (GrupoEconomico, error) {
+	if _, err := r.ObterPorID(id); err != nil {
+		return GrupoEconomico{}, err
+	}
+	query := r.db.Model(&empresa.Empresa{}).Where("id = ?", empresaID)
+	if grupoID == nil {
+		query = query.Where("grupo_economico_id = ?", id)
+	}
+	res := query.Update("grupo_economico_id", grupoID)
+	if res.Error != nil {
+		return GrupoEconomico{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return GrupoEconomico{}, errors.New("empresa not found")
+	}
+	g, err := r.ObterPorID(id)
+	if err != nil {
+		return GrupoEconomico{}, err
+	}
+	return *g, nil
+}
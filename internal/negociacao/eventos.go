@@ -0,0 +1,47 @@
+package negociacao
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"my-crm-backend/internal/evento"
+	"my-crm-backend/internal/notificacao"
+
+	"gorm.io/gorm"
+)
+
+// NotificarEncerramento avisa o responsável quando outra pessoa marca a
+// negociação como ganha ou perdida. É registrado como assinante do barramento
+// de eventos para negociacao.ganha e negociacao.perdida.
+func NotificarEncerramento(tx *gorm.DB, e evento.Evento) error {
+	var dados struct {
+		Negociacao struct {
+			ID             int    `json:"id"`
+			NomeNegociacao string `json:"nome_negociacao"`
+			Responsavel    string `json:"responsavel"`
+		} `json:"negociacao"`
+	}
+	if err := json.Unmarshal(e.Dados, &dados); err != nil {
+		return err
+	}
+	n := dados.Negociacao
+	if n.Responsavel == "" || n.Responsavel == e.Usuario {
+		return nil
+	}
+	tipo, situacao := "negociacao_ganha", "ganha"
+	if e.Tipo == evento.NegociacaoPerdida {
+		tipo, situacao = "negociacao_perdida", "perdida"
+	}
+	mensagem := ""
+	if e.Usuario != "" {
+		mensagem = fmt.Sprintf("Marcada como %s por %s.", situacao, e.Usuario)
+	}
+	return notificacao.Notificar(tx, notificacao.Notificacao{
+		Usuario:    n.Responsavel,
+		Tipo:       tipo,
+		Titulo:     fmt.Sprintf("Negociação %s: %s", situacao, n.NomeNegociacao),
+		Mensagem:   mensagem,
+		Entidade:   "negociacao",
+		EntidadeID: n.ID,
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"time"
 
+	"my-crm-backend/internal/campopersonalizado"
 	"my-crm-backend/internal/tarefa"
 
 	"github.com/gin-gonic/gin"
@@ -12,12 +13,14 @@ import (
 
 // Handler define os manipuladores HTTP para as operações de negociação.
 type Handler struct {
-	repo Repository
+	repo   Repository
+	campos campopersonalizado.Validador
+	fusos  tarefa.Fusos
 }
 
 // NovoHandler cria e retorna um novo handler para negociação.
-func NovoHandler(repo Repository) *Handler {
-	return &Handler{repo: repo}
+func NovoHandler(repo Repository, campos campopersonalizado.Validador, fusos tarefa.Fusos) *Handler {
+	return &Handler{repo: repo, campos: campos, fusos: fusos}
 }
 
 // CriarNegociacao cria uma nova negociação.
@@ -27,6 +30,12 @@ func (h *Handler) CriarNegociacao(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	campos, err := h.campos.Validar(campopersonalizado.EntidadeNegociacao, n.CamposPersonalizados)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	n.CamposPersonalizados = campos
 	negociacaoCriada, err := h.repo.Adicionar(n)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -36,8 +45,40 @@ func (h *Handler) CriarNegociacao(c *gin.Context) {
 }
 
 // ListarNegociacoes retorna todas as negociações.
+// Aceita filtros e ordenação por campos personalizados (ex.: ?cp.produto=frota&ordenar=cp.renovacao)
+// e pela pontuação (ex.: ?pontuacao_min=40&ordenar=-pontuacao).
 func (h *Handler) ListarNegociacoes(c *gin.Context) {
-	negociacoes, err := h.repo.Listar()
+	defs, err := h.campos.Definicoes(campopersonalizado.EntidadeNegociacao)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var filtro Filtro
+	filtro.Campos, err = campopersonalizado.LerFiltro(c.Request.URL.Query(), defs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for param, destino := range map[string]**int{
+		"pontuacao_min": &filtro.PontuacaoMinima,
+		"pontuacao_max": &filtro.PontuacaoMaxima,
+	} {
+		if v := c.Query(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": param + " inválido"})
+				return
+			}
+			*destino = &n
+		}
+	}
+	switch c.Query("ordenar") {
+	case "pontuacao":
+		filtro.OrdenarPorPontuacao = true
+	case "-pontuacao":
+		filtro.OrdenarPorPontuacao, filtro.Decrescente = true, true
+	}
+	negociacoes, err := h.repo.Listar(filtro)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -72,6 +113,14 @@ func (h *Handler) AtualizarNegociacao(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if len(updated.CamposPersonalizados) > 0 {
+		campos, err := h.campos.Validar(campopersonalizado.EntidadeNegociacao, updated.CamposPersonalizados)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updated.CamposPersonalizados = campos
+	}
 	negociacaoAtualizada, err := h.repo.Atualizar(id, updated)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -94,7 +143,10 @@ func (h *Handler) DeletarNegociacao(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
-// AdicionarTarefaHandler adiciona uma nova tarefa a uma negociação.
+// AdicionarTarefaHandler cria uma tarefa na negociação. Empresa e nomes da
+// negociação e da empresa são derivados da negociação; o responsável, se omitido,
+// é o da negociação. Espera receber um JSON como:
+// {"assunto": "Ligar", "tipo": "Ligação", "inicio_local": "2024-05-10T14:00", "duracao_minutos": 30}
 func (h *Handler) AdicionarTarefaHandler(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id")) // ID da Negociação
 	if err != nil {
@@ -106,12 +158,41 @@ func (h *Handler) AdicionarTarefaHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
 		return
 	}
-	negociacaoAtualizada, err := h.repo.AdicionarTarefa(id, novaTarefa)
+	n, err := h.repo.ObterPorID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, negociacaoAtualizada)
+	n.VincularTarefa(&novaTarefa)
+	if novaTarefa.Assunto == "" || novaTarefa.Tipo == "" || novaTarefa.Responsavel == "" || (novaTarefa.Inicio.IsZero() && novaTarefa.InicioLocal == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Campos obrigatórios: Assunto, Tipo, Inicio (ou InicioLocal) e Responsavel (se a negociação não tiver um)"})
+		return
+	}
+	if err := tarefa.Preparar(&novaTarefa, h.fusos); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	criada, err := h.repo.AdicionarTarefa(id, novaTarefa)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, criada)
+}
+
+// ListarTarefasHandler retorna as tarefas de uma negociação.
+func (h *Handler) ListarTarefasHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	tarefas, err := h.repo.ListarTarefas(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tarefas)
 }
 
 // AtualizarFunilHandler atualiza a etapa do funil de vendas e registra o histórico da alteração.
@@ -140,7 +221,7 @@ func (h *Handler) AtualizarFunilHandler(c *gin.Context) {
 }
 
 // AtualizarStatusHandler atualiza o campo Status da negociação.
-// Espera receber um JSON com: {"status": "novo status"}
+// Espera receber um JSON com: {"status": "novo status", "usuario": "login"}
 func (h *Handler) AtualizarStatusHandler(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -149,14 +230,15 @@ func (h *Handler) AtualizarStatusHandler(c *gin.Context) {
 	}
 
 	var payload struct {
-		Status string `json:"status"`
+		Status  string `json:"status"`
+		Usuario string `json:"usuario"`
 	}
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
 		return
 	}
 
-	atualizado, err := h.repo.AtualizarStatus(id, payload.Status)
+	atualizado, err := h.repo.AtualizarStatus(id, payload.Status, payload.Usuario)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -166,7 +248,7 @@ func (h *Handler) AtualizarStatusHandler(c *gin.Context) {
 }
 
 // AtualizarValoresHandler atualiza os campos ValorNegociacao e PrevisaoFechamento da negociação.
-// Espera receber um JSON com: {"valor_negociacao": 123.45, "previsao_fechamento": "2025-12-31T23:59:59Z"}
+// Espera receber um JSON com: {"valor_negociacao": 123.45, "previsao_fechamento": "2025-12-31T23:59:59Z", "usuario": "login"}
 func (h *Handler) AtualizarValoresHandler(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -177,13 +259,14 @@ func (h *Handler) AtualizarValoresHandler(c *gin.Context) {
 	var payload struct {
 		ValorNegociacao    float64   `json:"valor_negociacao"`
 		PrevisaoFechamento time.Time `json:"previsao_fechamento"`
+		Usuario            string    `json:"usuario"`
 	}
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
 		return
 	}
 
-	atualizado, err := h.repo.AtualizarValores(id, payload.ValorNegociacao, payload.PrevisaoFechamento)
+	atualizado, err := h.repo.AtualizarValores(id, payload.ValorNegociacao, payload.PrevisaoFechamento, payload.Usuario)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -191,3 +274,62 @@ func (h *Handler) AtualizarValoresHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, atualizado)
 }
+
+// ListarParticipantesHandler retorna os contatos envolvidos na negociação.
+func (h *Handler) ListarParticipantesHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	participantes, err := h.repo.ListarParticipantes(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, participantes)
+}
+
+// AdicionarParticipanteHandler inclui um contato na negociação ou altera o seu papel.
+// Espera receber um JSON com: {"contato_id": 1, "papel": "decisor"}
+// Papéis aceitos: decisor, influenciador, financeiro, usuario.
+func (h *Handler) AdicionarParticipanteHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	var payload struct {
+		ContatoID int    `json:"contato_id"`
+		Papel     string `json:"papel"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil || payload.ContatoID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
+		return
+	}
+	participante, err := h.repo.AdicionarParticipante(id, payload.ContatoID, payload.Papel)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, participante)
+}
+
+// RemoverParticipanteHandler retira um contato da negociação.
+func (h *Handler) RemoverParticipanteHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	contatoID, err := strconv.Atoi(c.Param("contatoId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "contatoId inválido"})
+		return
+	}
+	if err := h.repo.RemoverParticipante(id, contatoID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,35 @@
+package negociacao
+
+import "gorm.io/gorm"
+
+// SincronizarTarefas corrige, nas tarefas vinculadas a negociações, a empresa e os
+// nomes desnormalizados da negociação e da empresa. É idempotente.
+func SincronizarTarefas(db *gorm.DB) (int64, error) {
+	res := db.Exec(`
+		UPDATE tarefas t
+		SET negociacao = n.nome_negociacao,
+		    empresa_id = n.empresa_id,
+		    empresa_negociacao = COALESCE(e.nome, '')
+		FROM negociacoes n
+		LEFT JOIN empresas e ON e.id = n.empresa_id
+		WHERE t.negociacao_id = n.id
+		  AND (t.negociacao IS DISTINCT FROM n.nome_negociacao
+		       OR t.empresa_id IS DISTINCT FROM n.empresa_id
+		       OR t.empresa_negociacao IS DISTINCT FROM COALESCE(e.nome, ''))`)
+	return res.RowsAffected, res.Error
+}
+
+// MigrarParticipantes registra o contato principal das negociações existentes como
+// participante. É idempotente: participações já registradas são mantidas.
+func MigrarParticipantes(db *gorm.DB) error {
+	return db.Exec(`
+		INSERT INTO negociacao_contatos (negociacao_id, contato_id, papel, created_at, updated_at)
+		SELECT n.id, n.contato_id,
+		       CASE WHEN c.e_decisor THEN ? ELSE ? END,
+		       NOW(), NOW()
+		FROM negociacoes n
+		JOIN contatos c ON c.id = n.contato_id AND c.deleted_at IS NULL
+		WHERE n.deleted_at IS NULL AND n.contato_id <> 0
+		ON CONFLICT (negociacao_id, contato_id) DO NOTHING`,
+		PapelDecisor, PapelInfluenciador).Error
+}
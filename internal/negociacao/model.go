@@ -8,6 +8,7 @@ import (
 	"my-crm-backend/internal/historicoetapa"
 	"my-crm-backend/internal/tarefa"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -20,14 +21,19 @@ type Negociacao struct {
 	ContatoID             int             `json:"contato_id"`
 	Contato               contato.Contato `json:"contato"`
 	NomeNegociacao        string          `json:"nome_negociacao"`
+	Responsavel           string          `json:"responsavel,omitempty"` // Login do dono da negociação
 	FunilVendas           string          `json:"funil_vendas"`
 	EtapaFunilVendas      string          `json:"etapa_funil_vendas"`
 	Fonte                 string          `json:"fonte"`
 	Campanha              string          `json:"campanha"`
 	SeguradoraAtual       string          `json:"seguradora_atual"`
 	DataVencimentoApolice time.Time       `json:"data_vencimento_apolice"`
+	CamposPersonalizados  datatypes.JSON  `json:"campos_personalizados,omitempty"`
 	Tarefas               []tarefa.Tarefa `json:"tarefas" gorm:"foreignKey:NegociacaoID"`
 
+	// Contatos envolvidos na negociação e seus papéis na decisão de compra
+	Participantes []Participante `json:"participantes,omitempty" gorm:"foreignKey:NegociacaoID"`
+
 	// Opcional: carregar os históricos de mudança de etapa
 	HistoricoEtapas []historicoetapa.HistoricoEtapa `json:"historico_etapas,omitempty" gorm:"foreignKey:NegociacaoID"`
 
@@ -36,7 +42,65 @@ type Negociacao struct {
 	ValorNegociacao    float64   `json:"valor_negociacao"`    // Valor da negociação
 	PrevisaoFechamento time.Time `json:"previsao_fechamento"` // Data prevista para fechamento
 
+	// Pontuação do lead pelas regras de pontuação; calculada pelo CRM, nunca gravada pela API.
+	Pontuacao int `json:"pontuacao" gorm:"<-:false;not null;default:0;index"`
+
+	// Alertas calculados na leitura (ex.: etapa avançada sem decisor envolvido)
+	Avisos []string `json:"avisos,omitempty" gorm:"-"`
+
+	// Login de quem faz a alteração; registrado na auditoria, não na negociação.
+	AlteradoPor string `json:"alterado_por,omitempty" gorm:"-"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
+
+// Status que encerram a negociação; a mudança para eles publica os eventos
+// negociacao.ganha e negociacao.perdida.
+const (
+	StatusGanha   = "ganha"
+	StatusPerdida = "perdida"
+)
+
+// VincularTarefa preenche na tarefa os dados derivados da negociação: o vínculo,
+// a empresa e os nomes desnormalizados. Sem responsável, usa o da negociação.
+// A negociação deve estar carregada com a Empresa.
+func (n Negociacao) VincularTarefa(t *tarefa.Tarefa) {
+	t.NegociacaoID = n.ID
+	t.Negociacao = n.NomeNegociacao
+	t.EmpresaID = n.EmpresaID
+	t.EmpresaNegociacao = n.Empresa.Nome
+	t.Empresa = empresa.Empresa{}
+	if t.Responsavel == "" {
+		t.Responsavel = n.Responsavel
+	}
+}
+
+// Papéis possíveis de um contato no processo de compra.
+const (
+	PapelDecisor       = "decisor"
+	PapelInfluenciador = "influenciador"
+	PapelFinanceiro    = "financeiro"
+	PapelUsuario       = "usuario"
+)
+
+// PapeisValidos lista os papéis aceitos para um participante.
+var PapeisValidos = []string{PapelDecisor, PapelInfluenciador, PapelFinanceiro, PapelUsuario}
+
+// Participante relaciona um contato a uma negociação com o seu papel na compra.
+type Participante struct {
+	ID           int              `json:"id" gorm:"primaryKey;autoIncrement"`
+	NegociacaoID int              `json:"negociacao_id" gorm:"uniqueIndex:idx_negociacao_contato"`
+	ContatoID    int              `json:"contato_id" gorm:"uniqueIndex:idx_negociacao_contato;index"`
+	Contato      *contato.Contato `json:"contato,omitempty" gorm:"foreignKey:ContatoID"`
+	Papel        string           `json:"papel"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Participante) TableName() string {
+	return "negociacao_contatos"
+}
@@ -2,57 +2,122 @@ package negociacao
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"my-crm-backend/internal/auditoria"
+	"my-crm-backend/internal/campopersonalizado"
+	"my-crm-backend/internal/contato"
+	"my-crm-backend/internal/empresa"
+	"my-crm-backend/internal/evento"
 	"my-crm-backend/internal/historicoetapa"
+	"my-crm-backend/internal/negocio"
 	"my-crm-backend/internal/tarefa"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// EtapaExigeDecisor é a primeira etapa do funil a partir da qual se espera um decisor envolvido.
+const EtapaExigeDecisor = "Proposta"
+
 // Repository define as operações básicas para manipulação de negociações.
 type Repository interface {
 	Adicionar(n Negociacao) (Negociacao, error)
-	Listar() ([]Negociacao, error)
+	Listar(filtro Filtro) ([]Negociacao, error)
 	ObterPorID(id int) (*Negociacao, error)
 	Atualizar(id int, updated Negociacao) (Negociacao, error)
 	Deletar(id int) error
-	AdicionarTarefa(negociacaoID int, novaTarefa tarefa.Tarefa) (Negociacao, error)
+	AdicionarTarefa(negociacaoID int, novaTarefa tarefa.Tarefa) (tarefa.Tarefa, error)
+	ListarTarefas(negociacaoID int) ([]tarefa.Tarefa, error)
+	VincularTarefa(negociacaoID int, t *tarefa.Tarefa) error
 	// Atualiza o funil e registra o histórico da mudança.
 	AtualizarFunil(id int, novaEtapa, alteradoPor, observacao string) (Negociacao, error)
 	// Métodos novos para atualização parcial:
-	AtualizarStatus(id int, novoStatus string) (Negociacao, error)
-	AtualizarValores(id int, valorNegociacao float64, previsaoFechamento time.Time) (Negociacao, error)
+	AtualizarStatus(id int, novoStatus, usuario string) (Negociacao, error)
+	AtualizarValores(id int, valorNegociacao float64, previsaoFechamento time.Time, usuario string) (Negociacao, error)
+	// Passa a negociação e as suas tarefas abertas para outro responsável.
+	AtualizarResponsavel(id int, novo, usuario string) (string, error)
+	// Participantes (contatos) da negociação e seus papéis.
+	ListarParticipantes(id int) ([]Participante, error)
+	AdicionarParticipante(id, contatoID int, papel string) (Participante, error)
+	RemoverParticipante(id, contatoID int) error
+}
+
+// Filtro restringe e ordena a listagem de negociações.
+type Filtro struct {
+	Campos              campopersonalizado.Filtro
+	PontuacaoMinima     *int
+	PontuacaoMaxima     *int
+	OrdenarPorPontuacao bool
+	Decrescente         bool // Da maior para a menor pontuação
+}
+
+// GatilhoEtapa reage à mudança de etapa do funil de uma negociação. É executado
+// na mesma transação da mudança; um erro desfaz a mudança.
+type GatilhoEtapa interface {
+	AoMudarEtapa(tx *gorm.DB, n Negociacao, etapaAnterior, alteradoPor string) error
 }
 
 type repository struct {
-	db *gorm.DB
+	db       *gorm.DB
+	gatilhos []GatilhoEtapa
 }
 
-// NovoRepositorio cria e retorna um repositório baseado em GORM.
-func NovoRepositorio(db *gorm.DB) Repository {
-	return &repository{db: db}
+// NovoRepositorio cria e retorna um repositório baseado em GORM. Os gatilhos
+// informados são acionados a cada mudança de etapa em AtualizarFunil.
+func NovoRepositorio(db *gorm.DB, gatilhos ...GatilhoEtapa) Repository {
+	return &repository{db: db, gatilhos: gatilhos}
 }
 
 // Adicionar insere uma nova negociação no banco de dados.
 // Se DataVencimentoApolice estiver zerada, atribui a data atual.
+// O contato principal, se informado, também é registrado como participante.
 func (r *repository) Adicionar(n Negociacao) (Negociacao, error) {
 	if n.DataVencimentoApolice.IsZero() {
 		n.DataVencimentoApolice = time.Now()
 	}
-	err := r.db.Create(&n).Error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&n).Error; err != nil {
+			return err
+		}
+		if err := registrarContatoPrincipal(tx, n.ID, n.ContatoID); err != nil {
+			return err
+		}
+		return evento.Publicar(tx, evento.NegociacaoCriada, "negociacao", n.ID, n.AlteradoPor, n)
+	})
 	return n, err
 }
 
-// Listar retorna todas as negociações com suas associações (Empresa, Contato, Tarefas e Históricos).
-func (r *repository) Listar() ([]Negociacao, error) {
+// Listar retorna as negociações que atendem ao filtro com suas associações
+// (Empresa, Contato, Tarefas e Históricos).
+func (r *repository) Listar(filtro Filtro) ([]Negociacao, error) {
 	var negociacoes []Negociacao
-	err := r.db.
+	query := filtro.Campos.Aplicar(r.db)
+	if filtro.PontuacaoMinima != nil {
+		query = query.Where("pontuacao >= ?", *filtro.PontuacaoMinima)
+	}
+	if filtro.PontuacaoMaxima != nil {
+		query = query.Where("pontuacao <= ?", *filtro.PontuacaoMaxima)
+	}
+	if filtro.OrdenarPorPontuacao {
+		if filtro.Decrescente {
+			query = query.Order("pontuacao DESC, id")
+		} else {
+			query = query.Order("pontuacao, id")
+		}
+	}
+	err := query.
 		Preload("Empresa").
 		Preload("Contato").
 		Preload("Tarefas").
 		Preload("HistoricoEtapas").
+		Preload("Participantes").
 		Find(&negociacoes).Error
+	for i := range negociacoes {
+		negociacoes[i].Avisos = avisos(negociacoes[i])
+	}
 	return negociacoes, err
 }
 
@@ -64,13 +129,26 @@ func (r *repository) ObterPorID(id int) (*Negociacao, error) {
 		Preload("Contato").
 		Preload("Tarefas").
 		Preload("HistoricoEtapas").
+		Preload("Participantes.Contato").
 		First(&negociacao, id).Error
 	if err != nil {
 		return nil, errors.New("Negociacao not found")
 	}
+	negociacao.Avisos = avisos(negociacao)
 	return &negociacao, nil
 }
 
+// VincularTarefa preenche na tarefa os dados derivados da negociação (ver
+// Negociacao.VincularTarefa). Implementa tarefa.Negociacoes.
+func (r *repository) VincularTarefa(negociacaoID int, t *tarefa.Tarefa) error {
+	var n Negociacao
+	if err := r.db.Preload("Empresa").First(&n, negociacaoID).Error; err != nil {
+		return errors.New("Negociacao not found")
+	}
+	n.VincularTarefa(t)
+	return nil
+}
+
 // Atualizar modifica uma negociação existente.
 func (r *repository) Atualizar(id int, updated Negociacao) (Negociacao, error) {
 	var negociacao Negociacao
@@ -79,95 +157,361 @@ func (r *repository) Atualizar(id int, updated Negociacao) (Negociacao, error) {
 	}
 
 	updated.ID = id
-	err := r.db.Model(&negociacao).Updates(updated).Error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&negociacao).Updates(updated).Error; err != nil {
+			return err
+		}
+		var depois Negociacao
+		if err := tx.First(&depois, id).Error; err != nil {
+			return err
+		}
+		if err := registrarMudancas(tx, negociacao, depois, updated.AlteradoPor); err != nil {
+			return err
+		}
+		if err := sincronizarTarefas(tx, negociacao, updated); err != nil {
+			return err
+		}
+		if err := registrarContatoPrincipal(tx, id, updated.ContatoID); err != nil {
+			return err
+		}
+		return evento.Publicar(tx, evento.NegociacaoAtualizada, "negociacao", id, updated.AlteradoPor, depois)
+	})
 	return updated, err
 }
 
+// sincronizarTarefas propaga para as tarefas da negociação a mudança de nome ou de empresa.
+func sincronizarTarefas(tx *gorm.DB, atual, updated Negociacao) error {
+	campos := map[string]interface{}{}
+	if updated.NomeNegociacao != "" && updated.NomeNegociacao != atual.NomeNegociacao {
+		campos["negociacao"] = updated.NomeNegociacao
+	}
+	if updated.EmpresaID != 0 && updated.EmpresaID != atual.EmpresaID {
+		var e empresa.Empresa
+		if err := tx.Select("id", "nome").First(&e, updated.EmpresaID).Error; err != nil {
+			return errors.New("empresa não encontrada")
+		}
+		campos["empresa_id"] = e.ID
+		campos["empresa_negociacao"] = e.Nome
+	}
+	if len(campos) == 0 {
+		return nil
+	}
+	return tx.Model(&tarefa.Tarefa{}).Where("negociacao_id = ?", atual.ID).Updates(campos).Error
+}
+
 // Deletar remove uma negociação pelo ID.
 func (r *repository) Deletar(id int) error {
 	return r.db.Delete(&Negociacao{}, id).Error
 }
 
-// AdicionarTarefa adiciona uma nova tarefa à negociação especificada.
-func (r *repository) AdicionarTarefa(negociacaoID int, novaTarefa tarefa.Tarefa) (Negociacao, error) {
+// AdicionarTarefa cria uma tarefa na negociação especificada. A empresa e os
+// nomes desnormalizados são sempre derivados da negociação (ver VincularTarefa).
+func (r *repository) AdicionarTarefa(negociacaoID int, novaTarefa tarefa.Tarefa) (tarefa.Tarefa, error) {
 	var negociacao Negociacao
-	// Carrega a negociação com as tarefas já associadas
-	err := r.db.Preload("Tarefas").First(&negociacao, negociacaoID).Error
-	if err != nil {
-		return Negociacao{}, errors.New("Negociacao not found")
+	if err := r.db.Preload("Empresa").First(&negociacao, negociacaoID).Error; err != nil {
+		return tarefa.Tarefa{}, errors.New("Negociacao not found")
 	}
-	// Define a relação da nova tarefa
-	novaTarefa.NegociacaoID = negociacaoID
-	// Insere a nova tarefa
-	if err := r.db.Create(&novaTarefa).Error; err != nil {
-		return Negociacao{}, err
-	}
-	// Recarrega a negociação para retornar com as tarefas atualizadas
-	if err := r.db.Preload("Tarefas").First(&negociacao, negociacaoID).Error; err != nil {
-		return Negociacao{}, err
+	negociacao.VincularTarefa(&novaTarefa)
+	return tarefa.NovoRepositorio(r.db).Adicionar(novaTarefa)
+}
+
+// ListarTarefas retorna as tarefas da negociação, ordenadas pelo início.
+func (r *repository) ListarTarefas(negociacaoID int) ([]tarefa.Tarefa, error) {
+	var negociacao Negociacao
+	if err := r.db.Select("id").First(&negociacao, negociacaoID).Error; err != nil {
+		return nil, errors.New("Negociacao not found")
 	}
-	return negociacao, nil
+	var tarefas []tarefa.Tarefa
+	err := r.db.Where("negociacao_id = ?", negociacaoID).Order("inicio, id").Find(&tarefas).Error
+	return tarefas, err
 }
 
 // AtualizarFunil atualiza a etapa do funil de vendas de uma negociação e registra o histórico da alteração.
 func (r *repository) AtualizarFunil(id int, novaEtapa, alteradoPor, observacao string) (Negociacao, error) {
 	var negociacao Negociacao
-	if err := r.db.First(&negociacao, id).Error; err != nil {
+	if err := r.db.Preload("Empresa").Preload("Participantes").First(&negociacao, id).Error; err != nil {
 		return Negociacao{}, errors.New("Negociacao not found")
 	}
 	oldEtapa := negociacao.EtapaFunilVendas
 	// Se não houver alteração, retorna o registro atual.
 	if oldEtapa == novaEtapa {
+		negociacao.Avisos = avisos(negociacao)
 		return negociacao, nil
 	}
-	// Atualiza a etapa na negociação
-	if err := r.db.Model(&negociacao).Update("etapa_funil_vendas", novaEtapa).Error; err != nil {
-		return Negociacao{}, err
-	}
-	// Cria registro de histórico
-	historico := historicoetapa.HistoricoEtapa{
-		NegociacaoID:  negociacao.ID,
-		EtapaAnterior: oldEtapa,
-		EtapaAtual:    novaEtapa,
-		AlteradoPor:   alteradoPor,
-		Observacao:    observacao,
-		DataAlteracao: time.Now(),
-	}
-	if err := r.db.Create(&historico).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Atualiza a etapa na negociação
+		if err := tx.Model(&negociacao).Update("etapa_funil_vendas", novaEtapa).Error; err != nil {
+			return err
+		}
+		// Cria registro de histórico
+		historico := historicoetapa.HistoricoEtapa{
+			NegociacaoID:  negociacao.ID,
+			EtapaAnterior: oldEtapa,
+			EtapaAtual:    novaEtapa,
+			AlteradoPor:   alteradoPor,
+			Observacao:    observacao,
+			DataAlteracao: time.Now(),
+		}
+		if err := tx.Create(&historico).Error; err != nil {
+			return err
+		}
+		negociacao.EtapaFunilVendas = novaEtapa
+		if err := evento.Publicar(tx, evento.NegociacaoEtapaAlterada, "negociacao", negociacao.ID, alteradoPor, map[string]interface{}{
+			"negociacao":     negociacao,
+			"etapa_anterior": oldEtapa,
+			"etapa_atual":    novaEtapa,
+			"observacao":     observacao,
+		}); err != nil {
+			return err
+		}
+		for _, g := range r.gatilhos {
+			if err := g.AoMudarEtapa(tx, negociacao, oldEtapa, alteradoPor); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return Negociacao{}, err
 	}
-	negociacao.EtapaFunilVendas = novaEtapa
+	negociacao.Avisos = avisos(negociacao)
 	return negociacao, nil
 }
 
 // AtualizarStatus atualiza apenas o campo Status da negociação.
-func (r *repository) AtualizarStatus(id int, novoStatus string) (Negociacao, error) {
+func (r *repository) AtualizarStatus(id int, novoStatus, usuario string) (Negociacao, error) {
 	var negociacao Negociacao
 	if err := r.db.First(&negociacao, id).Error; err != nil {
 		return Negociacao{}, errors.New("Negociacao not found")
 	}
-	// Atualiza o campo "status" no banco de dados
-	if err := r.db.Model(&negociacao).Update("status", novoStatus).Error; err != nil {
+	anterior := negociacao
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Atualiza o campo "status" no banco de dados
+		if err := tx.Model(&negociacao).Update("status", novoStatus).Error; err != nil {
+			return err
+		}
+		negociacao.Status = novoStatus
+		return registrarMudancas(tx, anterior, negociacao, usuario)
+	})
+	if err != nil {
 		return Negociacao{}, err
 	}
-	negociacao.Status = novoStatus
 	return negociacao, nil
 }
 
+// AtualizarResponsavel passa a negociação para o novo responsável, registra a
+// troca na auditoria e transfere as tarefas abertas do responsável anterior.
+// Retorna o responsável anterior.
+func (r *repository) AtualizarResponsavel(id int, novo, usuario string) (string, error) {
+	var atual Negociacao
+	if err := r.db.Select("id", "responsavel").First(&atual, id).Error; err != nil {
+		return "", errors.New("Negociacao not found")
+	}
+	anterior := atual.Responsavel
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := NovoRepositorio(tx).Atualizar(id, Negociacao{Responsavel: novo, AlteradoPor: usuario}); err != nil {
+			return err
+		}
+		if err := auditoria.Registrar(tx, "negociacao", id, "responsavel", usuario, map[string]string{
+			"anterior": anterior,
+			"atual":    novo,
+		}); err != nil {
+			return err
+		}
+		if anterior == "" {
+			return nil
+		}
+		return tx.Model(&tarefa.Tarefa{}).
+			Where("negociacao_id = ? AND responsavel = ? AND status IN ?", id, anterior, tarefa.StatusesAbertos).
+			Update("responsavel", novo).Error
+	})
+	return anterior, err
+}
+
 // AtualizarValores atualiza os campos ValorNegociacao e PrevisaoFechamento da negociação.
-func (r *repository) AtualizarValores(id int, valorNegociacao float64, previsaoFechamento time.Time) (Negociacao, error) {
+func (r *repository) AtualizarValores(id int, valorNegociacao float64, previsaoFechamento time.Time, usuario string) (Negociacao, error) {
 	var negociacao Negociacao
 	if err := r.db.First(&negociacao, id).Error; err != nil {
 		return Negociacao{}, errors.New("Negociacao not found")
 	}
+	anterior := negociacao
 	updates := map[string]interface{}{
 		"valor_negociacao":    valorNegociacao,
 		"previsao_fechamento": previsaoFechamento,
 	}
-	if err := r.db.Model(&negociacao).Updates(updates).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&negociacao).Updates(updates).Error; err != nil {
+			return err
+		}
+		negociacao.ValorNegociacao = valorNegociacao
+		negociacao.PrevisaoFechamento = previsaoFechamento
+		return registrarMudancas(tx, anterior, negociacao, usuario)
+	})
+	if err != nil {
 		return Negociacao{}, err
 	}
-	negociacao.ValorNegociacao = valorNegociacao
-	negociacao.PrevisaoFechamento = previsaoFechamento
 	return negociacao, nil
 }
+
+// Ações registradas na auditoria quando o status ou os valores da negociação mudam.
+const (
+	AcaoStatus  = "status"
+	AcaoValores = "valores"
+)
+
+// registrarMudancas grava na auditoria, e publica como eventos, as mudanças de
+// status e de valores (valor e previsão de fechamento) entre a negociação antes
+// e depois da alteração.
+func registrarMudancas(tx *gorm.DB, antes, depois Negociacao, usuario string) error {
+	if depois.Status != antes.Status {
+		if err := auditoria.Registrar(tx, "negociacao", antes.ID, AcaoStatus, usuario, map[string]string{
+			"anterior": antes.Status,
+			"atual":    depois.Status,
+		}); err != nil {
+			return err
+		}
+		if err := publicarStatus(tx, antes.Status, depois, usuario); err != nil {
+			return err
+		}
+	}
+	if depois.ValorNegociacao == antes.ValorNegociacao && depois.PrevisaoFechamento.Equal(antes.PrevisaoFechamento) {
+		return nil
+	}
+	detalhes := map[string]interface{}{
+		"valor_anterior":    antes.ValorNegociacao,
+		"valor_atual":       depois.ValorNegociacao,
+		"previsao_anterior": antes.PrevisaoFechamento,
+		"previsao_atual":    depois.PrevisaoFechamento,
+	}
+	if err := auditoria.Registrar(tx, "negociacao", antes.ID, AcaoValores, usuario, detalhes); err != nil {
+		return err
+	}
+	detalhes["negociacao"] = depois
+	return evento.Publicar(tx, evento.NegociacaoValoresAlterados, "negociacao", antes.ID, usuario, detalhes)
+}
+
+// publicarStatus publica a mudança de status e, quando a negociação é ganha ou
+// perdida, também o evento específico.
+func publicarStatus(tx *gorm.DB, anterior string, n Negociacao, usuario string) error {
+	dados := map[string]interface{}{
+		"negociacao":      n,
+		"status_anterior": anterior,
+		"status_atual":    n.Status,
+	}
+	if err := evento.Publicar(tx, evento.NegociacaoStatusAlterado, "negociacao", n.ID, usuario, dados); err != nil {
+		return err
+	}
+	switch {
+	case strings.EqualFold(n.Status, StatusGanha):
+		return evento.Publicar(tx, evento.NegociacaoGanha, "negociacao", n.ID, usuario, dados)
+	case strings.EqualFold(n.Status, StatusPerdida):
+		return evento.Publicar(tx, evento.NegociacaoPerdida, "negociacao", n.ID, usuario, dados)
+	}
+	return nil
+}
+
+// ListarParticipantes retorna os contatos envolvidos na negociação.
+func (r *repository) ListarParticipantes(id int) ([]Participante, error) {
+	if err := r.db.First(&Negociacao{}, id).Error; err != nil {
+		return nil, errors.New("Negociacao not found")
+	}
+	var participantes []Participante
+	err := r.db.Preload("Contato").Where("negociacao_id = ?", id).Order("id").Find(&participantes).Error
+	return participantes, err
+}
+
+// AdicionarParticipante inclui o contato na negociação ou, se já participar, atualiza o seu papel.
+func (r *repository) AdicionarParticipante(id, contatoID int, papel string) (Participante, error) {
+	if !papelValido(papel) {
+		return Participante{}, fmt.Errorf("papel inválido: use um de %v", PapeisValidos)
+	}
+	if err := r.db.First(&Negociacao{}, id).Error; err != nil {
+		return Participante{}, errors.New("Negociacao not found")
+	}
+	if err := r.db.First(&contato.Contato{}, contatoID).Error; err != nil {
+		return Participante{}, errors.New("Contato not found")
+	}
+	p := Participante{NegociacaoID: id, ContatoID: contatoID, Papel: papel}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "negociacao_id"}, {Name: "contato_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"papel", "updated_at"}),
+		}).Create(&p).Error; err != nil {
+			return err
+		}
+		return evento.Publicar(tx, evento.NegociacaoParticipantesAlterados, "negociacao", id, "", p)
+	})
+	if err != nil {
+		return Participante{}, err
+	}
+	err = r.db.Preload("Contato").Where("negociacao_id = ? AND contato_id = ?", id, contatoID).First(&p).Error
+	return p, err
+}
+
+// RemoverParticipante retira o contato da negociação.
+func (r *repository) RemoverParticipante(id, contatoID int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("negociacao_id = ? AND contato_id = ?", id, contatoID).Delete(&Participante{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("participante não encontrado")
+		}
+		return evento.Publicar(tx, evento.NegociacaoParticipantesAlterados, "negociacao", id, "", map[string]int{"contato_id": contatoID})
+	})
+}
+
+// registrarContatoPrincipal garante que o contato principal da negociação conste entre os
+// participantes, com papel de decisor se o contato estiver marcado como tal.
+func registrarContatoPrincipal(tx *gorm.DB, negociacaoID, contatoID int) error {
+	if contatoID == 0 {
+		return nil
+	}
+	var c contato.Contato
+	if err := tx.First(&c, contatoID).Error; err != nil {
+		return errors.New("Contato not found")
+	}
+	papel := PapelInfluenciador
+	if c.EDecisor {
+		papel = PapelDecisor
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&Participante{NegociacaoID: negociacaoID, ContatoID: contatoID, Papel: papel}).Error
+}
+
+func papelValido(papel string) bool {
+	for _, p := range PapeisValidos {
+		if p == papel {
+			return true
+		}
+	}
+	return false
+}
+
+// etapaAvancada indica se a etapa está em EtapaExigeDecisor ou depois dela no funil.
+func etapaAvancada(etapa string) bool {
+	limite, atual := -1, -1
+	for i, e := range negocio.FunilOpcoes {
+		if e == EtapaExigeDecisor {
+			limite = i
+		}
+		if e == etapa {
+			atual = i
+		}
+	}
+	return limite >= 0 && atual >= limite
+}
+
+// avisos calcula os alertas da negociação a partir dos participantes já carregados.
+func avisos(n Negociacao) []string {
+	if !etapaAvancada(n.EtapaFunilVendas) {
+		return nil
+	}
+	for _, p := range n.Participantes {
+		if p.Papel == PapelDecisor {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("negociação na etapa %q sem nenhum decisor envolvido", n.EtapaFunilVendas)}
+}
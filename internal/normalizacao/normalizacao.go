@@ -0,0 +1,129 @@
+package normalizacao
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// acentos remove a acentuação mais comum do português.
+var acentos = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// sufixosSocietarios são termos de natureza jurídica ignorados na comparação de nomes de empresas.
+var sufixosSocietarios = map[string]bool{
+	"ltda": true, "sa": true, "s/a": true, "me": true, "epp": true,
+	"eireli": true, "mei": true, "cia": true, "limitada": true,
+}
+
+// Texto converte para minúsculas, remove acentos, pontuação e espaços repetidos.
+func Texto(s string) string {
+	s = acentos.Replace(strings.ToLower(strings.TrimSpace(s)))
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// sociedadeAnonima reconhece "S/A", "S.A.", "S.A" e "S. A." como a sigla "sa".
+var sociedadeAnonima = regexp.MustCompile(`\bs\s*[./]\s*a\b\.?`)
+
+// NomeEmpresa normaliza o nome de uma empresa, descartando sufixos como "Ltda", "S/A" e "S.A.".
+func NomeEmpresa(s string) string {
+	s = sociedadeAnonima.ReplaceAllString(strings.ToLower(s), "sa")
+	palavras := strings.Fields(Texto(s))
+	resultado := palavras[:0]
+	for _, p := range palavras {
+		if !sufixosSocietarios[p] {
+			resultado = append(resultado, p)
+		}
+	}
+	return strings.Join(resultado, " ")
+}
+
+// Email normaliza um endereço de e-mail para comparação.
+func Email(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// Digitos mantém apenas os dígitos de s.
+func Digitos(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// RaizCNPJ retorna os oito primeiros dígitos do CNPJ, comuns à matriz e às filiais.
+// Retorna string vazia se o CNPJ não tiver 14 dígitos.
+func RaizCNPJ(cnpj string) string {
+	d := Digitos(cnpj)
+	if len(d) != 14 {
+		return ""
+	}
+	return d[:8]
+}
+
+// Telefone reduz um telefone aos seus últimos dígitos significativos (DDD + número),
+// ignorando código de país e zeros de discagem.
+func Telefone(s string) string {
+	d := strings.TrimLeft(Digitos(s), "0")
+	if len(d) > 11 && strings.HasPrefix(d, "55") {
+		d = d[2:]
+	}
+	if len(d) < 10 {
+		return ""
+	}
+	return d
+}
+
+// Similaridade retorna um valor entre 0 e 1 baseado na distância de Levenshtein entre a e b.
+func Similaridade(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	maior := len(ra)
+	if len(rb) > maior {
+		maior = len(rb)
+	}
+	if maior == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(maior)
+}
+
+func levenshtein(a, b []rune) int {
+	anterior := make([]int, len(b)+1)
+	atual := make([]int, len(b)+1)
+	for j := range anterior {
+		anterior[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		atual[0] = i
+		for j := 1; j <= len(b); j++ {
+			custo := 1
+			if a[i-1] == b[j-1] {
+				custo = 0
+			}
+			atual[j] = min(anterior[j]+1, atual[j-1]+1, anterior[j-1]+custo)
+		}
+		anterior, atual = atual, anterior
+	}
+	return anterior[len(b)]
+}
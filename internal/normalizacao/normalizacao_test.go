@@ -0,0 +1,24 @@
+package normalizacao
+
+import "testing"
+
+func TestNomeEmpresa(t *testing.T) {
+	casos := []struct {
+		nome, esperado string
+	}{
+		{"Transportes Silva Ltda.", "transportes silva"},
+		{"Transportes Silva S/A", "transportes silva"},
+		{"Transportes Silva S.A.", "transportes silva"},
+		{"Transportes Silva S.A", "transportes silva"},
+		{"Transportes Silva S. A.", "transportes silva"},
+		{"TRANSPORTES SILVA SA", "transportes silva"},
+		{"Cia. Paulista de Seguros - EPP", "paulista de seguros"},
+		{"Padaria São José ME", "padaria sao jose"},
+		{"Casas Bahia", "casas bahia"},
+	}
+	for _, c := range casos {
+		if got := NomeEmpresa(c.nome); got != c.esperado {
+			t.Errorf("NomeEmpresa(%q) = %q; esperado %q", c.nome, got, c.esperado)
+		}
+	}
+}
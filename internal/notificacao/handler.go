@@ -0,0 +1,65 @@
+package notificacao
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler define os manipuladores HTTP para as notificações.
+type Handler struct {
+	repo Repository
+}
+
+// NovoHandler cria e retorna um novo handler de notificações.
+func NovoHandler(repo Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+// Listar retorna as notificações de um usuário: GET /api/notificacoes?usuario=joao&nao_lidas=true
+func (h *Handler) Listar(c *gin.Context) {
+	usuario := c.Query("usuario")
+	if usuario == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "usuario é obrigatório"})
+		return
+	}
+	notificacoes, err := h.repo.Listar(usuario, c.Query("nao_lidas") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, notificacoes)
+}
+
+// MarcarLida marca uma notificação como lida.
+func (h *Handler) MarcarLida(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	if err := h.repo.MarcarLida(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// MarcarTodasLidas marca como lidas todas as notificações do usuário.
+// Espera receber um JSON com: {"usuario": "joao"}
+func (h *Handler) MarcarTodasLidas(c *gin.Context) {
+	var payload struct {
+		Usuario string `json:"usuario"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil || payload.Usuario == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "usuario é obrigatório"})
+		return
+	}
+	total, err := h.repo.MarcarTodasLidas(payload.Usuario)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"marcadas": total})
+}
@@ -0,0 +1,21 @@
+package notificacao
+
+import "time"
+
+// Notificacao é um aviso destinado a um usuário, opcionalmente ligado a uma entidade do CRM.
+type Notificacao struct {
+	ID         int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	Usuario    string     `json:"usuario" gorm:"index;not null"` // Login do destinatário
+	Tipo       string     `json:"tipo"`                          // Ex: "tarefa_atrasada", "tarefa_reatribuida"
+	Titulo     string     `json:"titulo"`
+	Mensagem   string     `json:"mensagem,omitempty"`
+	Entidade   string     `json:"entidade,omitempty"`
+	EntidadeID int        `json:"entidade_id,omitempty"`
+	LidaEm     *time.Time `json:"lida_em,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Notificacao) TableName() string {
+	return "notificacoes"
+}
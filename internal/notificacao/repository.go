@@ -0,0 +1,67 @@
+package notificacao
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Repository define as operações sobre as notificações dos usuários.
+type Repository interface {
+	Listar(usuario string, apenasNaoLidas bool) ([]Notificacao, error)
+	MarcarLida(id int) error
+	MarcarTodasLidas(usuario string) (int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NovoRepositorio cria e retorna um repositório baseado em GORM.
+func NovoRepositorio(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Listar retorna as notificações do usuário, da mais recente para a mais antiga.
+func (r *repository) Listar(usuario string, apenasNaoLidas bool) ([]Notificacao, error) {
+	var notificacoes []Notificacao
+	query := r.db.Where("usuario = ?", usuario).Order("created_at DESC")
+	if apenasNaoLidas {
+		query = query.Where("lida_em IS NULL")
+	}
+	err := query.Find(&notificacoes).Error
+	return notificacoes, err
+}
+
+// MarcarLida marca uma notificação como lida.
+func (r *repository) MarcarLida(id int) error {
+	res := r.db.Model(&Notificacao{}).Where("id = ? AND lida_em IS NULL", id).Update("lida_em", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		var n Notificacao
+		if err := r.db.First(&n, id).Error; err != nil {
+			return errors.New("notificação não encontrada")
+		}
+	}
+	return nil
+}
+
+// MarcarTodasLidas marca como lidas todas as notificações pendentes do usuário.
+func (r *repository) MarcarTodasLidas(usuario string) (int64, error) {
+	res := r.db.Model(&Notificacao{}).Where("usuario = ? AND lida_em IS NULL", usuario).Update("lida_em", time.Now())
+	return res.RowsAffected, res.Error
+}
+
+// Notificar grava uma notificação usando a conexão (ou transação) informada,
+// permitindo que ela faça parte da mesma transação da operação que a originou.
+func Notificar(tx *gorm.DB, n Notificacao) error {
+	if n.Usuario == "" {
+		return errors.New("notificação sem destinatário")
+	}
+	n.ID = 0
+	n.LidaEm = nil
+	return tx.Create(&n).Error
+}
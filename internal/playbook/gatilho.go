@@ -0,0 +1,110 @@
+package playbook
+
+import (
+	"fmt"
+	"time"
+
+	"my-crm-backend/internal/evento"
+	"my-crm-backend/internal/negociacao"
+	"my-crm-backend/internal/tarefa"
+
+	"gorm.io/gorm"
+)
+
+// Gatilho instancia os playbooks quando uma negociação entra em uma etapa e
+// cancela as tarefas pendentes da etapa que ela deixou.
+type Gatilho struct {
+	fusos tarefa.Fusos
+	agora func() time.Time
+}
+
+// NovoGatilho cria o gatilho de playbooks para ser registrado no repositório de negociações.
+func NovoGatilho(fusos tarefa.Fusos) *Gatilho {
+	return &Gatilho{fusos: fusos, agora: time.Now}
+}
+
+// AoMudarEtapa implementa negociacao.GatilhoEtapa.
+func (g *Gatilho) AoMudarEtapa(tx *gorm.DB, n negociacao.Negociacao, etapaAnterior, alteradoPor string) error {
+	if etapaAnterior != "" {
+		if err := tx.Model(&tarefa.Tarefa{}).
+			Where("negociacao_id = ? AND playbook_etapa = ? AND status IN ?", n.ID, etapaAnterior, tarefa.StatusesAbertos).
+			Updates(map[string]interface{}{"status": tarefa.StatusCancelada, "atrasada": false}).Error; err != nil {
+			return err
+		}
+	}
+
+	var playbooks []Playbook
+	if err := tx.Preload("Modelos", modelosOrdenados).
+		Where("etapa = ? AND NOT pausado AND (funil = '' OR funil IS NULL OR funil = ?)", n.EtapaFunilVendas, n.FunilVendas).
+		Find(&playbooks).Error; err != nil {
+		return err
+	}
+	entrada := g.agora()
+	for _, p := range playbooks {
+		for _, m := range p.Modelos {
+			t, err := g.instanciar(p, m, n, alteradoPor, entrada)
+			if err != nil {
+				return fmt.Errorf("playbook %q, tarefa %q: %w", p.Nome, m.Assunto, err)
+			}
+			if err := tx.Create(&t).Error; err != nil {
+				return err
+			}
+			if err := evento.Publicar(tx, evento.TarefaCriada, "tarefa", t.ID, alteradoPor, t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// instanciar cria a tarefa do modelo para a negociação que entrou na etapa em entrada.
+func (g *Gatilho) instanciar(p Playbook, m Modelo, n negociacao.Negociacao, alteradoPor string, entrada time.Time) (tarefa.Tarefa, error) {
+	responsavel := m.Responsavel
+	switch m.RegraResponsavel {
+	case ResponsavelNegociacao:
+		responsavel = n.Responsavel
+	case ResponsavelQuemMoveu:
+		responsavel = alteradoPor
+	}
+	if responsavel == "" {
+		responsavel = n.Responsavel
+	}
+	if responsavel == "" {
+		responsavel = alteradoPor
+	}
+
+	playbookID := p.ID
+	t := tarefa.Tarefa{
+		NegociacaoID:      n.ID,
+		EmpresaID:         n.EmpresaID,
+		EmpresaNegociacao: n.Empresa.Nome,
+		Negociacao:        n.NomeNegociacao,
+		Assunto:           m.Assunto,
+		Descricao:         m.Descricao,
+		Tipo:              m.Tipo,
+		Prioridade:        m.Prioridade,
+		Responsavel:       responsavel,
+		DuracaoMinutos:    m.DuracaoMinutos,
+		PlaybookID:        &playbookID,
+		PlaybookEtapa:     n.EtapaFunilVendas,
+	}
+	loc, err := g.fusos.Fuso(responsavel)
+	if err != nil {
+		return t, err
+	}
+	dia := entrada.In(loc).AddDate(0, 0, m.DiasApos)
+	if m.Horario == "" {
+		t.DiaInteiro = true
+		t.Inicio = dia
+	} else {
+		var h, min int
+		fmt.Sscanf(m.Horario, "%d:%d", &h, &min)
+		t.Inicio = time.Date(dia.Year(), dia.Month(), dia.Day(), h, min, 0, 0, loc)
+	}
+	t.FusoHorario = loc.String()
+	if err := tarefa.Preparar(&t, g.fusos); err != nil {
+		return t, err
+	}
+	t.MarcarAtraso(entrada)
+	return t, nil
+}
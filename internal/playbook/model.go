@@ -0,0 +1,51 @@
+package playbook
+
+import "time"
+
+// Regras para definir o responsável pelas tarefas criadas por um playbook.
+const (
+	ResponsavelNegociacao = "negociacao" // dono da negociação
+	ResponsavelQuemMoveu  = "quem_moveu" // usuário que mudou a etapa
+	ResponsavelFixo       = "fixo"       // login informado no modelo
+)
+
+// Playbook é o conjunto de tarefas criado quando uma negociação entra na Etapa.
+type Playbook struct {
+	ID      int      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Nome    string   `json:"nome"`
+	Funil   string   `json:"funil,omitempty"` // Restringe ao FunilVendas da negociação; vazio vale para todos
+	Etapa   string   `json:"etapa" gorm:"index;not null"`
+	Pausado bool     `json:"pausado"`
+	Modelos []Modelo `json:"modelos" gorm:"foreignKey:PlaybookID;constraint:OnDelete:CASCADE"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Playbook) TableName() string {
+	return "playbooks"
+}
+
+// Modelo descreve uma tarefa do playbook. O prazo é relativo à entrada na etapa:
+// DiasApos dias depois, no Horario ("HH:MM") do fuso do responsável, ou no dia
+// inteiro se o horário for vazio.
+type Modelo struct {
+	ID               int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	PlaybookID       int    `json:"playbook_id" gorm:"index"`
+	Ordem            int    `json:"ordem"`
+	Assunto          string `json:"assunto"`
+	Descricao        string `json:"descricao,omitempty"`
+	Tipo             string `json:"tipo"`
+	Prioridade       string `json:"prioridade,omitempty"`
+	DiasApos         int    `json:"dias_apos"`
+	Horario          string `json:"horario,omitempty"`
+	DuracaoMinutos   int    `json:"duracao_minutos,omitempty"`
+	RegraResponsavel string `json:"regra_responsavel"` // negociacao, quem_moveu ou fixo
+	Responsavel      string `json:"responsavel,omitempty"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Modelo) TableName() string {
+	return "playbook_modelos"
+}
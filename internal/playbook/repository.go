@@ -0,0 +1,97 @@
+package playbook
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Repository define as operações básicas para manipular playbooks.
+type Repository interface {
+	Adicionar(p Playbook) (Playbook, error)
+	Listar(etapa string) ([]Playbook, error)
+	ObterPorID(id int) (*Playbook, error)
+	Atualizar(id int, updated Playbook) (Playbook, error)
+	Deletar(id int) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NovoRepositorio cria e retorna um repositório baseado em GORM.
+func NovoRepositorio(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func modelosOrdenados(db *gorm.DB) *gorm.DB {
+	return db.Order("ordem, id")
+}
+
+// Adicionar insere um playbook com os seus modelos de tarefa.
+func (r *repository) Adicionar(p Playbook) (Playbook, error) {
+	p.ID = 0
+	for i := range p.Modelos {
+		p.Modelos[i].ID = 0
+	}
+	err := r.db.Create(&p).Error
+	return p, err
+}
+
+// Listar retorna os playbooks, opcionalmente de uma etapa, com os seus modelos.
+func (r *repository) Listar(etapa string) ([]Playbook, error) {
+	var playbooks []Playbook
+	query := r.db.Preload("Modelos", modelosOrdenados).Order("etapa, id")
+	if etapa != "" {
+		query = query.Where("etapa = ?", etapa)
+	}
+	err := query.Find(&playbooks).Error
+	return playbooks, err
+}
+
+// ObterPorID busca um playbook pelo ID, com os seus modelos.
+func (r *repository) ObterPorID(id int) (*Playbook, error) {
+	var p Playbook
+	if err := r.db.Preload("Modelos", modelosOrdenados).First(&p, id).Error; err != nil {
+		return nil, errors.New("playbook não encontrado")
+	}
+	return &p, nil
+}
+
+// Atualizar substitui os dados e os modelos de um playbook. As tarefas já
+// criadas pelos modelos anteriores não são alteradas.
+func (r *repository) Atualizar(id int, updated Playbook) (Playbook, error) {
+	var p Playbook
+	if err := r.db.First(&p, id).Error; err != nil {
+		return Playbook{}, errors.New("playbook não encontrado")
+	}
+	updated.ID = id
+	updated.CreatedAt = p.CreatedAt
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&p).Select("nome", "funil", "etapa", "pausado").Updates(updated).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("playbook_id = ?", id).Delete(&Modelo{}).Error; err != nil {
+			return err
+		}
+		for i := range updated.Modelos {
+			updated.Modelos[i].ID = 0
+			updated.Modelos[i].PlaybookID = id
+		}
+		if len(updated.Modelos) == 0 {
+			return nil
+		}
+		return tx.Create(&updated.Modelos).Error
+	})
+	return updated, err
+}
+
+// Deletar remove um playbook e os seus modelos.
+func (r *repository) Deletar(id int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("playbook_id = ?", id).Delete(&Modelo{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Playbook{}, id).Error
+	})
+}
@@ -0,0 +1,55 @@
+package playbook
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"my-crm-backend/internal/negocio"
+	"my-crm-backend/internal/tarefa"
+)
+
+var formatoHorario = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`)
+
+// Validar confere a etapa e os modelos de tarefa do playbook.
+func Validar(p Playbook) error {
+	if p.Nome == "" {
+		return errors.New("nome é obrigatório")
+	}
+	etapaValida := false
+	for _, e := range negocio.FunilOpcoes {
+		if e == p.Etapa {
+			etapaValida = true
+		}
+	}
+	if !etapaValida {
+		return fmt.Errorf("etapa inválida: %q", p.Etapa)
+	}
+	if len(p.Modelos) == 0 {
+		return errors.New("o playbook precisa de ao menos um modelo de tarefa")
+	}
+	for i, m := range p.Modelos {
+		if m.Assunto == "" || m.Tipo == "" {
+			return fmt.Errorf("modelo %d: assunto e tipo são obrigatórios", i+1)
+		}
+		if m.DiasApos < 0 {
+			return fmt.Errorf("modelo %d: dias_apos não pode ser negativo", i+1)
+		}
+		if m.Horario != "" && !formatoHorario.MatchString(m.Horario) {
+			return fmt.Errorf("modelo %d: horário inválido, use HH:MM", i+1)
+		}
+		if err := tarefa.ValidarPrioridade(&tarefa.Tarefa{Prioridade: m.Prioridade}); err != nil {
+			return fmt.Errorf("modelo %d: %w", i+1, err)
+		}
+		switch m.RegraResponsavel {
+		case ResponsavelNegociacao, ResponsavelQuemMoveu:
+		case ResponsavelFixo:
+			if m.Responsavel == "" {
+				return fmt.Errorf("modelo %d: informe o responsável fixo", i+1)
+			}
+		default:
+			return fmt.Errorf("modelo %d: regra_responsavel inválida: %q", i+1, m.RegraResponsavel)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,340 @@
+package pontuacao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"my-crm-backend/internal/empresa"
+	"my-crm-backend/internal/evento"
+	"my-crm-backend/internal/negociacao"
+	"my-crm-backend/internal/normalizacao"
+	"my-crm-backend/internal/quiver"
+	"my-crm-backend/internal/tarefa"
+	"my-crm-backend/internal/timeline"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrEntidadeNaoEncontrada indica que a negociação ou a empresa não existe.
+var ErrEntidadeNaoEncontrada = errors.New("registro não encontrado")
+
+// Eventos lista os eventos de domínio que mudam a pontuação; a calculadora
+// deve assiná-los no barramento. Contatos e apólices do Quiver não publicam
+// eventos: as mudanças neles entram no recálculo periódico.
+var Eventos = []string{
+	evento.NegociacaoCriada, evento.NegociacaoAtualizada, evento.NegociacaoParticipantesAlterados,
+	evento.NegociacaoEtapaAlterada, evento.NegociacaoStatusAlterado,
+	evento.TarefaCriada, evento.TarefaConcluida,
+	evento.EmpresaCriada, evento.EmpresaAtualizada,
+}
+
+// Calculadora aplica as regras de pontuação às negociações e empresas.
+type Calculadora struct {
+	db      *gorm.DB
+	agora   func() time.Time
+	pedidos chan struct{} // Recálculos completos pedidos a Executar
+}
+
+// NovaCalculadora cria a calculadora de pontuação.
+func NovaCalculadora(db *gorm.DB) *Calculadora {
+	return &Calculadora{db: db, agora: time.Now, pedidos: make(chan struct{}, 1)}
+}
+
+// AgendarRecalculo pede a Executar um recálculo completo, em segundo plano,
+// por exemplo depois de uma mudança nas regras. Pedidos feitos enquanto outro
+// aguarda são atendidos pelo mesmo recálculo.
+func (c *Calculadora) AgendarRecalculo() {
+	select {
+	case c.pedidos <- struct{}{}:
+	default:
+	}
+}
+
+// Tratar recalcula a pontuação das entidades afetadas pelo evento: a
+// negociação, a empresa e as negociações dela, ou a negociação e a empresa da
+// tarefa. É registrado como assinante do barramento para os Eventos.
+func (c *Calculadora) Tratar(tx *gorm.DB, e evento.Evento) error {
+	switch e.Entidade {
+	case EntidadeNegociacao:
+		return c.recalcular(tx, EntidadeNegociacao, e.EntidadeID)
+	case EntidadeEmpresa:
+		if err := c.recalcular(tx, EntidadeEmpresa, e.EntidadeID); err != nil {
+			return err
+		}
+		var ids []int
+		if err := tx.Model(&negociacao.Negociacao{}).Where("empresa_id = ?", e.EntidadeID).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		return c.recalcular(tx, EntidadeNegociacao, ids...)
+	case "tarefa":
+		var t tarefa.Tarefa
+		if err := tx.Select("id", "negociacao_id", "empresa_id").Limit(1).Find(&t, e.EntidadeID).Error; err != nil {
+			return err
+		}
+		if t.NegociacaoID != 0 {
+			if err := c.recalcular(tx, EntidadeNegociacao, t.NegociacaoID); err != nil {
+				return err
+			}
+		}
+		if t.EmpresaID != 0 {
+			return c.recalcular(tx, EntidadeEmpresa, t.EmpresaID)
+		}
+	}
+	return nil
+}
+
+// recalcular calcula a pontuação de cada registro, ignorando os que foram removidos.
+func (c *Calculadora) recalcular(tx *gorm.DB, entidade string, ids ...int) error {
+	for _, id := range ids {
+		if _, err := c.Calcular(tx, entidade, id); err != nil && !errors.Is(err, ErrEntidadeNaoEncontrada) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Executar recalcula todas as pontuações a cada intervalo, para que a
+// atividade recente expire e as mudanças sem evento sejam consideradas, e a
+// cada pedido de AgendarRecalculo.
+func (c *Calculadora) Executar(ctx context.Context, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+	for {
+		total, err := c.RecalcularTodas()
+		if err != nil {
+			log.Printf("Erro no recálculo das pontuações: %v", err)
+		} else if total > 0 {
+			log.Printf("Pontuações recalculadas: %d", total)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-c.pedidos:
+		}
+	}
+}
+
+// RecalcularTodas recalcula a pontuação de todas as empresas e negociações,
+// cada uma em sua transação, e retorna quantas foram calculadas.
+func (c *Calculadora) RecalcularTodas() (int, error) {
+	total := 0
+	for _, entidade := range []struct {
+		nome   string
+		modelo interface{}
+	}{
+		{EntidadeEmpresa, &empresa.Empresa{}},
+		{EntidadeNegociacao, &negociacao.Negociacao{}},
+	} {
+		var ids []int
+		if err := c.db.Model(entidade.modelo).Order("id").Pluck("id", &ids).Error; err != nil {
+			return total, err
+		}
+		for _, id := range ids {
+			err := c.db.Transaction(func(tx *gorm.DB) error {
+				return c.recalcular(tx, entidade.nome, id)
+			})
+			if err != nil {
+				return total, err
+			}
+			total++
+		}
+	}
+	return total, nil
+}
+
+// Recalcular calcula, em uma transação própria, a pontuação da negociação ou empresa.
+func (c *Calculadora) Recalcular(entidade string, id int) (Pontuacao, error) {
+	var p Pontuacao
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		p, err = c.Calcular(tx, entidade, id)
+		return err
+	})
+	return p, err
+}
+
+// Calcular aplica as regras ativas à negociação ou empresa, grava a pontuação
+// com o detalhamento e atualiza a coluna pontuacao da entidade.
+func (c *Calculadora) Calcular(tx *gorm.DB, entidade string, id int) (Pontuacao, error) {
+	var regras []Regra
+	if err := tx.Where("ativa").Order("id").Find(&regras).Error; err != nil {
+		return Pontuacao{}, err
+	}
+	ctx, err := carregar(tx, entidade, id)
+	if err != nil {
+		return Pontuacao{}, err
+	}
+
+	p := Pontuacao{Entidade: entidade, EntidadeID: id, Detalhes: []Contribuicao{}, CalculadaEm: c.agora()}
+	for _, regra := range regras {
+		motivo, ok := ctx.avaliar(regra, p.CalculadaEm)
+		if !ok {
+			continue
+		}
+		p.Total += regra.Pontos
+		p.Detalhes = append(p.Detalhes, Contribuicao{
+			RegraID:  regra.ID,
+			Regra:    regra.Nome,
+			Criterio: regra.Criterio,
+			Pontos:   regra.Pontos,
+			Motivo:   motivo,
+		})
+	}
+
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "entidade"}, {Name: "entidade_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"total", "detalhes", "calculada_em"}),
+	}).Create(&p).Error; err != nil {
+		return Pontuacao{}, err
+	}
+	tabela := "negociacoes"
+	if entidade == EntidadeEmpresa {
+		tabela = "empresas"
+	}
+	err = tx.Table(tabela).Where("id = ?", id).UpdateColumn("pontuacao", p.Total).Error
+	return p, err
+}
+
+// contexto reúne o que as regras avaliam sobre uma negociação ou empresa.
+type contexto struct {
+	empresa         empresa.Empresa
+	decisor         string // Nome do contato decisor; vazio se não houver
+	ultimaAtividade *time.Time
+	apolices        []quiver.Quiver
+}
+
+// carregar monta o contexto. A negociação usa os dados da sua empresa, os
+// participantes decisores, os contatos decisores vinculados à empresa e a
+// própria linha do tempo; a empresa, os contatos decisores vinculados e a
+// linha do tempo de todas as suas negociações.
+func carregar(tx *gorm.DB, entidade string, id int) (contexto, error) {
+	var ctx contexto
+	var linha timeline.Pagina
+	filtro := timeline.Filtro{Pagina: 1, PorPagina: 1}
+	var decisores []string
+
+	switch entidade {
+	case EntidadeNegociacao:
+		var n negociacao.Negociacao
+		res := tx.Select("id", "empresa_id").Limit(1).Find(&n, id)
+		if res.Error != nil {
+			return ctx, res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ctx, ErrEntidadeNaoEncontrada
+		}
+		if err := tx.Limit(1).Find(&ctx.empresa, n.EmpresaID).Error; err != nil {
+			return ctx, err
+		}
+		if err := tx.Table("negociacao_contatos nc").
+			Joins("JOIN contatos c ON c.id = nc.contato_id AND c.deleted_at IS NULL").
+			Where("nc.negociacao_id = ? AND nc.papel = ?", id, negociacao.PapelDecisor).
+			Order("nc.id").Pluck("c.nome", &decisores).Error; err != nil {
+			return ctx, err
+		}
+		daEmpresa, err := decisoresDaEmpresa(tx, n.EmpresaID)
+		if err != nil {
+			return ctx, err
+		}
+		decisores = append(decisores, daEmpresa...)
+		if linha, err = timeline.NovoRepositorio(tx).DaNegociacao(id, filtro); err != nil {
+			return ctx, err
+		}
+	case EntidadeEmpresa:
+		res := tx.Limit(1).Find(&ctx.empresa, id)
+		if res.Error != nil {
+			return ctx, res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ctx, ErrEntidadeNaoEncontrada
+		}
+		var err error
+		if decisores, err = decisoresDaEmpresa(tx, id); err != nil {
+			return ctx, err
+		}
+		if linha, err = timeline.NovoRepositorio(tx).DaEmpresa(id, filtro); err != nil {
+			return ctx, err
+		}
+	default:
+		return ctx, fmt.Errorf("entidade sem pontuação: %s", entidade)
+	}
+
+	if len(decisores) > 0 {
+		ctx.decisor = decisores[0]
+	}
+	if len(linha.Eventos) > 0 {
+		ctx.ultimaAtividade = &linha.Eventos[0].Data
+	}
+	if raiz := normalizacao.RaizCNPJ(ctx.empresa.CNPJMatriz); raiz != "" {
+		// A raiz seguida de seis dígitos cobre a matriz e todas as filiais.
+		if err := tx.Where("regexp_replace(cpf_cnpj, '\\D', '', 'g') LIKE ?", raiz+"______").
+			Order("id").Find(&ctx.apolices).Error; err != nil {
+			return ctx, err
+		}
+	}
+	return ctx, nil
+}
+
+// decisoresDaEmpresa retorna os nomes dos contatos decisores com vínculo
+// vigente com a empresa.
+func decisoresDaEmpresa(tx *gorm.DB, empresaID int) ([]string, error) {
+	var nomes []string
+	err := tx.Table("contato_empresas v").
+		Joins("JOIN contatos c ON c.id = v.contato_id AND c.deleted_at IS NULL").
+		Where("v.empresa_id = ? AND v.data_fim IS NULL AND v.deleted_at IS NULL AND c.e_decisor", empresaID).
+		Order("v.id").Pluck("c.nome", &nomes).Error
+	return nomes, err
+}
+
+// avaliar informa se a regra é atendida e por quê.
+func (ctx contexto) avaliar(regra Regra, agora time.Time) (string, bool) {
+	e := ctx.empresa
+	switch regra.Criterio {
+	case CriterioTamanhoEmpresa:
+		return "porte: " + e.TamanhoEmpresa, contem(regra.Valores, e.TamanhoEmpresa)
+	case CriterioFaixaFaturamento:
+		return "faturamento: " + e.FaixaFaturamento, contem(regra.Valores, e.FaixaFaturamento)
+	case CriterioSegmento:
+		return "segmento: " + e.Segmento, contem(regra.Valores, e.Segmento)
+	case CriterioDecisor:
+		return "decisor: " + ctx.decisor, ctx.decisor != ""
+	case CriterioAtividade:
+		if ctx.ultimaAtividade == nil || agora.Sub(*ctx.ultimaAtividade) > time.Duration(regra.Dias)*24*time.Hour {
+			return "", false
+		}
+		return "última atividade em " + ctx.ultimaAtividade.Format("02/01/2006"), true
+	case CriterioApoliceQuiver:
+		var encontradas []string
+		for _, a := range ctx.apolices {
+			if len(regra.Valores) == 0 || contem(regra.Valores, a.Ramo) {
+				encontradas = append(encontradas, strings.TrimSpace(a.Seguradora+" "+a.Ramo))
+			}
+		}
+		if len(encontradas) == 0 {
+			return "", false
+		}
+		return fmt.Sprintf("%d apólice(s) no Quiver: %s", len(encontradas), strings.Join(encontradas, ", ")), true
+	}
+	return "", false
+}
+
+// contem informa se o valor é um dos valores da regra, sem diferenciar acentos
+// e maiúsculas. Valor vazio nunca é atendido.
+func contem(valores []string, valor string) bool {
+	alvo := normalizacao.Texto(valor)
+	if alvo == "" {
+		return false
+	}
+	for _, v := range valores {
+		if normalizacao.Texto(v) == alvo {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package pontuacao
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler define os manipuladores HTTP das regras de pontuação e das pontuações calculadas.
+type Handler struct {
+	repo        Repository
+	calculadora *Calculadora
+}
+
+// NovoHandler cria e retorna um novo handler de pontuação.
+func NovoHandler(repo Repository, calculadora *Calculadora) *Handler {
+	return &Handler{repo: repo, calculadora: calculadora}
+}
+
+// Metadados retorna os critérios aceitos nas regras.
+func (h *Handler) Metadados(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"criterios": Criterios})
+}
+
+// Criar insere uma nova regra de pontuação. As regras criadas, alteradas ou
+// removidas passam a valer em todas as pontuações após o recálculo em segundo plano.
+// Espera receber um JSON como: {"nome": "Empresa grande", "criterio": "tamanho_empresa",
+// "valores": ["Grande", "Enterprise"], "pontos": 20}
+func (h *Handler) Criar(c *gin.Context) {
+	r := Regra{Ativa: true}
+	if err := c.ShouldBindJSON(&r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := Validar(r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	criada, err := h.repo.Adicionar(r)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.calculadora.AgendarRecalculo()
+	c.JSON(http.StatusCreated, criada)
+}
+
+// Listar retorna todas as regras de pontuação.
+func (h *Handler) Listar(c *gin.Context) {
+	regras, err := h.repo.Listar()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, regras)
+}
+
+// Obter retorna uma regra pelo ID.
+func (h *Handler) Obter(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	r, err := h.repo.ObterPorID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// Atualizar substitui uma regra de pontuação.
+func (h *Handler) Atualizar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	r := Regra{Ativa: true}
+	if err := c.ShouldBindJSON(&r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := Validar(r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	atualizada, err := h.repo.Atualizar(id, r)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	h.calculadora.AgendarRecalculo()
+	c.JSON(http.StatusOK, atualizada)
+}
+
+// Deletar remove uma regra de pontuação.
+func (h *Handler) Deletar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	if err := h.repo.Deletar(id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrRegraNaoEncontrada) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	h.calculadora.AgendarRecalculo()
+	c.Status(http.StatusNoContent)
+}
+
+// Recalcular aplica as regras atuais a todas as empresas e negociações e
+// aguarda o resultado. Mudanças nas regras já agendam um recálculo em segundo plano.
+func (h *Handler) Recalcular(c *gin.Context) {
+	total, err := h.calculadora.RecalcularTodas()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recalculadas": total})
+}
+
+// DaEntidade retorna o handler que mostra a pontuação da entidade do tipo
+// informado, com a contribuição de cada regra. Sem cálculo anterior, calcula.
+func (h *Handler) DaEntidade(entidade string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+			return
+		}
+		if p, err := h.repo.ObterPontuacao(entidade, id); err == nil {
+			c.JSON(http.StatusOK, p)
+			return
+		}
+		h.calcular(c, entidade, id)
+	}
+}
+
+// RecalcularDaEntidade retorna o handler que recalcula a pontuação da
+// entidade do tipo informado.
+func (h *Handler) RecalcularDaEntidade(entidade string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+			return
+		}
+		h.calcular(c, entidade, id)
+	}
+}
+
+func (h *Handler) calcular(c *gin.Context, entidade string, id int) {
+	p, err := h.calculadora.Recalcular(entidade, id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrEntidadeNaoEncontrada) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
@@ -0,0 +1,78 @@
+package pontuacao
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Critérios avaliados pelas regras.
+const (
+	CriterioTamanhoEmpresa   = "tamanho_empresa"   // Empresa.TamanhoEmpresa é um dos Valores
+	CriterioFaixaFaturamento = "faixa_faturamento" // Empresa.FaixaFaturamento é um dos Valores
+	CriterioSegmento         = "segmento"          // Empresa.Segmento é um dos Valores
+	CriterioDecisor          = "decisor"           // Há um contato decisor: participante decisor da negociação ou decisor vinculado à empresa
+	CriterioAtividade        = "atividade_recente" // Houve atividade na linha do tempo nos últimos Dias dias
+	CriterioApoliceQuiver    = "apolice_quiver"    // Há apólice no Quiver para a raiz do CNPJ; Valores restringe os ramos
+)
+
+// Criterios lista os critérios válidos.
+var Criterios = []string{
+	CriterioTamanhoEmpresa, CriterioFaixaFaturamento, CriterioSegmento,
+	CriterioDecisor, CriterioAtividade, CriterioApoliceQuiver,
+}
+
+// Entidades pontuadas.
+const (
+	EntidadeNegociacao = "negociacao"
+	EntidadeEmpresa    = "empresa"
+)
+
+// Regra soma Pontos (que podem ser negativos) à pontuação das negociações e
+// empresas que atendem ao critério. Os valores são comparados sem acentos nem
+// diferença entre maiúsculas e minúsculas.
+type Regra struct {
+	ID       int                         `json:"id" gorm:"primaryKey;autoIncrement"`
+	Nome     string                      `json:"nome"`
+	Criterio string                      `json:"criterio" gorm:"not null"`
+	Valores  datatypes.JSONSlice[string] `json:"valores,omitempty"`
+	Dias     int                         `json:"dias,omitempty"` // atividade_recente
+	Pontos   int                         `json:"pontos"`
+	Ativa    bool                        `json:"ativa" gorm:"not null"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Regra) TableName() string {
+	return "pontuacao_regras"
+}
+
+// Pontuacao é o último cálculo da pontuação de uma negociação ou empresa, com
+// a contribuição de cada regra atendida. O Total também fica na coluna
+// pontuacao da entidade, usada para filtrar e ordenar as listagens.
+type Pontuacao struct {
+	ID          int                               `json:"id" gorm:"primaryKey;autoIncrement"`
+	Entidade    string                            `json:"entidade" gorm:"uniqueIndex:idx_pontuacao_entidade;not null"`
+	EntidadeID  int                               `json:"entidade_id" gorm:"uniqueIndex:idx_pontuacao_entidade;not null"`
+	Total       int                               `json:"total"`
+	Detalhes    datatypes.JSONSlice[Contribuicao] `json:"detalhes"`
+	CalculadaEm time.Time                         `json:"calculada_em"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Pontuacao) TableName() string {
+	return "pontuacoes"
+}
+
+// Contribuicao explica os pontos que uma regra deu à pontuação.
+type Contribuicao struct {
+	RegraID  int    `json:"regra_id"`
+	Regra    string `json:"regra"`
+	Criterio string `json:"criterio"`
+	Pontos   int    `json:"pontos"`
+	Motivo   string `json:"motivo"`
+}
@@ -0,0 +1,86 @@
+package pontuacao
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrRegraNaoEncontrada indica que a regra de pontuação não existe.
+var ErrRegraNaoEncontrada = errors.New("regra de pontuação não encontrada")
+
+// Repository define as operações sobre as regras de pontuação e as pontuações calculadas.
+type Repository interface {
+	Adicionar(r Regra) (Regra, error)
+	Listar() ([]Regra, error)
+	ObterPorID(id int) (*Regra, error)
+	Atualizar(id int, updated Regra) (Regra, error)
+	Deletar(id int) error
+	ObterPontuacao(entidade string, id int) (*Pontuacao, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NovoRepositorio cria e retorna um repositório baseado em GORM.
+func NovoRepositorio(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Adicionar insere uma nova regra.
+func (r *repository) Adicionar(regra Regra) (Regra, error) {
+	regra.ID = 0
+	err := r.db.Create(&regra).Error
+	return regra, err
+}
+
+// Listar retorna todas as regras.
+func (r *repository) Listar() ([]Regra, error) {
+	regras := []Regra{}
+	err := r.db.Order("criterio, id").Find(&regras).Error
+	return regras, err
+}
+
+// ObterPorID busca uma regra pelo ID.
+func (r *repository) ObterPorID(id int) (*Regra, error) {
+	var regra Regra
+	if err := r.db.First(&regra, id).Error; err != nil {
+		return nil, ErrRegraNaoEncontrada
+	}
+	return &regra, nil
+}
+
+// Atualizar substitui os dados de uma regra existente. As pontuações já
+// calculadas só mudam no próximo recálculo.
+func (r *repository) Atualizar(id int, updated Regra) (Regra, error) {
+	var regra Regra
+	if err := r.db.First(&regra, id).Error; err != nil {
+		return Regra{}, ErrRegraNaoEncontrada
+	}
+	updated.ID = id
+	updated.CreatedAt = regra.CreatedAt
+	err := r.db.Model(&regra).Select("*").Omit("id", "created_at", "deleted_at").Updates(updated).Error
+	return updated, err
+}
+
+// Deletar remove uma regra pelo ID.
+func (r *repository) Deletar(id int) error {
+	res := r.db.Delete(&Regra{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRegraNaoEncontrada
+	}
+	return nil
+}
+
+// ObterPontuacao retorna o último cálculo da pontuação da negociação ou empresa.
+func (r *repository) ObterPontuacao(entidade string, id int) (*Pontuacao, error) {
+	var p Pontuacao
+	if err := r.db.Where("entidade = ? AND entidade_id = ?", entidade, id).First(&p).Error; err != nil {
+		return nil, ErrEntidadeNaoEncontrada
+	}
+	return &p, nil
+}
@@ -0,0 +1,36 @@
+package pontuacao
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Validar confere a regra de pontuação: critério conhecido, valores nos
+// critérios que comparam campos da empresa e período na atividade recente.
+func Validar(r Regra) error {
+	if r.Nome == "" {
+		return errors.New("nome é obrigatório")
+	}
+	valido := false
+	for _, c := range Criterios {
+		valido = valido || c == r.Criterio
+	}
+	if !valido {
+		return fmt.Errorf("critério inválido: %q (use %s)", r.Criterio, strings.Join(Criterios, ", "))
+	}
+	switch r.Criterio {
+	case CriterioTamanhoEmpresa, CriterioFaixaFaturamento, CriterioSegmento:
+		if len(r.Valores) == 0 {
+			return fmt.Errorf("informe os valores do critério %s", r.Criterio)
+		}
+	case CriterioAtividade:
+		if r.Dias <= 0 {
+			return errors.New("dias deve ser maior que zero")
+		}
+	}
+	if r.Pontos == 0 {
+		return errors.New("pontos não pode ser zero")
+	}
+	return nil
+}
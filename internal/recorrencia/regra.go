@@ -0,0 +1,300 @@
+// Package recorrencia implementa o subconjunto de regras RRULE do iCalendar
+// (RFC 5545) usado pelas tarefas recorrentes: FREQ diária, semanal ou mensal,
+// INTERVAL, COUNT, UNTIL e BYDAY.
+package recorrencia
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequências suportadas.
+const (
+	Diaria  = "DAILY"
+	Semanal = "WEEKLY"
+	Mensal  = "MONTHLY"
+)
+
+// maxPeriodos limita a expansão de regras sem fim, evitando laços longos
+// para janelas muito distantes do início da série.
+const maxPeriodos = 50000
+
+// DiaSemana é um dia do BYDAY, opcionalmente com ordinal (ex: 1MO, -1FR),
+// aceito apenas na frequência mensal.
+type DiaSemana struct {
+	Ordinal int
+	Dia     time.Weekday
+}
+
+// Regra é uma regra de recorrência já interpretada.
+type Regra struct {
+	Frequencia string
+	Intervalo  int
+	Contagem   int
+	Ate        *time.Time
+	Dias       []DiaSemana
+}
+
+var siglasDias = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// Interpretar lê uma regra no formato RRULE, com ou sem o prefixo "RRULE:".
+// Datas UNTIL sem "Z" são interpretadas no fuso loc.
+func Interpretar(texto string, loc *time.Location) (Regra, error) {
+	texto = strings.TrimPrefix(strings.TrimSpace(texto), "RRULE:")
+	r := Regra{Intervalo: 1}
+	for _, parte := range strings.Split(texto, ";") {
+		if parte == "" {
+			continue
+		}
+		chave, valor, ok := strings.Cut(parte, "=")
+		if !ok {
+			return Regra{}, fmt.Errorf("recorrência inválida: %q", parte)
+		}
+		switch strings.ToUpper(chave) {
+		case "FREQ":
+			r.Frequencia = strings.ToUpper(valor)
+		case "INTERVAL":
+			n, err := strconv.Atoi(valor)
+			if err != nil || n < 1 {
+				return Regra{}, fmt.Errorf("INTERVAL inválido: %s", valor)
+			}
+			r.Intervalo = n
+		case "COUNT":
+			n, err := strconv.Atoi(valor)
+			if err != nil || n < 1 {
+				return Regra{}, fmt.Errorf("COUNT inválido: %s", valor)
+			}
+			r.Contagem = n
+		case "UNTIL":
+			ate, err := lerUntil(valor, loc)
+			if err != nil {
+				return Regra{}, err
+			}
+			r.Ate = &ate
+		case "BYDAY":
+			for _, d := range strings.Split(valor, ",") {
+				dia, err := lerDia(strings.ToUpper(strings.TrimSpace(d)))
+				if err != nil {
+					return Regra{}, err
+				}
+				r.Dias = append(r.Dias, dia)
+			}
+		case "WKST":
+			if strings.ToUpper(valor) != "MO" {
+				return Regra{}, errors.New("apenas WKST=MO é suportado")
+			}
+		default:
+			return Regra{}, fmt.Errorf("parâmetro de recorrência não suportado: %s", chave)
+		}
+	}
+	switch r.Frequencia {
+	case Diaria, Semanal, Mensal:
+	case "":
+		return Regra{}, errors.New("FREQ é obrigatório")
+	default:
+		return Regra{}, fmt.Errorf("FREQ não suportado: %s", r.Frequencia)
+	}
+	if r.Contagem > 0 && r.Ate != nil {
+		return Regra{}, errors.New("COUNT e UNTIL não podem ser usados juntos")
+	}
+	for _, d := range r.Dias {
+		if d.Ordinal != 0 && r.Frequencia != Mensal {
+			return Regra{}, errors.New("BYDAY com ordinal só é aceito com FREQ=MONTHLY")
+		}
+	}
+	return r, nil
+}
+
+func lerUntil(valor string, loc *time.Location) (time.Time, error) {
+	for _, formato := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		var t time.Time
+		var err error
+		if strings.HasSuffix(formato, "Z") {
+			t, err = time.Parse(formato, valor)
+		} else {
+			t, err = time.ParseInLocation(formato, valor, loc)
+		}
+		if err == nil {
+			if formato == "20060102" {
+				t = t.AddDate(0, 0, 1).Add(-time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("UNTIL inválido: %s", valor)
+}
+
+func lerDia(texto string) (DiaSemana, error) {
+	if len(texto) < 2 {
+		return DiaSemana{}, fmt.Errorf("BYDAY inválido: %s", texto)
+	}
+	dia, ok := siglasDias[texto[len(texto)-2:]]
+	if !ok {
+		return DiaSemana{}, fmt.Errorf("BYDAY inválido: %s", texto)
+	}
+	d := DiaSemana{Dia: dia}
+	if prefixo := texto[:len(texto)-2]; prefixo != "" {
+		n, err := strconv.Atoi(prefixo)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return DiaSemana{}, fmt.Errorf("BYDAY inválido: %s", texto)
+		}
+		d.Ordinal = n
+	}
+	return d, nil
+}
+
+// String devolve a regra no formato RRULE canônico.
+func (r Regra) String() string {
+	partes := []string{"FREQ=" + r.Frequencia}
+	if r.Intervalo > 1 {
+		partes = append(partes, "INTERVAL="+strconv.Itoa(r.Intervalo))
+	}
+	if r.Contagem > 0 {
+		partes = append(partes, "COUNT="+strconv.Itoa(r.Contagem))
+	}
+	if r.Ate != nil {
+		partes = append(partes, "UNTIL="+r.Ate.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.Dias) > 0 {
+		dias := make([]string, len(r.Dias))
+		for i, d := range r.Dias {
+			sigla := ""
+			for s, w := range siglasDias {
+				if w == d.Dia {
+					sigla = s
+				}
+			}
+			if d.Ordinal != 0 {
+				sigla = strconv.Itoa(d.Ordinal) + sigla
+			}
+			dias[i] = sigla
+		}
+		partes = append(partes, "BYDAY="+strings.Join(dias, ","))
+	}
+	return strings.Join(partes, ";")
+}
+
+// Ocorrencias retorna os inícios das ocorrências da série iniciada em inicio
+// que caem na janela [de, ate). O horário de parede de inicio é mantido em
+// todas as ocorrências, inclusive nas mudanças de horário de verão.
+func (r Regra) Ocorrencias(inicio, de, ate time.Time) []time.Time {
+	var resultado []time.Time
+	emitidas := 0
+	for periodo := 0; periodo < maxPeriodos; periodo++ {
+		candidatos := r.candidatos(inicio, periodo*r.Intervalo)
+		if len(candidatos) > 0 && !candidatos[0].Before(ate) {
+			break
+		}
+		for _, c := range candidatos {
+			if c.Before(inicio) {
+				continue
+			}
+			if r.Ate != nil && c.After(*r.Ate) {
+				return resultado
+			}
+			if !c.Before(ate) {
+				return resultado
+			}
+			emitidas++
+			if !c.Before(de) {
+				resultado = append(resultado, c)
+			}
+			if r.Contagem > 0 && emitidas >= r.Contagem {
+				return resultado
+			}
+		}
+	}
+	return resultado
+}
+
+// Contem informa se t é o início de uma ocorrência da série.
+func (r Regra) Contem(inicio, t time.Time) bool {
+	o := r.Ocorrencias(inicio, t, t.Add(time.Second))
+	return len(o) > 0 && o[0].Equal(t)
+}
+
+// candidatos retorna, em ordem, as datas candidatas do período deslocado de
+// desloc unidades (dias, semanas ou meses) a partir do início.
+func (r Regra) candidatos(inicio time.Time, desloc int) []time.Time {
+	loc := inicio.Location()
+	h, m, s := inicio.Clock()
+	em := func(ano int, mes time.Month, dia int) time.Time {
+		return time.Date(ano, mes, dia, h, m, s, inicio.Nanosecond(), loc)
+	}
+	switch r.Frequencia {
+	case Diaria:
+		d := em(inicio.Year(), inicio.Month(), inicio.Day()+desloc)
+		if len(r.Dias) > 0 && !r.temDia(d.Weekday()) {
+			return nil
+		}
+		return []time.Time{d}
+	case Semanal:
+		// Semanas começam na segunda-feira (WKST=MO).
+		recuo := (int(inicio.Weekday()) + 6) % 7
+		segunda := em(inicio.Year(), inicio.Month(), inicio.Day()-recuo+7*desloc)
+		if len(r.Dias) == 0 {
+			return []time.Time{em(segunda.Year(), segunda.Month(), segunda.Day()+recuo)}
+		}
+		var datas []time.Time
+		for i := 0; i < 7; i++ {
+			d := em(segunda.Year(), segunda.Month(), segunda.Day()+i)
+			if r.temDia(d.Weekday()) {
+				datas = append(datas, d)
+			}
+		}
+		return datas
+	default: // Mensal
+		primeiro := time.Date(inicio.Year(), inicio.Month()+time.Month(desloc), 1, 0, 0, 0, 0, loc)
+		ano, mes := primeiro.Year(), primeiro.Month()
+		ultimoDia := time.Date(ano, mes+1, 0, 0, 0, 0, 0, loc).Day()
+		if len(r.Dias) == 0 {
+			if inicio.Day() > ultimoDia {
+				return nil
+			}
+			return []time.Time{em(ano, mes, inicio.Day())}
+		}
+		var datas []time.Time
+		for _, ds := range r.Dias {
+			var dias []int
+			for dia := 1; dia <= ultimoDia; dia++ {
+				if time.Date(ano, mes, dia, 0, 0, 0, 0, loc).Weekday() == ds.Dia {
+					dias = append(dias, dia)
+				}
+			}
+			switch {
+			case ds.Ordinal > 0 && ds.Ordinal <= len(dias):
+				datas = append(datas, em(ano, mes, dias[ds.Ordinal-1]))
+			case ds.Ordinal < 0 && -ds.Ordinal <= len(dias):
+				datas = append(datas, em(ano, mes, dias[len(dias)+ds.Ordinal]))
+			case ds.Ordinal == 0:
+				for _, dia := range dias {
+					datas = append(datas, em(ano, mes, dia))
+				}
+			}
+		}
+		sort.Slice(datas, func(i, j int) bool { return datas[i].Before(datas[j]) })
+		unicas := datas[:0]
+		for i, d := range datas {
+			if i == 0 || !d.Equal(datas[i-1]) {
+				unicas = append(unicas, d)
+			}
+		}
+		return unicas
+	}
+}
+
+func (r Regra) temDia(d time.Weekday) bool {
+	for _, ds := range r.Dias {
+		if ds.Dia == d {
+			return true
+		}
+	}
+	return false
+}
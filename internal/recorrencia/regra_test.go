@@ -0,0 +1,161 @@
+package recorrencia
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInterpretar(t *testing.T) {
+	sp, _ := time.LoadLocation("America/Sao_Paulo")
+	casos := []struct {
+		texto    string
+		canonica string // Vazia quando a regra deve ser recusada
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"RRULE:freq=weekly;interval=2;byday=mo,we", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"},
+		{"FREQ=MONTHLY;COUNT=12", "FREQ=MONTHLY;COUNT=12"},
+		{"FREQ=MONTHLY;BYDAY=-1FR", "FREQ=MONTHLY;BYDAY=-1FR"},
+		{"FREQ=WEEKLY;UNTIL=20240131T235959Z;WKST=MO", "FREQ=WEEKLY;UNTIL=20240131T235959Z"},
+		{"FREQ=DAILY;UNTIL=20240131", "FREQ=DAILY;UNTIL=20240201T025959Z"},
+
+		{"", ""},
+		{"INTERVAL=2", ""},
+		{"FREQ=YEARLY", ""},
+		{"FREQ=DAILY;INTERVAL=0", ""},
+		{"FREQ=DAILY;COUNT=3;UNTIL=20240131", ""},
+		{"FREQ=WEEKLY;BYDAY=1MO", ""},
+		{"FREQ=MONTHLY;BYDAY=6MO", ""},
+		{"FREQ=MONTHLY;BYDAY=XX", ""},
+		{"FREQ=WEEKLY;WKST=SU", ""},
+		{"FREQ=DAILY;BYHOUR=9", ""},
+		{"FREQ", ""},
+	}
+	for _, c := range casos {
+		r, err := Interpretar(c.texto, sp)
+		if c.canonica == "" {
+			if err == nil {
+				t.Errorf("Interpretar(%q) = %s; esperado erro", c.texto, r)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Interpretar(%q): %v", c.texto, err)
+			continue
+		}
+		if got := r.String(); got != c.canonica {
+			t.Errorf("Interpretar(%q) = %s; esperado %s", c.texto, got, c.canonica)
+		}
+	}
+}
+
+func TestOcorrencias(t *testing.T) {
+	sp, _ := time.LoadLocation("America/Sao_Paulo")
+	ny, _ := time.LoadLocation("America/New_York")
+	data := func(loc *time.Location, ano int, mes time.Month, dia, hora, minuto int) time.Time {
+		return time.Date(ano, mes, dia, hora, minuto, 0, 0, loc)
+	}
+	casos := []struct {
+		nome     string
+		regra    string
+		inicio   time.Time
+		de, ate  time.Time
+		esperado []time.Time
+	}{
+		{
+			nome:   "diária com contagem",
+			regra:  "FREQ=DAILY;COUNT=3",
+			inicio: data(sp, 2024, 3, 4, 9, 0),
+			de:     data(sp, 2024, 3, 1, 0, 0), ate: data(sp, 2024, 4, 1, 0, 0),
+			esperado: []time.Time{data(sp, 2024, 3, 4, 9, 0), data(sp, 2024, 3, 5, 9, 0), data(sp, 2024, 3, 6, 9, 0)},
+		},
+		{
+			nome:   "a contagem inclui as ocorrências antes da janela",
+			regra:  "FREQ=DAILY;COUNT=3",
+			inicio: data(sp, 2024, 3, 4, 9, 0),
+			de:     data(sp, 2024, 3, 5, 12, 0), ate: data(sp, 2024, 4, 1, 0, 0),
+			esperado: []time.Time{data(sp, 2024, 3, 6, 9, 0)},
+		},
+		{
+			nome:   "semanal em dias alternados, a cada duas semanas",
+			regra:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR",
+			inicio: data(sp, 2024, 3, 6, 14, 30), // Quarta-feira
+			de:     data(sp, 2024, 3, 1, 0, 0), ate: data(sp, 2024, 3, 26, 0, 0),
+			esperado: []time.Time{data(sp, 2024, 3, 8, 14, 30), data(sp, 2024, 3, 18, 14, 30), data(sp, 2024, 3, 22, 14, 30)},
+		},
+		{
+			nome:   "mensal no dia 31 pula os meses mais curtos",
+			regra:  "FREQ=MONTHLY;COUNT=3",
+			inicio: data(sp, 2024, 1, 31, 10, 0),
+			de:     data(sp, 2024, 1, 1, 0, 0), ate: data(sp, 2025, 1, 1, 0, 0),
+			esperado: []time.Time{data(sp, 2024, 1, 31, 10, 0), data(sp, 2024, 3, 31, 10, 0), data(sp, 2024, 5, 31, 10, 0)},
+		},
+		{
+			nome:   "última sexta-feira do mês",
+			regra:  "FREQ=MONTHLY;BYDAY=-1FR",
+			inicio: data(sp, 2024, 1, 1, 8, 0),
+			de:     data(sp, 2024, 1, 1, 0, 0), ate: data(sp, 2024, 4, 1, 0, 0),
+			esperado: []time.Time{data(sp, 2024, 1, 26, 8, 0), data(sp, 2024, 2, 23, 8, 0), data(sp, 2024, 3, 29, 8, 0)},
+		},
+		{
+			nome:   "UNTIL é inclusivo",
+			regra:  "FREQ=WEEKLY;UNTIL=20240318T120000Z",
+			inicio: data(sp, 2024, 3, 4, 9, 0),
+			de:     data(sp, 2024, 3, 1, 0, 0), ate: data(sp, 2024, 4, 1, 0, 0),
+			esperado: []time.Time{data(sp, 2024, 3, 4, 9, 0), data(sp, 2024, 3, 11, 9, 0), data(sp, 2024, 3, 18, 9, 0)},
+		},
+		{
+			nome:   "mantém o horário de parede na mudança de horário de verão",
+			regra:  "FREQ=DAILY",
+			inicio: data(ny, 2024, 3, 9, 9, 0),
+			de:     data(ny, 2024, 3, 9, 0, 0), ate: data(ny, 2024, 3, 12, 0, 0),
+			esperado: []time.Time{data(ny, 2024, 3, 9, 9, 0), data(ny, 2024, 3, 10, 9, 0), data(ny, 2024, 3, 11, 9, 0)},
+		},
+		{
+			nome:   "série sem fim limitada pela janela",
+			regra:  "FREQ=DAILY;INTERVAL=10",
+			inicio: data(sp, 2020, 1, 1, 9, 0),
+			de:     data(sp, 2024, 1, 1, 0, 0), ate: data(sp, 2024, 1, 21, 0, 0),
+			esperado: []time.Time{data(sp, 2024, 1, 10, 9, 0), data(sp, 2024, 1, 20, 9, 0)},
+		},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			r, err := Interpretar(c.regra, c.inicio.Location())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := r.Ocorrencias(c.inicio, c.de, c.ate)
+			if len(got) != len(c.esperado) {
+				t.Fatalf("Ocorrencias = %v; esperado %v", got, c.esperado)
+			}
+			for i := range got {
+				if !got[i].Equal(c.esperado[i]) {
+					t.Errorf("ocorrência %d = %s; esperado %s", i, got[i], c.esperado[i])
+				}
+			}
+		})
+	}
+}
+
+func TestContem(t *testing.T) {
+	r, err := Interpretar("FREQ=WEEKLY;BYDAY=TU,TH", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inicio := time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC) // Terça-feira
+	casos := []struct {
+		t        time.Time
+		esperado bool
+	}{
+		{inicio, true},
+		{time.Date(2024, 3, 7, 9, 0, 0, 0, time.UTC), true},
+		{time.Date(2024, 3, 7, 10, 0, 0, 0, time.UTC), false},
+		{time.Date(2024, 3, 6, 9, 0, 0, 0, time.UTC), false},
+		{time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC), false},
+	}
+	for _, c := range casos {
+		if got := r.Contem(inicio, c.t); got != c.esperado {
+			t.Errorf("Contem(%s) = %v; esperado %v", c.t, got, c.esperado)
+		}
+	}
+}
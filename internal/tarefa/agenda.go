@@ -0,0 +1,132 @@
+package tarefa
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"my-crm-backend/internal/recorrencia"
+)
+
+// FormatoLocal é o formato de data e hora sem fuso aceito em InicioLocal e nos filtros.
+const FormatoLocal = "2006-01-02T15:04"
+
+// ErrInicioObrigatorio indica uma tarefa sem data de início.
+var ErrInicioObrigatorio = errors.New("inicio é obrigatório")
+
+// Fusos resolve o fuso horário de um responsável pelo seu login.
+type Fusos interface {
+	Fuso(login string) (*time.Location, error)
+}
+
+// Preparar valida a prioridade e a situação e normaliza o agendamento da tarefa
+// usando o fuso do responsável quando a tarefa não informar o seu.
+func Preparar(t *Tarefa, fusos Fusos) error {
+	if err := ValidarPrioridade(t); err != nil {
+		return err
+	}
+	if err := NormalizarStatus(t, time.Now()); err != nil {
+		return err
+	}
+	padrao, err := fusos.Fuso(t.Responsavel)
+	if err != nil {
+		return err
+	}
+	return Agendar(t, padrao)
+}
+
+// Agendar valida e normaliza os campos de agendamento da tarefa:
+//   - o fuso é o FusoHorario da tarefa ou, se vazio, padrao;
+//   - InicioLocal, se informado, é interpretado nesse fuso e substitui Inicio;
+//   - tarefas de dia inteiro começam à meia-noite e duram dias completos;
+//   - se Fim for informado, a duração é recalculada; senão Fim = Inicio + DuracaoMinutos.
+func Agendar(t *Tarefa, padrao *time.Location) error {
+	loc := padrao
+	if t.FusoHorario != "" {
+		l, err := time.LoadLocation(t.FusoHorario)
+		if err != nil {
+			return fmt.Errorf("fuso horário inválido: %s", t.FusoHorario)
+		}
+		loc = l
+	}
+	t.FusoHorario = loc.String()
+
+	if t.Recorrencia != "" {
+		regra, err := recorrencia.Interpretar(t.Recorrencia, loc)
+		if err != nil {
+			return err
+		}
+		t.Recorrencia = regra.String()
+	}
+
+	if t.InicioLocal != "" {
+		inicio, err := time.ParseInLocation(FormatoLocal, strings.TrimSpace(t.InicioLocal), loc)
+		if err != nil {
+			return fmt.Errorf("inicio_local inválido, use o formato AAAA-MM-DDTHH:MM: %s", t.InicioLocal)
+		}
+		t.Inicio = inicio
+		t.InicioLocal = ""
+	}
+	if t.Inicio.IsZero() {
+		return ErrInicioObrigatorio
+	}
+	t.Inicio = t.Inicio.In(loc)
+	if t.DuracaoMinutos < 0 {
+		return errors.New("duracao_minutos não pode ser negativa")
+	}
+
+	if t.DiaInteiro {
+		t.Inicio = time.Date(t.Inicio.Year(), t.Inicio.Month(), t.Inicio.Day(), 0, 0, 0, 0, loc)
+		dias := 1
+		if !t.Fim.IsZero() {
+			// O fim é exclusivo: terminar à meia-noite não ocupa o dia seguinte.
+			fim := t.Fim.In(loc)
+			primeiro := time.Date(t.Inicio.Year(), t.Inicio.Month(), t.Inicio.Day(), 0, 0, 0, 0, time.UTC)
+			ultimo := time.Date(fim.Year(), fim.Month(), fim.Day(), 0, 0, 0, 0, time.UTC)
+			dias = int(ultimo.Sub(primeiro).Hours() / 24)
+			if !fim.Equal(time.Date(fim.Year(), fim.Month(), fim.Day(), 0, 0, 0, 0, loc)) {
+				dias++
+			}
+			if dias < 1 {
+				dias = 1
+			}
+		} else if t.DuracaoMinutos > 0 {
+			dias = (t.DuracaoMinutos + 24*60 - 1) / (24 * 60)
+		}
+		t.Fim = t.Inicio.AddDate(0, 0, dias)
+		t.DuracaoMinutos = dias * 24 * 60
+		return nil
+	}
+
+	if !t.Fim.IsZero() {
+		if t.Fim.Before(t.Inicio) {
+			return errors.New("fim não pode ser anterior ao início")
+		}
+		t.Fim = t.Fim.In(loc)
+		t.DuracaoMinutos = int(t.Fim.Sub(t.Inicio) / time.Minute)
+		return nil
+	}
+	t.Fim = t.Inicio.Add(time.Duration(t.DuracaoMinutos) * time.Minute)
+	return nil
+}
+
+// LerInstante interpreta um limite de filtro de agenda. Aceita RFC 3339,
+// FormatoLocal ou apenas a data ("AAAA-MM-DD"), no fuso loc. Para uma data sem
+// hora, fimDoDia devolve a meia-noite do dia seguinte, de modo que "ate" inclua o dia todo.
+func LerInstante(valor string, loc *time.Location, fimDoDia bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, valor); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(FormatoLocal, valor, loc); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", valor, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("data inválida: %s", valor)
+	}
+	if fimDoDia {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,116 @@
+package tarefa
+
+import (
+	"fmt"
+	"time"
+)
+
+// Prioridades das tarefas, da menor para a maior.
+const (
+	PrioridadeBaixa   = "baixa"
+	PrioridadeNormal  = "normal"
+	PrioridadeAlta    = "alta"
+	PrioridadeUrgente = "urgente"
+)
+
+// Prioridades lista as prioridades válidas em ordem crescente.
+var Prioridades = []string{PrioridadeBaixa, PrioridadeNormal, PrioridadeAlta, PrioridadeUrgente}
+
+// ValidarPrioridade confere a prioridade, usando PrioridadeNormal quando vazia.
+func ValidarPrioridade(t *Tarefa) error {
+	if t.Prioridade == "" {
+		t.Prioridade = PrioridadeNormal
+		return nil
+	}
+	for _, p := range Prioridades {
+		if p == t.Prioridade {
+			return nil
+		}
+	}
+	return fmt.Errorf("prioridade inválida: %s", t.Prioridade)
+}
+
+// ProximaPrioridade retorna a prioridade imediatamente acima de p; urgente se mantém.
+func ProximaPrioridade(p string) string {
+	for i, atual := range Prioridades {
+		if atual == p && i+1 < len(Prioridades) {
+			return Prioridades[i+1]
+		}
+	}
+	return PrioridadeUrgente
+}
+
+// EstaAtrasada informa se a tarefa passou do horário sem ser concluída ou
+// cancelada; numa série, se a sua última ocorrência está atrasada.
+func (t Tarefa) EstaAtrasada(agora time.Time) bool {
+	_, ok := t.InstanciaAtrasada(agora)
+	return ok
+}
+
+// InstanciaAtrasada retorna o que está atrasado na tarefa aberta: a própria
+// tarefa, se o seu fim já passou, ou, numa série, a última ocorrência iniciada
+// antes de agora, se ela não foi concluída nem excluída e o seu fim já passou.
+// As alterações das ocorrências (Ocorrencias) devem estar carregadas.
+func (t Tarefa) InstanciaAtrasada(agora time.Time) (Tarefa, bool) {
+	if !t.Aberta() {
+		return Tarefa{}, false
+	}
+	if t.Recorrencia == "" {
+		return t, !t.Fim.IsZero() && t.Fim.Before(agora)
+	}
+	r, loc, err := t.regra()
+	if err != nil {
+		return Tarefa{}, false
+	}
+	originais := r.Ocorrencias(t.Inicio.In(loc), time.Time{}, agora)
+	if len(originais) == 0 {
+		return Tarefa{}, false
+	}
+	original := originais[len(originais)-1]
+	var alteracao *Ocorrencia
+	for i := range t.Ocorrencias {
+		if t.Ocorrencias[i].Original.Equal(original) {
+			alteracao = &t.Ocorrencias[i]
+		}
+	}
+	inst, ok := t.instancia(original, alteracao, loc)
+	if !ok || inst.Concluida || !inst.Fim.Before(agora) {
+		return Tarefa{}, false
+	}
+	return inst, true
+}
+
+// MarcarAtraso preenche Atrasada e OcorrenciaAtrasada a partir de InstanciaAtrasada.
+func (t *Tarefa) MarcarAtraso(agora time.Time) {
+	inst, ok := t.InstanciaAtrasada(agora)
+	t.Atrasada, t.OcorrenciaAtrasada = ok, nil
+	if ok {
+		t.OcorrenciaAtrasada = inst.Ocorrencia
+	}
+}
+
+// Ações de escalonamento registradas nas tarefas.
+const (
+	AcaoNotificarGestor    = "notificar_gestor"
+	AcaoReatribuir         = "reatribuir"
+	AcaoAumentarPrioridade = "aumentar_prioridade"
+)
+
+// Escalonamento registra uma ação tomada sobre uma tarefa atrasada por uma regra
+// de escalonamento. Cada regra é aplicada no máximo uma vez por tarefa ou, nas
+// séries, por ocorrência (índice único criado em MigrarIndiceEscalonamentos).
+type Escalonamento struct {
+	ID         int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	TarefaID   int        `json:"tarefa_id" gorm:"not null;index"`
+	RegraID    int        `json:"regra_id" gorm:"not null"`
+	Ocorrencia *time.Time `json:"ocorrencia,omitempty"` // Início original da ocorrência atrasada, nas séries
+	Acao       string     `json:"acao"`
+	Detalhes   string     `json:"detalhes,omitempty"`
+	DiasAtraso int        `json:"dias_atraso"`
+	Data       time.Time  `json:"data"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Escalonamento) TableName() string {
+	return "tarefa_escalonamentos"
+}
@@ -3,18 +3,29 @@ package tarefa
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Negociacoes preenche na tarefa os dados derivados da negociação vinculada: a
+// empresa e os nomes desnormalizados.
+type Negociacoes interface {
+	VincularTarefa(negociacaoID int, t *Tarefa) error
+}
+
 // Handler define os manipuladores HTTP para as operações de tarefa.
 type Handler struct {
-	repo Repository
+	repo        Repository
+	fusos       Fusos
+	negociacoes Negociacoes
 }
 
-// NovoHandler cria um novo handler para Tarefa.
-func NovoHandler(repo Repository) *Handler {
-	return &Handler{repo: repo}
+// NovoHandler cria um novo handler para Tarefa. fusos resolve o fuso horário
+// do responsável quando a tarefa não informa o seu; negociacoes, os dados das
+// tarefas vinculadas a uma negociação, que prevalecem sobre os enviados.
+func NovoHandler(repo Repository, fusos Fusos, negociacoes Negociacoes) *Handler {
+	return &Handler{repo: repo, fusos: fusos, negociacoes: negociacoes}
 }
 
 // CriarTarefa cria uma nova tarefa.
@@ -24,9 +35,19 @@ func (h *Handler) CriarTarefa(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if t.NegociacaoID != 0 {
+		if err := h.negociacoes.VincularTarefa(t.NegociacaoID, &t); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
 	// Validação dos campos obrigatórios
-	if t.EmpresaID == 0 || t.Negociacao == "" || t.Assunto == "" || t.Responsavel == "" || t.Tipo == "" || t.DataAgendamento.IsZero() || t.Horario == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Campos obrigatórios: EmpresaID, Negociacao, Assunto, Responsavel, Tipo, DataAgendamento, Horario"})
+	if t.EmpresaID == 0 || t.Negociacao == "" || t.Assunto == "" || t.Responsavel == "" || t.Tipo == "" || (t.Inicio.IsZero() && t.InicioLocal == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Campos obrigatórios: EmpresaID, Negociacao, Assunto, Responsavel, Tipo, Inicio (ou InicioLocal)"})
+		return
+	}
+	if err := Preparar(&t, h.fusos); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	novaTarefa, err := h.repo.Adicionar(t)
@@ -37,9 +58,51 @@ func (h *Handler) CriarTarefa(c *gin.Context) {
 	c.JSON(http.StatusCreated, novaTarefa)
 }
 
-// ListarTarefas retorna todas as tarefas.
+// ListarTarefas retorna as tarefas, opcionalmente restritas a uma janela da agenda:
+// GET /api/tarefas?de=2024-05-01&ate=2024-05-31&responsavel=joao
+// "de" e "ate" aceitam RFC 3339, "AAAA-MM-DDTHH:MM" ou "AAAA-MM-DD"; sem fuso explícito,
+// são interpretados no fuso informado em ?fuso= ou, na falta dele, no do responsável.
+// Com "ate" informado, as tarefas recorrentes são expandidas em ocorrências.
 func (h *Handler) ListarTarefas(c *gin.Context) {
-	tarefas, err := h.repo.Listar()
+	filtro := Filtro{Responsavel: c.Query("responsavel")}
+	loc, err := h.fusos.Fuso(filtro.Responsavel)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if fuso := c.Query("fuso"); fuso != "" {
+		if loc, err = time.LoadLocation(fuso); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "fuso horário inválido: " + fuso})
+			return
+		}
+	}
+	if de := c.Query("de"); de != "" {
+		t, err := LerInstante(de, loc, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filtro.De = &t
+	}
+	if ate := c.Query("ate"); ate != "" {
+		t, err := LerInstante(ate, loc, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filtro.Ate = &t
+	}
+	tarefas, err := h.repo.Listar(filtro)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tarefas)
+}
+
+// ListarAtrasadas retorna as tarefas atrasadas: GET /api/tarefas/atrasadas?responsavel=joao
+func (h *Handler) ListarAtrasadas(c *gin.Context) {
+	tarefas, err := h.repo.ListarAtrasadas(c.Query("responsavel"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -71,12 +134,116 @@ func (h *Handler) AtualizarTarefa(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
 		return
 	}
-	var updated Tarefa
+	atual, err := h.repo.ObterPorID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	// Os campos enviados sobrescrevem a tarefa atual. O fim é recalculado a partir
+	// da duração, a menos que o próprio pedido informe um novo fim, e o fuso passa
+	// a ser o do novo responsável, a menos que o pedido informe um fuso.
+	updated := *atual
+	updated.Fim = time.Time{}
+	updated.FusoHorario = ""
 	if err := c.ShouldBindJSON(&updated); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	tarefaAtualizada, err := h.repo.Atualizar(id, updated)
+	updated.ID = id
+	if updated.NegociacaoID != 0 {
+		if err := h.negociacoes.VincularTarefa(updated.NegociacaoID, &updated); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if updated.FusoHorario == "" && updated.Responsavel == atual.Responsavel {
+		updated.FusoHorario = atual.FusoHorario
+	}
+	// Compatibilidade: quem só altera "concluida" também muda a situação.
+	if updated.Concluida != atual.Concluida && updated.Status == atual.Status {
+		updated.Status = StatusPendente
+		if updated.Concluida {
+			updated.Status = StatusConcluida
+		}
+	}
+	h.salvar(c, updated)
+}
+
+// AtualizarStatus muda a situação da tarefa e registra o resultado.
+// Espera receber um JSON como: {"status": "concluida", "resultado": "atendeu", "usuario": "joao"}
+func (h *Handler) AtualizarStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	var payload struct {
+		Status    string `json:"status"`
+		Resultado string `json:"resultado"`
+		Usuario   string `json:"usuario"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil || payload.Status == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status é obrigatório"})
+		return
+	}
+	t, err := h.repo.ObterPorID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	t.Status = payload.Status
+	if payload.Resultado != "" {
+		t.Resultado = payload.Resultado
+	}
+	t.AlteradoPor = payload.Usuario
+	h.salvar(c, *t)
+}
+
+// Reagendar muda o início da tarefa e registra o reagendamento no histórico.
+// Espera receber um JSON como: {"inicio_local": "2024-05-12T10:00", "motivo": "Cliente pediu", "usuario": "joao"}
+// A duração é mantida, a menos que "duracao_minutos" seja informado.
+func (h *Handler) Reagendar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	var payload struct {
+		Inicio         time.Time `json:"inicio"`
+		InicioLocal    string    `json:"inicio_local"`
+		DuracaoMinutos int       `json:"duracao_minutos"`
+		Motivo         string    `json:"motivo"`
+		Usuario        string    `json:"usuario"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil || (payload.Inicio.IsZero() && payload.InicioLocal == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "informe inicio ou inicio_local"})
+		return
+	}
+	t, err := h.repo.ObterPorID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if !t.Aberta() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "apenas tarefas em aberto podem ser reagendadas"})
+		return
+	}
+	t.Inicio, t.InicioLocal, t.Fim = payload.Inicio, payload.InicioLocal, time.Time{}
+	if payload.DuracaoMinutos > 0 {
+		t.DuracaoMinutos = payload.DuracaoMinutos
+	}
+	t.AlteradoPor = payload.Usuario
+	t.MotivoReagendamento = payload.Motivo
+	h.salvar(c, *t)
+}
+
+// salvar prepara e grava a tarefa alterada, respondendo com o resultado.
+func (h *Handler) salvar(c *gin.Context, t Tarefa) {
+	if err := Preparar(&t, h.fusos); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tarefaAtualizada, err := h.repo.Atualizar(t.ID, t)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -98,3 +265,155 @@ func (h *Handler) DeletarTarefa(c *gin.Context) {
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// ListarOcorrencias retorna as ocorrências de uma tarefa recorrente na janela
+// ?de=&ate= (padrão: de hoje até 90 dias depois), com as alterações aplicadas.
+func (h *Handler) ListarOcorrencias(c *gin.Context) {
+	t, loc, ok := h.obterSerie(c)
+	if !ok {
+		return
+	}
+	de := time.Now().In(loc)
+	de = time.Date(de.Year(), de.Month(), de.Day(), 0, 0, 0, 0, loc)
+	if v := c.Query("de"); v != "" {
+		var err error
+		if de, err = LerInstante(v, loc, false); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	ate := de.AddDate(0, 0, 90)
+	if v := c.Query("ate"); v != "" {
+		var err error
+		if ate, err = LerInstante(v, loc, true); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	ocorrencias, err := h.repo.ListarOcorrencias(t.ID, de, ate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ocorrencias)
+}
+
+// AtualizarOcorrencia altera uma única ocorrência da série, identificada pelo seu
+// início original em :data (RFC 3339 ou "AAAA-MM-DDTHH:MM" no fuso da tarefa).
+// Espera receber um JSON com os campos a alterar, ex: {"concluida": true} ou
+// {"inicio": "2024-05-10T15:00:00-03:00", "assunto": "Remarcada"}. Os campos
+// omitidos mantêm as alterações anteriores da ocorrência; null ou "" as desfaz.
+func (h *Handler) AtualizarOcorrencia(c *gin.Context) {
+	t, loc, ok := h.obterSerie(c)
+	if !ok {
+		return
+	}
+	original, err := LerInstante(c.Param("data"), loc, false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	o := ocorrenciaAtual(t, original)
+	if err := c.ShouldBindJSON(&o); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	o.Original = original
+	o.Excluida = false
+	salva, err := h.repo.DefinirOcorrencia(t.ID, o)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, salva)
+}
+
+// ExcluirOcorrencia transforma uma ocorrência da série em data de exceção.
+func (h *Handler) ExcluirOcorrencia(c *gin.Context) {
+	t, loc, ok := h.obterSerie(c)
+	if !ok {
+		return
+	}
+	original, err := LerInstante(c.Param("data"), loc, false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	o := ocorrenciaAtual(t, original)
+	o.Excluida = true
+	if _, err := h.repo.DefinirOcorrencia(t.ID, o); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ocorrenciaAtual retorna as alterações já gravadas da ocorrência iniciada
+// originalmente em original, ou uma ocorrência sem alterações.
+func ocorrenciaAtual(t *Tarefa, original time.Time) Ocorrencia {
+	for _, o := range t.Ocorrencias {
+		if o.Original.Equal(original) {
+			return o
+		}
+	}
+	return Ocorrencia{Original: original}
+}
+
+// obterSerie carrega a tarefa recorrente do parâmetro :id e o seu fuso horário.
+// Em caso de erro, escreve a resposta e retorna ok falso.
+func (h *Handler) obterSerie(c *gin.Context) (*Tarefa, *time.Location, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return nil, nil, false
+	}
+	t, err := h.repo.ObterPorID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+	if t.Recorrencia == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrNaoRecorrente.Error()})
+		return nil, nil, false
+	}
+	loc, err := time.LoadLocation(t.FusoHorario)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+	return t, loc, true
+}
+
+// Relatorio retorna as taxas de conclusão e os resultados das tarefas agrupados
+// por responsável ou por tipo: GET /api/tarefas/relatorio?agrupar=tipo&de=2024-05-01&ate=2024-05-31
+func (h *Handler) Relatorio(c *gin.Context) {
+	agrupar := c.DefaultQuery("agrupar", "responsavel")
+	loc, err := h.fusos.Fuso("")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var de, ate *time.Time
+	if v := c.Query("de"); v != "" {
+		t, err := LerInstante(v, loc, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		de = &t
+	}
+	if v := c.Query("ate"); v != "" {
+		t, err := LerInstante(v, loc, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ate = &t
+	}
+	linhas, err := h.repo.Relatorio(agrupar, de, ate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, linhas)
+}
@@ -0,0 +1,127 @@
+package tarefa
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// horarioLegado aceita "H:MM", "HH:MM" ou "HH:MM:SS".
+var horarioLegado = regexp.MustCompile(`^([01]?\d|2[0-3]):([0-5]\d)(:[0-5]\d)?$`)
+
+// MigrarAgendamentoLegado converte as colunas antigas "data_agendamento" e "horario"
+// no início com fuso horário, interpretando-as no fuso padrão. Tarefas com horário
+// vazio ou inválido viram tarefas de dia inteiro; o texto inválido é preservado na
+// descrição. Retorna o total migrado e os IDs com horário inválido. Sem as colunas
+// antigas, nada é feito.
+func MigrarAgendamentoLegado(db *gorm.DB, fuso *time.Location) (int, []int, error) {
+	if !db.Migrator().HasColumn(&Tarefa{}, "horario") {
+		return 0, nil, nil
+	}
+	migradas := 0
+	invalidas := []int{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var linhas []struct {
+			ID              int
+			DataAgendamento *time.Time
+			Horario         *string
+			Descricao       string
+			CreatedAt       time.Time
+		}
+		if err := tx.Table("tarefas").
+			Select("id", "data_agendamento", "horario", "descricao", "created_at").
+			Where("inicio IS NULL OR inicio = ?", time.Time{}).
+			Scan(&linhas).Error; err != nil {
+			return err
+		}
+		for _, l := range linhas {
+			data := l.CreatedAt.In(fuso)
+			if l.DataAgendamento != nil && !l.DataAgendamento.IsZero() {
+				// As datas antigas foram gravadas à meia-noite UTC: o dia é o da data em UTC.
+				d := l.DataAgendamento.UTC()
+				data = time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, fuso)
+			}
+			t := Tarefa{FusoHorario: fuso.String(), Descricao: l.Descricao}
+			horario := ""
+			if l.Horario != nil {
+				horario = strings.TrimSpace(*l.Horario)
+			}
+			if m := horarioLegado.FindStringSubmatch(horario); m != nil {
+				hora, _ := strconv.Atoi(m[1])
+				minuto, _ := strconv.Atoi(m[2])
+				t.Inicio = time.Date(data.Year(), data.Month(), data.Day(), hora, minuto, 0, 0, fuso)
+			} else {
+				t.Inicio = data
+				t.DiaInteiro = true
+				if horario != "" {
+					invalidas = append(invalidas, l.ID)
+					t.Descricao = strings.TrimSpace(t.Descricao + "\n(horário original: " + horario + ")")
+				}
+			}
+			if err := Agendar(&t, fuso); err != nil {
+				return err
+			}
+			if err := tx.Table("tarefas").Where("id = ?", l.ID).UpdateColumns(map[string]interface{}{
+				"inicio":          t.Inicio,
+				"fim":             t.Fim,
+				"duracao_minutos": t.DuracaoMinutos,
+				"dia_inteiro":     t.DiaInteiro,
+				"fuso_horario":    t.FusoHorario,
+				"descricao":       t.Descricao,
+			}).Error; err != nil {
+				return err
+			}
+			migradas++
+		}
+		if err := tx.Migrator().DropColumn(&Tarefa{}, "horario"); err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&Tarefa{}, "data_agendamento")
+	})
+	return migradas, invalidas, err
+}
+
+// MigrarStatus preenche a situação das tarefas criadas antes dela a partir dos
+// antigos indicadores "concluida" e "cancelada", removendo o último. É idempotente.
+func MigrarStatus(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		cancelada := "FALSE"
+		if tx.Migrator().HasColumn(&Tarefa{}, "cancelada") {
+			cancelada = "cancelada"
+		}
+		if err := tx.Exec(`UPDATE tarefas SET status = CASE
+				WHEN `+cancelada+` THEN ?
+				WHEN concluida THEN ?
+				ELSE ? END
+			WHERE status IS NULL OR status = ''`,
+			StatusCancelada, StatusConcluida, StatusPendente).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`UPDATE tarefas SET concluida_em = updated_at
+			WHERE status = ? AND concluida_em IS NULL`, StatusConcluida).Error; err != nil {
+			return err
+		}
+		if cancelada == "cancelada" {
+			return tx.Migrator().DropColumn(&Tarefa{}, "cancelada")
+		}
+		return nil
+	})
+}
+
+// MigrarIndiceEscalonamentos troca o índice único dos escalonamentos de
+// (tarefa, regra) por (tarefa, regra, ocorrência), para que cada ocorrência
+// atrasada de uma série possa ser escalonada. É idempotente.
+func MigrarIndiceEscalonamentos(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if tx.Migrator().HasIndex(&Escalonamento{}, "idx_tarefa_regra") {
+			if err := tx.Migrator().DropIndex(&Escalonamento{}, "idx_tarefa_regra"); err != nil {
+				return err
+			}
+		}
+		return tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_tarefa_regra_ocorrencia
+			ON tarefa_escalonamentos (tarefa_id, regra_id, COALESCE(ocorrencia, 'epoch'))`).Error
+	})
+}
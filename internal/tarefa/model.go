@@ -10,19 +10,41 @@ import (
 
 // Tarefa representa os dados de uma tarefa.
 type Tarefa struct {
-	ID                int             `json:"id" gorm:"primaryKey;autoIncrement"`
-	NegociacaoID      int             `json:"negociacao_id"`
-	EmpresaID         int             `json:"empresa_id"` // Nova coluna que referencia a empresa da negociação
-	Empresa           empresa.Empresa `json:"empresa" gorm:"foreignKey:EmpresaID;references:ID"`
-	EmpresaNegociacao string          `json:"empresa_negociacao,omitempty"` // Nome ou info da empresa, se necessário
-	Negociacao        string          `json:"negociacao"`                   // Campo obrigatório que representa a negociação
-	Assunto           string          `json:"assunto"`                      // Campo obrigatório
-	Descricao         string          `json:"descricao,omitempty"`          // Descrição opcional
-	Responsavel       string          `json:"responsavel"`                  // Campo obrigatório
-	Tipo              string          `json:"tipo"`                         // Campo obrigatório
-	DataAgendamento   time.Time       `json:"data_agendamento"`             // Campo obrigatório
-	Horario           string          `json:"horario"`                      // Campo obrigatório (ex: "HH:MM")
-	Concluida         bool            `json:"concluida"`                    // Indica se a tarefa foi concluída
+	ID                 int             `json:"id" gorm:"primaryKey;autoIncrement"`
+	NegociacaoID       int             `json:"negociacao_id"`
+	EmpresaID          int             `json:"empresa_id"` // Nova coluna que referencia a empresa da negociação
+	Empresa            empresa.Empresa `json:"empresa" gorm:"foreignKey:EmpresaID;references:ID"`
+	EmpresaNegociacao  string          `json:"empresa_negociacao,omitempty"`    // Nome ou info da empresa, se necessário
+	Negociacao         string          `json:"negociacao"`                      // Campo obrigatório que representa a negociação
+	Assunto            string          `json:"assunto"`                         // Campo obrigatório
+	Descricao          string          `json:"descricao,omitempty"`             // Descrição opcional
+	Responsavel        string          `json:"responsavel"`                     // Campo obrigatório
+	Tipo               string          `json:"tipo"`                            // Campo obrigatório
+	Inicio             time.Time       `json:"inicio" gorm:"index"`             // Campo obrigatório: início agendado, com fuso
+	InicioLocal        string          `json:"inicio_local,omitempty" gorm:"-"` // Alternativa a Inicio na entrada: "AAAA-MM-DDTHH:MM" no FusoHorario
+	Fim                time.Time       `json:"fim" gorm:"index"`                // Calculado a partir da duração, se não informado
+	DuracaoMinutos     int             `json:"duracao_minutos"`
+	DiaInteiro         bool            `json:"dia_inteiro"`
+	FusoHorario        string          `json:"fuso_horario"`        // Nome IANA; vazio usa o fuso do responsável
+	Status             string          `json:"status" gorm:"index"` // pendente, em_andamento, concluida, cancelada ou reagendada
+	Concluida          bool            `json:"concluida"`           // Indica se a tarefa foi concluída; mantido a partir do Status
+	ConcluidaEm        *time.Time      `json:"concluida_em,omitempty"`
+	ConcluidaPor       string          `json:"concluida_por,omitempty"`
+	Resultado          string          `json:"resultado,omitempty"`           // atendeu, nao_atendeu, reuniao_realizada ou no_show
+	Prioridade         string          `json:"prioridade"`                    // baixa, normal, alta ou urgente
+	Atrasada           bool            `json:"atrasada" gorm:"index"`         // Mantido pela verificação periódica de atrasos
+	OcorrenciaAtrasada *time.Time      `json:"ocorrencia_atrasada,omitempty"` // Início original da ocorrência atrasada, nas séries
+	PlaybookID         *int            `json:"playbook_id,omitempty"`         // Playbook que criou a tarefa, se houver
+	PlaybookEtapa      string          `json:"playbook_etapa,omitempty"`      // Etapa do funil cuja entrada criou a tarefa
+	Recorrencia        string          `json:"recorrencia,omitempty"`         // Regra RRULE (ex: "FREQ=MONTHLY;COUNT=12"); vazia para tarefas únicas
+	Ocorrencias        []Ocorrencia    `json:"ocorrencias,omitempty" gorm:"foreignKey:TarefaID"`
+	Ocorrencia         *time.Time      `json:"ocorrencia,omitempty" gorm:"-"` // Início original, nas ocorrências expandidas de uma série
+	Escalonamentos     []Escalonamento `json:"escalonamentos,omitempty" gorm:"foreignKey:TarefaID"`
+	Reagendamentos     []Reagendamento `json:"reagendamentos,omitempty" gorm:"foreignKey:TarefaID"`
+
+	// Dados da alteração em curso, usados no histórico; não são gravados na tarefa.
+	AlteradoPor         string `json:"alterado_por,omitempty" gorm:"-"`
+	MotivoReagendamento string `json:"motivo_reagendamento,omitempty" gorm:"-"`
 
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
@@ -33,3 +55,12 @@ type Tarefa struct {
 func (Tarefa) TableName() string {
 	return "tarefas"
 }
+
+// AfterFind apresenta o início e o fim no fuso horário da tarefa.
+func (t *Tarefa) AfterFind(tx *gorm.DB) error {
+	if loc, err := time.LoadLocation(t.FusoHorario); err == nil && t.FusoHorario != "" {
+		t.Inicio = t.Inicio.In(loc)
+		t.Fim = t.Fim.In(loc)
+	}
+	return nil
+}
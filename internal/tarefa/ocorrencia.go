@@ -0,0 +1,148 @@
+package tarefa
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"my-crm-backend/internal/recorrencia"
+)
+
+// Ocorrencia guarda as alterações de uma única ocorrência de uma tarefa recorrente,
+// identificada pelo seu início original na série. Uma ocorrência excluída é uma
+// data de exceção (EXDATE) e não aparece na agenda.
+type Ocorrencia struct {
+	ID        int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	TarefaID  int        `json:"tarefa_id" gorm:"not null;uniqueIndex:idx_tarefa_ocorrencia"`
+	Original  time.Time  `json:"original" gorm:"not null;uniqueIndex:idx_tarefa_ocorrencia"`
+	Excluida  bool       `json:"excluida"`
+	Concluida bool       `json:"concluida"`
+	Inicio    *time.Time `json:"inicio,omitempty"` // Remarcação da ocorrência
+	Fim       *time.Time `json:"fim,omitempty"`
+	Assunto   string     `json:"assunto,omitempty"`
+	Descricao string     `json:"descricao,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Ocorrencia) TableName() string {
+	return "tarefa_ocorrencias"
+}
+
+// ErrNaoRecorrente indica uma operação de ocorrência sobre uma tarefa única.
+var ErrNaoRecorrente = errors.New("a tarefa não é recorrente")
+
+// regra retorna a regra de recorrência da tarefa e o seu fuso horário.
+func (t Tarefa) regra() (recorrencia.Regra, *time.Location, error) {
+	loc, err := time.LoadLocation(t.FusoHorario)
+	if err != nil {
+		return recorrencia.Regra{}, nil, err
+	}
+	if t.Recorrencia == "" {
+		return recorrencia.Regra{}, loc, ErrNaoRecorrente
+	}
+	r, err := recorrencia.Interpretar(t.Recorrencia, loc)
+	return r, loc, err
+}
+
+// ContemOcorrencia informa se original é o início de uma ocorrência da série.
+func (t Tarefa) ContemOcorrencia(original time.Time) (bool, error) {
+	r, loc, err := t.regra()
+	if err != nil {
+		return false, err
+	}
+	return r.Contem(t.Inicio.In(loc), original.In(loc)), nil
+}
+
+// fimDe calcula o fim de uma ocorrência iniciada em inicio, preservando dias
+// completos nas tarefas de dia inteiro.
+func (t Tarefa) fimDe(inicio time.Time) time.Time {
+	if t.DiaInteiro {
+		return inicio.AddDate(0, 0, t.DuracaoMinutos/(24*60))
+	}
+	return inicio.Add(time.Duration(t.DuracaoMinutos) * time.Minute)
+}
+
+// Expandir retorna as ocorrências da tarefa que se sobrepõem à janela [de, ate),
+// com as alterações individuais aplicadas e as exceções removidas. Uma tarefa
+// única retorna a si mesma, se estiver na janela.
+func (t Tarefa) Expandir(de, ate time.Time) ([]Tarefa, error) {
+	if t.Recorrencia == "" {
+		if sobrepoe(t, de, ate) {
+			return []Tarefa{t}, nil
+		}
+		return nil, nil
+	}
+	r, loc, err := t.regra()
+	if err != nil {
+		return nil, err
+	}
+	alteracoes := make(map[int64]Ocorrencia, len(t.Ocorrencias))
+	for _, o := range t.Ocorrencias {
+		alteracoes[o.Original.Unix()] = o
+	}
+
+	// Recua a janela pela duração para incluir ocorrências iniciadas antes de "de".
+	recuo := t.fimDe(t.Inicio).Sub(t.Inicio) + 24*time.Hour
+	var instancias []Tarefa
+	vistas := map[int64]bool{}
+	for _, original := range r.Ocorrencias(t.Inicio.In(loc), de.Add(-recuo), ate) {
+		vistas[original.Unix()] = true
+		var alteracao *Ocorrencia
+		if o, ok := alteracoes[original.Unix()]; ok {
+			alteracao = &o
+		}
+		if inst, ok := t.instancia(original, alteracao, loc); ok && sobrepoe(inst, de, ate) {
+			instancias = append(instancias, inst)
+		}
+	}
+	// Ocorrências remarcadas para dentro da janela a partir de datas fora dela.
+	for _, o := range t.Ocorrencias {
+		if vistas[o.Original.Unix()] {
+			continue
+		}
+		o := o
+		if inst, ok := t.instancia(o.Original.In(loc), &o, loc); ok && sobrepoe(inst, de, ate) {
+			instancias = append(instancias, inst)
+		}
+	}
+	sort.Slice(instancias, func(i, j int) bool { return instancias[i].Inicio.Before(instancias[j].Inicio) })
+	return instancias, nil
+}
+
+// instancia monta a ocorrência da série iniciada em original; ok é falso para exceções.
+func (t Tarefa) instancia(original time.Time, alteracao *Ocorrencia, loc *time.Location) (Tarefa, bool) {
+	inst := t
+	inst.Ocorrencias = nil
+	inst.Ocorrencia = &original
+	inst.Inicio = original
+	inst.Fim = t.fimDe(original)
+	if alteracao == nil {
+		return inst, true
+	}
+	if alteracao.Excluida {
+		return Tarefa{}, false
+	}
+	if alteracao.Inicio != nil {
+		inst.Inicio = alteracao.Inicio.In(loc)
+		inst.Fim = t.fimDe(inst.Inicio)
+	}
+	if alteracao.Fim != nil {
+		inst.Fim = alteracao.Fim.In(loc)
+	}
+	if alteracao.Assunto != "" {
+		inst.Assunto = alteracao.Assunto
+	}
+	if alteracao.Descricao != "" {
+		inst.Descricao = alteracao.Descricao
+	}
+	inst.Concluida = t.Concluida || alteracao.Concluida
+	return inst, true
+}
+
+// sobrepoe informa se a tarefa ocupa algum instante da janela [de, ate).
+func sobrepoe(t Tarefa, de, ate time.Time) bool {
+	return t.Inicio.Before(ate) && (t.Fim.After(de) || !t.Inicio.Before(de))
+}
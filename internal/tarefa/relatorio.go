@@ -0,0 +1,85 @@
+package tarefa
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LinhaRelatorio resume as tarefas de um responsável ou de um tipo.
+type LinhaRelatorio struct {
+	Chave         string           `json:"chave"`
+	Total         int64            `json:"total"`
+	Concluidas    int64            `json:"concluidas"`
+	Canceladas    int64            `json:"canceladas"`
+	Abertas       int64            `json:"abertas"`
+	Atrasadas     int64            `json:"atrasadas"`
+	Reagendadas   int64            `json:"reagendadas"`
+	TaxaConclusao float64          `json:"taxa_conclusao"` // Concluídas sobre as não canceladas, de 0 a 1
+	Resultados    map[string]int64 `json:"resultados"`
+}
+
+// agrupamentos associa os agrupamentos aceitos no relatório às colunas da tabela.
+var agrupamentos = map[string]string{
+	"responsavel": "responsavel",
+	"tipo":        "tipo",
+}
+
+// Relatorio agrupa por responsável ou por tipo as tarefas iniciadas na janela
+// [de, ate), com as taxas de conclusão e a contagem de resultados.
+func (r *repository) Relatorio(agrupar string, de, ate *time.Time) ([]LinhaRelatorio, error) {
+	coluna, ok := agrupamentos[agrupar]
+	if !ok {
+		return nil, fmt.Errorf("agrupamento inválido: %q (use responsavel ou tipo)", agrupar)
+	}
+	base := func() *gorm.DB {
+		q := r.db.Model(&Tarefa{})
+		if de != nil {
+			q = q.Where("inicio >= ?", *de)
+		}
+		if ate != nil {
+			q = q.Where("inicio < ?", *ate)
+		}
+		return q
+	}
+
+	var linhas []LinhaRelatorio
+	err := base().Select(coluna+` AS chave,
+			COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE status = ?) AS concluidas,
+			COUNT(*) FILTER (WHERE status = ?) AS canceladas,
+			COUNT(*) FILTER (WHERE status IN ?) AS abertas,
+			COUNT(*) FILTER (WHERE atrasada) AS atrasadas,
+			COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM tarefa_reagendamentos g WHERE g.tarefa_id = tarefas.id)) AS reagendadas`,
+		StatusConcluida, StatusCancelada, StatusesAbertos).
+		Group(coluna).Order(coluna).Scan(&linhas).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var resultados []struct {
+		Chave     string
+		Resultado string
+		Total     int64
+	}
+	err = base().Select(coluna + " AS chave, resultado, COUNT(*) AS total").
+		Where("resultado <> ''").Group(coluna + ", resultado").Scan(&resultados).Error
+	if err != nil {
+		return nil, err
+	}
+	indice := make(map[string]int, len(linhas))
+	for i := range linhas {
+		linhas[i].Resultados = map[string]int64{}
+		if validas := linhas[i].Total - linhas[i].Canceladas; validas > 0 {
+			linhas[i].TaxaConclusao = float64(linhas[i].Concluidas) / float64(validas)
+		}
+		indice[linhas[i].Chave] = i
+	}
+	for _, res := range resultados {
+		if i, ok := indice[res.Chave]; ok {
+			linhas[i].Resultados[res.Resultado] = res.Total
+		}
+	}
+	return linhas, nil
+}
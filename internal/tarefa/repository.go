@@ -2,17 +2,27 @@ package tarefa
 
 import (
 	"errors"
+	"sort"
 	"time"
 
+	"my-crm-backend/internal/evento"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
 	Adicionar(t Tarefa) (Tarefa, error)
-	Listar() ([]Tarefa, error)
+	Listar(filtro Filtro) ([]Tarefa, error)
 	ObterPorID(id int) (*Tarefa, error)
 	Atualizar(id int, updated Tarefa) (Tarefa, error)
 	Deletar(id int) error
+	ListarOcorrencias(id int, de, ate time.Time) ([]Tarefa, error)
+	DefinirOcorrencia(id int, o Ocorrencia) (Ocorrencia, error)
+	ListarCalendario(responsavel string, desde time.Time) ([]Tarefa, error)
+	ListarAtrasadas(responsavel string) ([]Tarefa, error)
+	MarcarAtrasadas(agora time.Time) (marcadas, liberadas int64, err error)
+	Relatorio(agrupar string, de, ate *time.Time) ([]LinhaRelatorio, error)
 }
 
 type repository struct {
@@ -26,25 +36,82 @@ func NovoRepositorio(db *gorm.DB) Repository {
 
 // Adicionar insere uma nova tarefa no banco de dados.
 func (r *repository) Adicionar(t Tarefa) (Tarefa, error) {
-	// Se a data não for definida, atribui a data atual
-	if t.DataAgendamento.IsZero() {
-		t.DataAgendamento = time.Now()
+	if t.Inicio.IsZero() {
+		return t, ErrInicioObrigatorio
 	}
-	err := r.db.Create(&t).Error
+	t.Ocorrencias = nil
+	t.Escalonamentos = nil
+	t.Reagendamentos = nil
+	t.MarcarAtraso(time.Now())
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&t).Error; err != nil {
+			return err
+		}
+		return evento.Publicar(tx, evento.TarefaCriada, "tarefa", t.ID, "", t)
+	})
 	return t, err
 }
 
-// Listar retorna todas as tarefas.
-func (r *repository) Listar() ([]Tarefa, error) {
+// Filtro restringe a listagem de tarefas a uma janela da agenda.
+// A janela é semiaberta [De, Ate) e inclui tarefas que a sobrepõem. Com Ate
+// informado, as tarefas recorrentes são expandidas em ocorrências.
+type Filtro struct {
+	De          *time.Time
+	Ate         *time.Time
+	Responsavel string
+}
+
+// Listar retorna as tarefas que atendem ao filtro, ordenadas pelo início.
+func (r *repository) Listar(filtro Filtro) ([]Tarefa, error) {
+	query := r.db.Order("inicio, id")
+	if filtro.Responsavel != "" {
+		query = query.Where("responsavel = ?", filtro.Responsavel)
+	}
+	if filtro.Ate == nil {
+		// Sem o fim da janela não há como expandir as séries: elas são retornadas inteiras.
+		if filtro.De != nil {
+			query = query.Where("fim > ? OR inicio >= ? OR recorrencia <> ''", *filtro.De, *filtro.De)
+		}
+		var tarefas []Tarefa
+		err := query.Find(&tarefas).Error
+		return tarefas, err
+	}
+
+	de := time.Time{}
+	if filtro.De != nil {
+		de = *filtro.De
+	}
 	var tarefas []Tarefa
-	err := r.db.Find(&tarefas).Error
-	return tarefas, err
+	err := query.Preload("Ocorrencias").
+		Where("inicio < ? OR id IN (?)", *filtro.Ate,
+			r.db.Model(&Ocorrencia{}).Select("tarefa_id").Where("inicio < ?", *filtro.Ate)).
+		Where("fim > ? OR inicio >= ? OR recorrencia <> ''", de, de).
+		Find(&tarefas).Error
+	if err != nil {
+		return nil, err
+	}
+	agenda := []Tarefa{}
+	for _, t := range tarefas {
+		instancias, err := t.Expandir(de, *filtro.Ate)
+		if err != nil {
+			return nil, err
+		}
+		agenda = append(agenda, instancias...)
+	}
+	sort.SliceStable(agenda, func(i, j int) bool { return agenda[i].Inicio.Before(agenda[j].Inicio) })
+	return agenda, nil
 }
 
 // ObterPorID busca uma tarefa pelo ID.
 func (r *repository) ObterPorID(id int) (*Tarefa, error) {
 	var t Tarefa
-	err := r.db.First(&t, id).Error
+	err := r.db.Preload("Ocorrencias", func(db *gorm.DB) *gorm.DB {
+		return db.Order("original")
+	}).Preload("Escalonamentos", func(db *gorm.DB) *gorm.DB {
+		return db.Order("data")
+	}).Preload("Reagendamentos", func(db *gorm.DB) *gorm.DB {
+		return db.Order("data")
+	}).First(&t, id).Error
 	if err != nil {
 		return nil, errors.New("Tarefa not found")
 	}
@@ -54,15 +121,199 @@ func (r *repository) ObterPorID(id int) (*Tarefa, error) {
 // Atualizar modifica os dados de uma tarefa existente.
 func (r *repository) Atualizar(id int, updated Tarefa) (Tarefa, error) {
 	var tarefa Tarefa
-	err := r.db.First(&tarefa, id).Error
+	err := r.db.Preload("Ocorrencias").First(&tarefa, id).Error
 	if err != nil {
 		return Tarefa{}, errors.New("Tarefa not found")
 	}
+	if updated.Inicio.IsZero() {
+		return Tarefa{}, ErrInicioObrigatorio
+	}
 	updated.ID = id
-	err = r.db.Model(&tarefa).Updates(updated).Error
+	updated.Ocorrencias = tarefa.Ocorrencias
+	updated.MarcarAtraso(time.Now())
+	reagendada := !tarefa.Inicio.Equal(updated.Inicio)
+	if reagendada && updated.Status == tarefa.Status && updated.Aberta() {
+		updated.Status = StatusReagendada
+	}
+	// Grava todos os campos, para que valores zerados (ex: dia_inteiro falso) também sejam salvos.
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&tarefa).Select("*").Omit("id", "created_at", "deleted_at", clause.Associations).Updates(updated).Error; err != nil {
+			return err
+		}
+		if reagendada {
+			if err := tx.Create(&Reagendamento{
+				TarefaID:       id,
+				InicioAnterior: tarefa.Inicio,
+				NovoInicio:     updated.Inicio,
+				Motivo:         updated.MotivoReagendamento,
+				Usuario:        updated.AlteradoPor,
+				Data:           time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if updated.Status == StatusConcluida && tarefa.Status != StatusConcluida {
+			if err := evento.Publicar(tx, evento.TarefaConcluida, "tarefa", id, updated.AlteradoPor, updated); err != nil {
+				return err
+			}
+		}
+		return descartarOcorrenciasOrfas(tx, updated)
+	})
 	return updated, err
 }
 
+// descartarOcorrenciasOrfas remove as alterações de ocorrências que deixaram de
+// pertencer à série depois de uma mudança no início ou na regra de recorrência.
+func descartarOcorrenciasOrfas(tx *gorm.DB, t Tarefa) error {
+	if t.Recorrencia == "" {
+		return tx.Where("tarefa_id = ?", t.ID).Delete(&Ocorrencia{}).Error
+	}
+	var ocorrencias []Ocorrencia
+	if err := tx.Where("tarefa_id = ?", t.ID).Find(&ocorrencias).Error; err != nil {
+		return err
+	}
+	for _, o := range ocorrencias {
+		contem, err := t.ContemOcorrencia(o.Original)
+		if err != nil {
+			return err
+		}
+		if !contem {
+			if err := tx.Delete(&o).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ListarOcorrencias retorna as ocorrências da tarefa na janela [de, ate).
+func (r *repository) ListarOcorrencias(id int, de, ate time.Time) ([]Tarefa, error) {
+	t, err := r.ObterPorID(id)
+	if err != nil {
+		return nil, err
+	}
+	return t.Expandir(de, ate)
+}
+
+// DefinirOcorrencia grava as alterações de uma ocorrência da série, criando-as
+// ou substituindo as anteriores por o inteira: quem altera só alguns campos deve
+// partir das alterações atuais. o.Original deve ser o início de uma ocorrência.
+func (r *repository) DefinirOcorrencia(id int, o Ocorrencia) (Ocorrencia, error) {
+	t, err := r.ObterPorID(id)
+	if err != nil {
+		return Ocorrencia{}, err
+	}
+	contem, err := t.ContemOcorrencia(o.Original)
+	if err != nil {
+		return Ocorrencia{}, err
+	}
+	if !contem {
+		return Ocorrencia{}, errors.New("a data informada não é uma ocorrência da tarefa")
+	}
+	if o.Inicio != nil && o.Fim != nil && o.Fim.Before(*o.Inicio) {
+		return Ocorrencia{}, errors.New("fim não pode ser anterior ao início")
+	}
+	o.ID = 0
+	o.TarefaID = id
+	err = r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tarefa_id"}, {Name: "original"}},
+		DoUpdates: clause.AssignmentColumns([]string{"excluida", "concluida", "inicio", "fim", "assunto", "descricao", "updated_at"}),
+	}).Create(&o).Error
+	return o, err
+}
+
+// ListarCalendario retorna as tarefas do responsável que terminam a partir de
+// desde, além de todas as suas séries recorrentes, com a empresa e as alterações
+// de ocorrências carregadas.
+func (r *repository) ListarCalendario(responsavel string, desde time.Time) ([]Tarefa, error) {
+	var tarefas []Tarefa
+	err := r.db.Preload("Empresa").Preload("Ocorrencias").
+		Where("responsavel = ?", responsavel).
+		Where("fim >= ? OR recorrencia <> ''", desde).
+		Order("inicio, id").
+		Find(&tarefas).Error
+	return tarefas, err
+}
+
+// ListarAtrasadas retorna as tarefas atrasadas, opcionalmente de um responsável,
+// da mais antiga para a mais recente.
+func (r *repository) ListarAtrasadas(responsavel string) ([]Tarefa, error) {
+	query := r.db.Preload("Escalonamentos").Where("atrasada").Order("fim, id")
+	if responsavel != "" {
+		query = query.Where("responsavel = ?", responsavel)
+	}
+	var tarefas []Tarefa
+	err := query.Find(&tarefas).Error
+	return tarefas, err
+}
+
+// MarcarAtrasadas atualiza o indicador de atraso: marca as tarefas únicas não
+// concluídas cujo fim já passou e as séries cuja última ocorrência está
+// atrasada (ver InstanciaAtrasada), e libera as que foram concluídas ou
+// remarcadas. TarefaAtrasada é publicado para cada tarefa ou ocorrência que
+// passa a estar atrasada.
+func (r *repository) MarcarAtrasadas(agora time.Time) (marcadas, liberadas int64, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		var atrasadas []Tarefa
+		res := tx.Model(&atrasadas).Clauses(clause.Returning{}).
+			Where("NOT atrasada AND status IN ? AND (recorrencia IS NULL OR recorrencia = '') AND fim < ?", StatusesAbertos, agora).
+			UpdateColumn("atrasada", true)
+		if res.Error != nil {
+			return res.Error
+		}
+		marcadas = res.RowsAffected
+
+		var series []Tarefa
+		if err := tx.Preload("Ocorrencias").
+			Where("status IN ? AND recorrencia <> ''", StatusesAbertos).
+			Find(&series).Error; err != nil {
+			return err
+		}
+		for _, t := range series {
+			anterior, ocorrencia := t.Atrasada, t.OcorrenciaAtrasada
+			t.MarcarAtraso(agora)
+			if t.Atrasada == anterior && mesmoInstante(t.OcorrenciaAtrasada, ocorrencia) {
+				continue
+			}
+			if err := tx.Model(&Tarefa{}).Where("id = ?", t.ID).UpdateColumns(map[string]interface{}{
+				"atrasada":            t.Atrasada,
+				"ocorrencia_atrasada": t.OcorrenciaAtrasada,
+			}).Error; err != nil {
+				return err
+			}
+			if !t.Atrasada {
+				liberadas++
+				continue
+			}
+			marcadas++
+			t.Ocorrencias = nil
+			atrasadas = append(atrasadas, t)
+		}
+
+		for _, t := range atrasadas {
+			if err := evento.Publicar(tx, evento.TarefaAtrasada, "tarefa", t.ID, "", t); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	res := r.db.Model(&Tarefa{}).
+		Where("atrasada AND (status NOT IN ? OR ((recorrencia IS NULL OR recorrencia = '') AND fim >= ?))", StatusesAbertos, agora).
+		UpdateColumns(map[string]interface{}{"atrasada": false, "ocorrencia_atrasada": nil})
+	return marcadas, liberadas + res.RowsAffected, res.Error
+}
+
+// mesmoInstante compara dois instantes opcionais.
+func mesmoInstante(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
 // Deletar remove uma tarefa pelo ID.
 func (r *repository) Deletar(id int) error {
 	return r.db.Delete(&Tarefa{}, id).Error
@@ -0,0 +1,93 @@
+package tarefa
+
+import (
+	"fmt"
+	"time"
+)
+
+// Situações de uma tarefa.
+const (
+	StatusPendente    = "pendente"
+	StatusEmAndamento = "em_andamento"
+	StatusConcluida   = "concluida"
+	StatusCancelada   = "cancelada"
+	StatusReagendada  = "reagendada"
+)
+
+// Statuses lista as situações válidas.
+var Statuses = []string{StatusPendente, StatusEmAndamento, StatusConcluida, StatusCancelada, StatusReagendada}
+
+// StatusesAbertos lista as situações de tarefas que ainda precisam ser feitas.
+var StatusesAbertos = []string{StatusPendente, StatusEmAndamento, StatusReagendada}
+
+// Resultados possíveis de ligações e reuniões.
+const (
+	ResultadoAtendeu          = "atendeu"
+	ResultadoNaoAtendeu       = "nao_atendeu"
+	ResultadoReuniaoRealizada = "reuniao_realizada"
+	ResultadoNoShow           = "no_show"
+)
+
+// Resultados lista os resultados válidos.
+var Resultados = []string{ResultadoAtendeu, ResultadoNaoAtendeu, ResultadoReuniaoRealizada, ResultadoNoShow}
+
+// Reagendamento registra uma mudança no início de uma tarefa.
+type Reagendamento struct {
+	ID             int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	TarefaID       int       `json:"tarefa_id" gorm:"index;not null"`
+	InicioAnterior time.Time `json:"inicio_anterior"`
+	NovoInicio     time.Time `json:"novo_inicio"`
+	Motivo         string    `json:"motivo,omitempty"`
+	Usuario        string    `json:"usuario,omitempty"`
+	Data           time.Time `json:"data"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Reagendamento) TableName() string {
+	return "tarefa_reagendamentos"
+}
+
+// Aberta informa se a tarefa ainda precisa ser feita.
+func (t Tarefa) Aberta() bool {
+	return contem(StatusesAbertos, t.Status)
+}
+
+// NormalizarStatus valida a situação e o resultado da tarefa e mantém os campos
+// derivados: Concluida, para compatibilidade, e a data e o autor da conclusão.
+// Sem situação, ela é deduzida de Concluida.
+func NormalizarStatus(t *Tarefa, agora time.Time) error {
+	if t.Status == "" {
+		t.Status = StatusPendente
+		if t.Concluida {
+			t.Status = StatusConcluida
+		}
+	}
+	if !contem(Statuses, t.Status) {
+		return fmt.Errorf("status inválido: %s", t.Status)
+	}
+	if t.Resultado != "" && !contem(Resultados, t.Resultado) {
+		return fmt.Errorf("resultado inválido: %s", t.Resultado)
+	}
+	t.Concluida = t.Status == StatusConcluida
+	if !t.Concluida {
+		t.ConcluidaEm = nil
+		t.ConcluidaPor = ""
+		return nil
+	}
+	if t.ConcluidaEm == nil {
+		t.ConcluidaEm = &agora
+	}
+	if t.ConcluidaPor == "" {
+		t.ConcluidaPor = t.AlteradoPor
+	}
+	return nil
+}
+
+func contem(lista []string, valor string) bool {
+	for _, v := range lista {
+		if v == valor {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package telefone
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/datatypes"
+)
+
+// Tipos de telefone.
+const (
+	TipoCelular  = "celular"
+	TipoFixo     = "fixo"
+	TipoWhatsApp = "whatsapp"
+)
+
+// Telefone é um número normalizado no formato E.164 com o seu tipo.
+type Telefone struct {
+	Numero    string `json:"numero"` // Ex.: +5511999998888
+	Tipo      string `json:"tipo"`
+	Principal bool   `json:"principal"`
+}
+
+// dddsValidos lista os códigos de área em uso no Brasil.
+var dddsValidos = map[string]bool{
+	"11": true, "12": true, "13": true, "14": true, "15": true, "16": true, "17": true, "18": true, "19": true,
+	"21": true, "22": true, "24": true, "27": true, "28": true,
+	"31": true, "32": true, "33": true, "34": true, "35": true, "37": true, "38": true,
+	"41": true, "42": true, "43": true, "44": true, "45": true, "46": true, "47": true, "48": true, "49": true,
+	"51": true, "53": true, "54": true, "55": true,
+	"61": true, "62": true, "63": true, "64": true, "65": true, "66": true, "67": true, "68": true, "69": true,
+	"71": true, "73": true, "74": true, "75": true, "77": true, "79": true,
+	"81": true, "82": true, "83": true, "84": true, "85": true, "86": true, "87": true, "88": true, "89": true,
+	"91": true, "92": true, "93": true, "94": true, "95": true, "96": true, "97": true, "98": true, "99": true,
+}
+
+// Normalizar converte um telefone em E.164 e identifica se é celular ou fixo.
+// Números brasileiros são aceitos com ou sem +55, zero de discagem e pontuação;
+// celulares antigos de oito dígitos (iniciados em 6 a 9) recebem o nono dígito.
+// Números estrangeiros devem ser informados com "+" e código do país.
+func Normalizar(bruto string) (string, string, error) {
+	bruto = strings.TrimSpace(bruto)
+	internacional := strings.HasPrefix(bruto, "+")
+	var b strings.Builder
+	for _, r := range bruto {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digitos := b.String()
+
+	if internacional && !strings.HasPrefix(digitos, "55") {
+		if len(digitos) < 8 || len(digitos) > 15 {
+			return "", "", fmt.Errorf("telefone internacional inválido: %s", bruto)
+		}
+		return "+" + digitos, "", nil
+	}
+
+	if internacional || (len(digitos) >= 12 && strings.HasPrefix(digitos, "55")) {
+		digitos = strings.TrimPrefix(digitos, "55")
+	}
+	digitos = strings.TrimLeft(digitos, "0")
+	if len(digitos) != 10 && len(digitos) != 11 {
+		return "", "", fmt.Errorf("telefone deve ter DDD e 8 ou 9 dígitos: %s", bruto)
+	}
+
+	ddd, numero := digitos[:2], digitos[2:]
+	if !dddsValidos[ddd] {
+		return "", "", fmt.Errorf("DDD inválido: %s", ddd)
+	}
+
+	tipo := TipoFixo
+	switch {
+	case len(numero) == 9:
+		if numero[0] != '9' {
+			return "", "", fmt.Errorf("celular deve começar com 9: %s", bruto)
+		}
+		tipo = TipoCelular
+	case numero[0] >= '2' && numero[0] <= '5':
+		tipo = TipoFixo
+	case numero[0] >= '6':
+		numero = "9" + numero
+		tipo = TipoCelular
+	default:
+		return "", "", fmt.Errorf("número inválido: %s", bruto)
+	}
+	return "+55" + ddd + numero, tipo, nil
+}
+
+// NormalizarLista valida e normaliza o campo JSON de telefones de um contato. Aceita
+// a lista de objetos {"numero", "tipo", "principal"} ou o formato legado de lista de textos.
+// Garante exatamente um telefone principal quando a lista não está vazia.
+func NormalizarLista(dados datatypes.JSON) (datatypes.JSON, error) {
+	telefones, err := decodificar(dados)
+	if err != nil {
+		return nil, err
+	}
+	vistos := make(map[string]bool)
+	normalizados := make([]Telefone, 0, len(telefones))
+	principal := -1
+	for _, t := range telefones {
+		numero, detectado, err := Normalizar(t.Numero)
+		if err != nil {
+			return nil, err
+		}
+		if vistos[numero] {
+			continue
+		}
+		vistos[numero] = true
+		switch t.Tipo {
+		case "":
+			t.Tipo = detectado
+			if t.Tipo == "" {
+				t.Tipo = TipoCelular
+			}
+		case TipoCelular, TipoFixo, TipoWhatsApp:
+		default:
+			return nil, fmt.Errorf("tipo de telefone inválido: %q", t.Tipo)
+		}
+		if t.Tipo == TipoCelular && detectado == TipoFixo {
+			return nil, fmt.Errorf("o número %s é fixo, não celular", numero)
+		}
+		t.Numero = numero
+		if t.Principal {
+			if principal >= 0 {
+				t.Principal = false
+			} else {
+				principal = len(normalizados)
+			}
+		}
+		normalizados = append(normalizados, t)
+	}
+	if principal < 0 && len(normalizados) > 0 {
+		normalizados[0].Principal = true
+	}
+	resultado, err := json.Marshal(normalizados)
+	return datatypes.JSON(resultado), err
+}
+
+// Decodificar lê o campo JSON de telefones sem validar os números, aceitando os dois formatos.
+func Decodificar(dados datatypes.JSON) []Telefone {
+	telefones, _ := decodificar(dados)
+	return telefones
+}
+
+func decodificar(dados datatypes.JSON) ([]Telefone, error) {
+	dados = bytes.TrimSpace(dados)
+	if len(dados) == 0 || string(dados) == "null" {
+		return nil, nil
+	}
+	var telefones []Telefone
+	if err := json.Unmarshal(dados, &telefones); err == nil {
+		return telefones, nil
+	}
+	var legado []string
+	if err := json.Unmarshal(dados, &legado); err != nil {
+		return nil, errors.New("telefones deve ser uma lista de objetos {numero, tipo, principal}")
+	}
+	telefones = make([]Telefone, 0, len(legado))
+	for _, numero := range legado {
+		telefones = append(telefones, Telefone{Numero: numero})
+	}
+	return telefones, nil
+}
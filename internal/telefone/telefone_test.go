@@ -0,0 +1,84 @@
+package telefone
+
+import (
+	"testing"
+
+	"gorm.io/datatypes"
+)
+
+func TestNormalizar(t *testing.T) {
+	casos := []struct {
+		bruto, numero, tipo string
+		erro                bool
+	}{
+		{bruto: "(11) 99999-8888", numero: "+5511999998888", tipo: TipoCelular},
+		{bruto: "+55 11 99999-8888", numero: "+5511999998888", tipo: TipoCelular},
+		{bruto: "5511999998888", numero: "+5511999998888", tipo: TipoCelular},
+		{bruto: "011 99999 8888", numero: "+5511999998888", tipo: TipoCelular},
+		{bruto: "(21) 3333-4444", numero: "+552133334444", tipo: TipoFixo},
+		{bruto: "21 8888-7777", numero: "+5521988887777", tipo: TipoCelular}, // Celular antigo recebe o nono dígito
+		{bruto: " +1 (415) 555-2671 ", numero: "+14155552671", tipo: ""},
+		{bruto: "+351 912 345 678", numero: "+351912345678", tipo: ""},
+
+		{bruto: "", erro: true},
+		{bruto: "9999-8888", erro: true},              // Sem DDD
+		{bruto: "(20) 99999-8888", erro: true},        // DDD inexistente
+		{bruto: "(11) 89999-8888", erro: true},        // Nove dígitos sem o 9 inicial
+		{bruto: "(11) 1999-8888", erro: true},         // Número iniciado em 1
+		{bruto: "+1 555", erro: true},                 // Internacional curto demais
+		{bruto: "+1 4155 5526 7112 3456", erro: true}, // Internacional longo demais
+		{bruto: "11 99999-8888 ramal 12", erro: true}, // Dígitos a mais
+	}
+	for _, c := range casos {
+		numero, tipo, err := Normalizar(c.bruto)
+		if c.erro {
+			if err == nil {
+				t.Errorf("Normalizar(%q) = %q, %q; esperado erro", c.bruto, numero, tipo)
+			}
+			continue
+		}
+		if err != nil || numero != c.numero || tipo != c.tipo {
+			t.Errorf("Normalizar(%q) = %q, %q, %v; esperado %q, %q", c.bruto, numero, tipo, err, c.numero, c.tipo)
+		}
+	}
+}
+
+func TestNormalizarLista(t *testing.T) {
+	casos := []struct {
+		nome, entrada, esperado string
+		erro                    bool
+	}{
+		{
+			nome:     "formato legado",
+			entrada:  `["(11) 99999-8888", "(11) 3333-4444"]`,
+			esperado: `[{"numero":"+5511999998888","tipo":"celular","principal":true},{"numero":"+551133334444","tipo":"fixo","principal":false}]`,
+		},
+		{
+			nome:     "remove duplicados e mantém um principal",
+			entrada:  `[{"numero":"11 99999-8888"},{"numero":"+5511999998888","principal":true},{"numero":"11 3333-4444","tipo":"whatsapp","principal":true}]`,
+			esperado: `[{"numero":"+5511999998888","tipo":"celular","principal":false},{"numero":"+551133334444","tipo":"whatsapp","principal":true}]`,
+		},
+		{
+			nome:     "internacional sem tipo vira celular",
+			entrada:  `[{"numero":"+1 415 555 2671"}]`,
+			esperado: `[{"numero":"+14155552671","tipo":"celular","principal":true}]`,
+		},
+		{nome: "vazio", entrada: ``, esperado: `[]`},
+		{nome: "fixo informado como celular", entrada: `[{"numero":"11 3333-4444","tipo":"celular"}]`, erro: true},
+		{nome: "tipo desconhecido", entrada: `[{"numero":"11 99999-8888","tipo":"pager"}]`, erro: true},
+		{nome: "número inválido", entrada: `["123"]`, erro: true},
+		{nome: "formato inválido", entrada: `{"numero":"11 99999-8888"}`, erro: true},
+	}
+	for _, c := range casos {
+		got, err := NormalizarLista(datatypes.JSON(c.entrada))
+		if c.erro {
+			if err == nil {
+				t.Errorf("%s: NormalizarLista = %s; esperado erro", c.nome, got)
+			}
+			continue
+		}
+		if err != nil || string(got) != c.esperado {
+			t.Errorf("%s: NormalizarLista = %s, %v; esperado %s", c.nome, got, err, c.esperado)
+		}
+	}
+}
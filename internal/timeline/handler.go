@@ -0,0 +1,122 @@
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"my-crm-backend/internal/tarefa"
+	"my-crm-backend/internal/usuario"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limites da paginação da linha do tempo.
+const (
+	porPaginaPadrao = 50
+	porPaginaMaximo = 200
+)
+
+// Handler contém as dependências do HTTP para a linha do tempo.
+type Handler struct {
+	repo Repository
+}
+
+// NovoHandler cria o handler da linha do tempo.
+func NovoHandler(repo Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+// DaNegociacao retorna a linha do tempo da negociação :id.
+// Parâmetros opcionais: pagina, por_pagina, tipos (separados por vírgula), de e ate.
+func (h *Handler) DaNegociacao(c *gin.Context) {
+	h.responder(c, h.repo.DaNegociacao)
+}
+
+// DaEmpresa retorna a linha do tempo da empresa :id, incluindo os eventos de
+// todas as suas negociações. Aceita os mesmos parâmetros de DaNegociacao.
+func (h *Handler) DaEmpresa(c *gin.Context) {
+	h.responder(c, h.repo.DaEmpresa)
+}
+
+func (h *Handler) responder(c *gin.Context, buscar func(id int, filtro Filtro) (Pagina, error)) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	filtro, err := lerFiltro(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	pagina, err := buscar(id, filtro)
+	if errors.Is(err, ErrNaoEncontrada) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, pagina)
+}
+
+// lerFiltro interpreta os parâmetros de consulta da linha do tempo. As datas
+// aceitam RFC 3339 ou "AAAA-MM-DD"; "ate" com apenas a data inclui o dia todo.
+func lerFiltro(c *gin.Context) (Filtro, error) {
+	filtro := Filtro{Pagina: 1, PorPagina: porPaginaPadrao}
+	if v := c.Query("pagina"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return Filtro{}, fmt.Errorf("pagina inválida: %s", v)
+		}
+		filtro.Pagina = n
+	}
+	if v := c.Query("por_pagina"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > porPaginaMaximo {
+			return Filtro{}, fmt.Errorf("por_pagina deve estar entre 1 e %d", porPaginaMaximo)
+		}
+		filtro.PorPagina = n
+	}
+	if v := c.Query("tipos"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.TrimSpace(t)
+			if !tipoValido(t) {
+				return Filtro{}, fmt.Errorf("tipo de evento inválido: %q (use %s)", t, strings.Join(Tipos, ", "))
+			}
+			filtro.Tipos = append(filtro.Tipos, t)
+		}
+	}
+	// As datas sem fuso usam o fuso padrão, como no relatório de tarefas.
+	loc, err := usuario.CarregarFuso("")
+	if err != nil {
+		return Filtro{}, err
+	}
+	if v := c.Query("de"); v != "" {
+		de, err := tarefa.LerInstante(v, loc, false)
+		if err != nil {
+			return Filtro{}, err
+		}
+		filtro.De = &de
+	}
+	if v := c.Query("ate"); v != "" {
+		ate, err := tarefa.LerInstante(v, loc, true)
+		if err != nil {
+			return Filtro{}, err
+		}
+		filtro.Ate = &ate
+	}
+	return filtro, nil
+}
+
+func tipoValido(tipo string) bool {
+	for _, t := range Tipos {
+		if t == tipo {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package timeline
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Tipos de evento da linha do tempo.
+const (
+	TipoEtapaAlterada    = "etapa_alterada"
+	TipoStatusAlterado   = "status_alterado"
+	TipoValoresAlterados = "valores_alterados"
+	TipoTarefaCriada     = "tarefa_criada"
+	TipoTarefaConcluida  = "tarefa_concluida"
+	TipoAnotacao         = "anotacao"
+	TipoAnexoAdicionado  = "anexo_adicionado"
+	TipoAnexoNovaVersao  = "anexo_nova_versao"
+)
+
+// Tipos lista todos os tipos de evento, na ordem em que são documentados.
+var Tipos = []string{
+	TipoEtapaAlterada, TipoStatusAlterado, TipoValoresAlterados,
+	TipoTarefaCriada, TipoTarefaConcluida, TipoAnotacao,
+	TipoAnexoAdicionado, TipoAnexoNovaVersao,
+}
+
+// Evento é um item da linha do tempo. Origem e OrigemID identificam o registro
+// que gerou o evento (ex: "tarefa" 12); Dados traz os campos próprios do tipo.
+type Evento struct {
+	Tipo         string          `json:"tipo"`
+	Data         time.Time       `json:"data"`
+	Usuario      string          `json:"usuario,omitempty"`
+	Origem       string          `json:"origem"`
+	OrigemID     int             `json:"origem_id"`
+	NegociacaoID *int            `json:"negociacao_id,omitempty"`
+	Dados        json.RawMessage `json:"dados"`
+}
+
+// Pagina é um trecho da linha do tempo, do evento mais recente para o mais antigo.
+type Pagina struct {
+	Eventos   []Evento `json:"eventos"`
+	Pagina    int      `json:"pagina"`
+	PorPagina int      `json:"por_pagina"`
+	Total     int64    `json:"total"`
+}
@@ -0,0 +1,196 @@
+package timeline
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrNaoEncontrada indica que a negociação ou a empresa da linha do tempo não existe.
+var ErrNaoEncontrada = errors.New("registro não encontrado")
+
+// Filtro restringe os eventos da linha do tempo.
+type Filtro struct {
+	Tipos     []string   // Vazio inclui todos os tipos
+	De        *time.Time // Inclusivo
+	Ate       *time.Time // Exclusivo
+	Pagina    int        // A partir de 1
+	PorPagina int
+}
+
+// Repository monta as linhas do tempo a partir das tabelas de cada módulo.
+type Repository interface {
+	DaNegociacao(id int, filtro Filtro) (Pagina, error)
+	DaEmpresa(id int, filtro Filtro) (Pagina, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NovoRepositorio cria e retorna um repositório baseado em GORM.
+func NovoRepositorio(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// escopo descreve de quais registros os eventos são reunidos. Os trechos SQL
+// usam os parâmetros nomeados @negociacao e @empresa (0 quando não se aplica).
+type escopo struct {
+	negociacoes string // Subconsulta (ou parâmetro) com os IDs das negociações
+	tarefas     string // Condição sobre a tabela tarefas (alias t)
+	negociacao  int
+	empresa     int // Empresa cujas anotações e anexos diretos entram
+}
+
+// DaNegociacao reúne os eventos de uma negociação.
+func (r *repository) DaNegociacao(id int, filtro Filtro) (Pagina, error) {
+	if err := r.conferir("negociacoes", id); err != nil {
+		return Pagina{}, err
+	}
+	return r.montar(escopo{
+		negociacoes: "@negociacao",
+		tarefas:     "t.negociacao_id = @negociacao",
+		negociacao:  id,
+	}, filtro)
+}
+
+// DaEmpresa reúne os eventos da empresa e de todas as suas negociações.
+func (r *repository) DaEmpresa(id int, filtro Filtro) (Pagina, error) {
+	if err := r.conferir("empresas", id); err != nil {
+		return Pagina{}, err
+	}
+	return r.montar(escopo{
+		negociacoes: "SELECT id FROM negociacoes WHERE empresa_id = @empresa AND deleted_at IS NULL",
+		tarefas:     "t.empresa_id = @empresa",
+		empresa:     id,
+	}, filtro)
+}
+
+func (r *repository) conferir(tabela string, id int) error {
+	var total int64
+	if err := r.db.Table(tabela).Where("id = ? AND deleted_at IS NULL", id).Count(&total).Error; err != nil {
+		return err
+	}
+	if total == 0 {
+		return ErrNaoEncontrada
+	}
+	return nil
+}
+
+// Trechos da consulta, um por fonte de eventos. NEGOCIACOES e TAREFAS são
+// substituídos pelo escopo; todos produzem as colunas de linha.
+const (
+	sqlEtapas = `SELECT 'etapa_alterada' AS tipo, h.data_alteracao AS data, h.alterado_por AS usuario,
+		'historico_etapa' AS origem, h.id AS origem_id, h.negociacao_id AS negociacao_id,
+		jsonb_build_object('etapa_anterior', h.etapa_anterior, 'etapa_atual', h.etapa_atual, 'observacao', h.observacao) AS dados
+		FROM historico_etapas h
+		WHERE h.deleted_at IS NULL AND h.negociacao_id IN (NEGOCIACOES)`
+
+	sqlAuditoria = `SELECT CASE a.acao WHEN 'status' THEN 'status_alterado' ELSE 'valores_alterados' END, a.data, COALESCE(a.usuario, ''),
+		'auditoria', a.id, a.entidade_id,
+		COALESCE(a.detalhes::jsonb, '{}'::jsonb)
+		FROM auditorias a
+		WHERE a.entidade = 'negociacao' AND a.acao IN ('status', 'valores') AND a.entidade_id IN (NEGOCIACOES)`
+
+	sqlTarefasCriadas = `SELECT 'tarefa_criada', t.created_at, '',
+		'tarefa', t.id, NULLIF(t.negociacao_id, 0),
+		jsonb_build_object('assunto', t.assunto, 'tipo', t.tipo, 'responsavel', t.responsavel,
+			'inicio', t.inicio, 'status', t.status, 'prioridade', t.prioridade)
+		FROM tarefas t
+		WHERE t.deleted_at IS NULL AND (TAREFAS)`
+
+	sqlTarefasConcluidas = `SELECT 'tarefa_concluida', t.concluida_em, COALESCE(t.concluida_por, ''),
+		'tarefa', t.id, NULLIF(t.negociacao_id, 0),
+		jsonb_build_object('assunto', t.assunto, 'tipo', t.tipo, 'responsavel', t.responsavel, 'resultado', t.resultado)
+		FROM tarefas t
+		WHERE t.deleted_at IS NULL AND t.status = 'concluida' AND t.concluida_em IS NOT NULL AND (TAREFAS)`
+
+	sqlAnotacoes = `SELECT 'anotacao', an.data, COALESCE(an.autor, ''),
+		'anotacao', an.id, CASE WHEN an.entidade = 'negociacao' THEN an.entidade_id END,
+		jsonb_build_object('assunto', an.assunto, 'html', an.html, 'fixada', an.fixada,
+			'entidade', an.entidade, 'entidade_id', an.entidade_id)
+		FROM anotacoes an
+		WHERE an.deleted_at IS NULL AND (
+			(an.entidade = 'negociacao' AND an.entidade_id IN (NEGOCIACOES))
+			OR (an.entidade = 'empresa' AND an.entidade_id = @empresa)
+			OR (an.entidade = 'tarefa' AND an.entidade_id IN (SELECT t.id FROM tarefas t WHERE t.deleted_at IS NULL AND (TAREFAS))))`
+
+	sqlAnexos = `SELECT CASE WHEN v.numero = 1 THEN 'anexo_adicionado' ELSE 'anexo_nova_versao' END, v.created_at, COALESCE(v.autor, ''),
+		'anexo', x.id, CASE WHEN x.entidade = 'negociacao' THEN x.entidade_id END,
+		jsonb_build_object('nome', x.nome, 'nome_arquivo', v.nome_arquivo, 'versao', v.numero,
+			'tipo', v.tipo, 'tamanho', v.tamanho, 'entidade', x.entidade, 'entidade_id', x.entidade_id)
+		FROM anexo_versoes v JOIN anexos x ON x.id = v.anexo_id
+		WHERE x.deleted_at IS NULL AND (
+			(x.entidade = 'negociacao' AND x.entidade_id IN (NEGOCIACOES))
+			OR (x.entidade = 'empresa' AND x.entidade_id = @empresa))`
+)
+
+// linha recebe um evento do banco; os dados chegam como texto JSON.
+type linha struct {
+	Tipo         string
+	Data         time.Time
+	Usuario      string
+	Origem       string
+	OrigemID     int
+	NegociacaoID *int
+	Dados        string
+}
+
+// montar une os trechos com UNION ALL, aplica o filtro e pagina o resultado.
+func (r *repository) montar(e escopo, filtro Filtro) (Pagina, error) {
+	marcadores := strings.NewReplacer("NEGOCIACOES", e.negociacoes, "TAREFAS", e.tarefas)
+	partes := []string{sqlEtapas, sqlAuditoria, sqlTarefasCriadas, sqlTarefasConcluidas, sqlAnotacoes, sqlAnexos}
+	for i, p := range partes {
+		partes[i] = marcadores.Replace(p)
+	}
+	args := map[string]interface{}{
+		"negociacao":   e.negociacao,
+		"empresa":      e.empresa,
+		"limite":       filtro.PorPagina,
+		"deslocamento": (filtro.Pagina - 1) * filtro.PorPagina,
+	}
+
+	var condicoes []string
+	if len(filtro.Tipos) > 0 {
+		condicoes = append(condicoes, "tipo IN @tipos")
+		args["tipos"] = filtro.Tipos
+	}
+	if filtro.De != nil {
+		condicoes = append(condicoes, "data >= @de")
+		args["de"] = *filtro.De
+	}
+	if filtro.Ate != nil {
+		condicoes = append(condicoes, "data < @ate")
+		args["ate"] = *filtro.Ate
+	}
+	base := "SELECT * FROM (" + strings.Join(partes, "\nUNION ALL\n") + ") eventos"
+	if len(condicoes) > 0 {
+		base += " WHERE " + strings.Join(condicoes, " AND ")
+	}
+
+	pagina := Pagina{Eventos: []Evento{}, Pagina: filtro.Pagina, PorPagina: filtro.PorPagina}
+	if err := r.db.Raw("SELECT count(*) FROM ("+base+") total", args).Scan(&pagina.Total).Error; err != nil {
+		return Pagina{}, err
+	}
+
+	var linhas []linha
+	consulta := base + " ORDER BY data DESC, origem, origem_id DESC LIMIT @limite OFFSET @deslocamento"
+	if err := r.db.Raw(consulta, args).Scan(&linhas).Error; err != nil {
+		return Pagina{}, err
+	}
+	for _, l := range linhas {
+		pagina.Eventos = append(pagina.Eventos, Evento{
+			Tipo:         l.Tipo,
+			Data:         l.Data,
+			Usuario:      l.Usuario,
+			Origem:       l.Origem,
+			OrigemID:     l.OrigemID,
+			NegociacaoID: l.NegociacaoID,
+			Dados:        json.RawMessage(l.Dados),
+		})
+	}
+	return pagina, nil
+}
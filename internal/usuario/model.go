@@ -0,0 +1,51 @@
+package usuario
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FusoPadrao é o fuso horário usado quando o usuário não define o seu.
+const FusoPadrao = "America/Sao_Paulo"
+
+// Usuario representa um usuário do CRM. O Login é o valor usado nos campos
+// "responsavel" das demais entidades.
+type Usuario struct {
+	ID          int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	Login       string `json:"login" gorm:"uniqueIndex;not null"`
+	Nome        string `json:"nome"`
+	Email       string `json:"email"`
+	FusoHorario string `json:"fuso_horario"`     // Nome IANA, ex: "America/Manaus"; vazio usa FusoPadrao
+	Gestor      string `json:"gestor,omitempty"` // Login do gestor, destinatário dos escalonamentos
+
+	// TokenCalendario é o segredo da URL do feed iCalendar do usuário; nulo enquanto não for gerado.
+	TokenCalendario *string `json:"-" gorm:"uniqueIndex"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Usuario) TableName() string {
+	return "usuarios"
+}
+
+// Localizacao retorna o fuso horário do usuário, ou FusoPadrao se não definido.
+func (u Usuario) Localizacao() (*time.Location, error) {
+	return CarregarFuso(u.FusoHorario)
+}
+
+// CarregarFuso carrega um fuso horário pelo nome IANA; o nome vazio equivale a FusoPadrao.
+func CarregarFuso(nome string) (*time.Location, error) {
+	if nome == "" {
+		nome = FusoPadrao
+	}
+	loc, err := time.LoadLocation(nome)
+	if err != nil {
+		return nil, errors.New("fuso horário inválido: " + nome)
+	}
+	return loc, nil
+}
@@ -0,0 +1,124 @@
+package usuario
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Repository define as operações básicas para manipular usuários.
+type Repository interface {
+	Adicionar(u Usuario) (Usuario, error)
+	Listar() ([]Usuario, error)
+	ObterPorID(id int) (*Usuario, error)
+	ObterPorLogin(login string) (*Usuario, error)
+	Atualizar(id int, updated Usuario) (Usuario, error)
+	Deletar(id int) error
+	Fuso(login string) (*time.Location, error)
+	GerarTokenCalendario(id int) (string, error)
+	ObterPorTokenCalendario(token string) (*Usuario, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NovoRepositorio cria e retorna um repositório baseado em GORM.
+func NovoRepositorio(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Adicionar insere um novo usuário.
+func (r *repository) Adicionar(u Usuario) (Usuario, error) {
+	u.TokenCalendario = nil
+	err := r.db.Create(&u).Error
+	return u, err
+}
+
+// Listar retorna todos os usuários ordenados pelo login.
+func (r *repository) Listar() ([]Usuario, error) {
+	var usuarios []Usuario
+	err := r.db.Order("login").Find(&usuarios).Error
+	return usuarios, err
+}
+
+// ObterPorID busca um usuário pelo ID.
+func (r *repository) ObterPorID(id int) (*Usuario, error) {
+	var u Usuario
+	if err := r.db.First(&u, id).Error; err != nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+	return &u, nil
+}
+
+// ObterPorLogin busca um usuário pelo login.
+func (r *repository) ObterPorLogin(login string) (*Usuario, error) {
+	var u Usuario
+	if err := r.db.Where("login = ?", login).First(&u).Error; err != nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+	return &u, nil
+}
+
+// Atualizar modifica os dados de um usuário existente.
+func (r *repository) Atualizar(id int, updated Usuario) (Usuario, error) {
+	var u Usuario
+	if err := r.db.First(&u, id).Error; err != nil {
+		return Usuario{}, errors.New("usuário não encontrado")
+	}
+	updated.ID = id
+	updated.TokenCalendario = nil
+	err := r.db.Model(&u).Updates(updated).Error
+	return updated, err
+}
+
+// Deletar remove um usuário pelo ID.
+func (r *repository) Deletar(id int) error {
+	return r.db.Delete(&Usuario{}, id).Error
+}
+
+// Fuso retorna o fuso horário do usuário com o login informado. Logins
+// desconhecidos (ou vazios) usam FusoPadrao.
+func (r *repository) Fuso(login string) (*time.Location, error) {
+	if login == "" {
+		return CarregarFuso("")
+	}
+	u, err := r.ObterPorLogin(login)
+	if err != nil {
+		return CarregarFuso("")
+	}
+	return u.Localizacao()
+}
+
+// GerarTokenCalendario cria um novo token secreto para o feed de calendário do
+// usuário, invalidando o anterior.
+func (r *repository) GerarTokenCalendario(id int) (string, error) {
+	var u Usuario
+	if err := r.db.First(&u, id).Error; err != nil {
+		return "", errors.New("usuário não encontrado")
+	}
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	if err := r.db.Model(&u).Update("token_calendario", token).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ObterPorTokenCalendario busca o usuário dono do token do feed de calendário.
+func (r *repository) ObterPorTokenCalendario(token string) (*Usuario, error) {
+	var u Usuario
+	if token == "" {
+		return nil, errors.New("usuário não encontrado")
+	}
+	if err := r.db.Where("token_calendario = ?", token).First(&u).Error; err != nil {
+		return nil, errors.New("usuário não encontrado")
+	}
+	return &u, nil
+}
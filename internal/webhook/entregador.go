@@ -0,0 +1,287 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Cabeçalhos enviados em cada entrega.
+const (
+	CabecalhoEvento     = "X-Webhook-Evento"
+	CabecalhoEventoID   = "X-Webhook-Evento-Id"
+	CabecalhoEntrega    = "X-Webhook-Entrega"
+	CabecalhoAssinatura = "X-Webhook-Assinatura"
+)
+
+// Config define o comportamento do entregador.
+type Config struct {
+	Timeout       time.Duration // Tempo máximo de cada requisição
+	MaxTentativas int           // Tentativas automáticas antes de a entrega falhar
+	LimiteFalhas  int           // Falhas consecutivas que desativam o webhook
+	Lote          int           // Entregas processadas por rodada
+
+	// RedesPermitidas libera destinos em redes que, por padrão, são recusadas
+	// (loopback, privadas e link-local), como os serviços da rede interna.
+	RedesPermitidas []*net.IPNet
+}
+
+// Entregador envia as entregas pendentes, com novas tentativas em intervalos
+// exponenciais, e desativa os webhooks que falham repetidamente.
+type Entregador struct {
+	db      *gorm.DB
+	cliente *http.Client
+	cfg     Config
+}
+
+// NovoEntregador cria o entregador de webhooks. Campos zerados de cfg usam os
+// padrões: 10s de timeout, 8 tentativas, desativação após 20 falhas seguidas e
+// lotes de 50 entregas.
+func NovoEntregador(db *gorm.DB, cfg Config) *Entregador {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxTentativas <= 0 {
+		cfg.MaxTentativas = 8
+	}
+	if cfg.LimiteFalhas <= 0 {
+		cfg.LimiteFalhas = 20
+	}
+	if cfg.Lote <= 0 {
+		cfg.Lote = 50
+	}
+	transporte := http.DefaultTransport.(*http.Transport).Clone()
+	// Sem proxy: o destino conferido na conexão é sempre o do webhook.
+	transporte.Proxy = nil
+	transporte.DialContext = (&net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: controlarDestino(cfg.RedesPermitidas),
+	}).DialContext
+	cliente := &http.Client{
+		Transport: transporte,
+		Timeout:   cfg.Timeout,
+		// Redirecionamentos não são seguidos: a resposta 3xx conta como falha.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	return &Entregador{db: db, cliente: cliente, cfg: cfg}
+}
+
+// controlarDestino recusa, no momento da conexão, os endereços de loopback,
+// privados, link-local e não especificados, salvo os das redes permitidas.
+// Conferir o IP já resolvido impede que um nome aponte para a rede interna.
+func controlarDestino(permitidas []*net.IPNet) func(rede, endereco string, _ syscall.RawConn) error {
+	return func(rede, endereco string, _ syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(endereco)
+		if err != nil {
+			return err
+		}
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return fmt.Errorf("destino inválido: %s", endereco)
+		}
+		for _, r := range permitidas {
+			if r.Contains(ip) {
+				return nil
+			}
+		}
+		if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+			ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+			return fmt.Errorf("destino não permitido: %s está em uma rede interna", ip)
+		}
+		return nil
+	}
+}
+
+// Assinar calcula a assinatura de uma entrega: HMAC-SHA256, com o segredo do
+// webhook, de "<timestamp>.<corpo>". O cabeçalho X-Webhook-Assinatura traz
+// "t=<timestamp>,v1=<assinatura em hexadecimal>".
+func Assinar(segredo string, timestamp int64, corpo []byte) string {
+	mac := hmac.New(sha256.New, []byte(segredo))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(corpo)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Espera calcula o intervalo até a próxima tentativa depois de n tentativas
+// com falha: 30s, 1min, 2min... limitado a 6h.
+func Espera(n int) time.Duration {
+	espera := 30 * time.Second
+	for i := 1; i < n && espera < 6*time.Hour; i++ {
+		espera *= 2
+	}
+	if espera > 6*time.Hour {
+		espera = 6 * time.Hour
+	}
+	return espera
+}
+
+// Executar processa a fila a cada intervalo até o contexto ser cancelado.
+func (e *Entregador) Executar(ctx context.Context, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+	for {
+		if n, err := e.Processar(ctx); err != nil {
+			log.Printf("Erro no envio de webhooks: %v", err)
+		} else if n > 0 {
+			log.Printf("Webhooks: %d entregas processadas", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Processar envia um lote de entregas pendentes cuja próxima tentativa já
+// venceu e retorna quantas foram processadas.
+func (e *Entregador) Processar(ctx context.Context) (int, error) {
+	var entregas []Entrega
+	agora := time.Now()
+	err := e.db.Transaction(func(tx *gorm.DB) error {
+		// SKIP LOCKED permite várias instâncias da API dividindo a mesma fila.
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND proxima_tentativa <= ?", EntregaPendente, agora).
+			Where("webhook_id IN (?)", tx.Model(&Webhook{}).Select("id").Where("ativo")).
+			Order("proxima_tentativa, id").
+			Limit(e.cfg.Lote).
+			Find(&entregas).Error
+		if err != nil || len(entregas) == 0 {
+			return err
+		}
+		ids := make([]int, len(entregas))
+		for i, en := range entregas {
+			ids[i] = en.ID
+		}
+		// Reserva as entregas durante o envio; se o processo cair no meio, elas
+		// voltam para a fila quando a reserva vencer.
+		reserva := agora.Add(time.Duration(len(entregas)+1) * e.cfg.Timeout)
+		return tx.Model(&Entrega{}).Where("id IN ?", ids).Update("proxima_tentativa", reserva).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	for i := range entregas {
+		if err := e.enviar(ctx, &entregas[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(entregas), nil
+}
+
+// EntregarAgora tenta enviar a entrega imediatamente, mesmo que já tenha sido
+// entregue, tenha falhado ou o webhook esteja desativado. É usada nos reenvios
+// manuais e nos testes.
+func (e *Entregador) EntregarAgora(ctx context.Context, webhookID, id int) (Entrega, error) {
+	var en Entrega
+	if err := e.db.Where("webhook_id = ?", webhookID).First(&en, id).Error; err != nil {
+		return Entrega{}, ErrEntregaNaoEncontrada
+	}
+	if err := e.enviar(ctx, &en); err != nil {
+		return Entrega{}, err
+	}
+	err := e.db.Preload("Historico", func(db *gorm.DB) *gorm.DB {
+		return db.Order("numero")
+	}).First(&en, id).Error
+	return en, err
+}
+
+// enviar faz uma tentativa de entrega e registra o resultado.
+func (e *Entregador) enviar(ctx context.Context, en *Entrega) error {
+	var w Webhook
+	if err := e.db.Unscoped().First(&w, en.WebhookID).Error; err != nil {
+		return err
+	}
+
+	inicio := time.Now()
+	t := Tentativa{EntregaID: en.ID, Numero: en.Tentativas + 1, Data: inicio}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(en.Payload))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "my-crm-backend-webhooks/1.0")
+		req.Header.Set(CabecalhoEvento, en.Evento)
+		req.Header.Set(CabecalhoEventoID, en.EventoID)
+		req.Header.Set(CabecalhoEntrega, strconv.Itoa(en.ID))
+		req.Header.Set(CabecalhoAssinatura, fmt.Sprintf("t=%d,v1=%s", inicio.Unix(), Assinar(w.Segredo, inicio.Unix(), en.Payload)))
+
+		var resp *http.Response
+		resp, err = e.cliente.Do(req)
+		if err == nil {
+			corpo, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+			resp.Body.Close()
+			t.StatusHTTP = resp.StatusCode
+			t.Resposta = string(corpo)
+		}
+	}
+	t.DuracaoMs = time.Since(inicio).Milliseconds()
+	if err != nil {
+		t.Erro = err.Error()
+	}
+	sucesso := err == nil && t.StatusHTTP >= 200 && t.StatusHTTP < 300
+	if err == nil && !sucesso {
+		t.Erro = fmt.Sprintf("resposta HTTP %d", t.StatusHTTP)
+	}
+
+	return e.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&t).Error; err != nil {
+			return err
+		}
+		campos := map[string]interface{}{
+			"tentativas":         t.Numero,
+			"ultimo_status_http": t.StatusHTTP,
+			"ultimo_erro":        t.Erro,
+		}
+		if sucesso {
+			campos["status"] = EntregaEntregue
+			campos["entregue_em"] = time.Now()
+			campos["proxima_tentativa"] = nil
+		} else if t.Numero >= e.cfg.MaxTentativas {
+			campos["status"] = EntregaFalhou
+			campos["proxima_tentativa"] = nil
+		} else {
+			campos["status"] = EntregaPendente
+			campos["proxima_tentativa"] = time.Now().Add(Espera(t.Numero))
+		}
+		if err := tx.Model(en).Updates(campos).Error; err != nil {
+			return err
+		}
+		return e.registrarResultado(tx, w, sucesso)
+	})
+}
+
+// registrarResultado atualiza a contagem de falhas consecutivas do webhook e o
+// desativa ao atingir o limite.
+func (e *Entregador) registrarResultado(tx *gorm.DB, w Webhook, sucesso bool) error {
+	if sucesso {
+		return tx.Model(&Webhook{}).Where("id = ? AND falhas_consecutivas <> 0", w.ID).
+			Update("falhas_consecutivas", 0).Error
+	}
+	if err := tx.Model(&Webhook{}).Where("id = ?", w.ID).
+		Update("falhas_consecutivas", gorm.Expr("falhas_consecutivas + 1")).Error; err != nil {
+		return err
+	}
+	res := tx.Model(&Webhook{}).Where("id = ? AND ativo AND falhas_consecutivas >= ?", w.ID, e.cfg.LimiteFalhas).
+		Updates(map[string]interface{}{
+			"ativo":              false,
+			"desativado_em":      time.Now(),
+			"motivo_desativacao": fmt.Sprintf("%d falhas de entrega consecutivas", e.cfg.LimiteFalhas),
+		})
+	if res.Error == nil && res.RowsAffected > 0 {
+		log.Printf("Webhook %d (%s) desativado após %d falhas consecutivas", w.ID, w.URL, e.cfg.LimiteFalhas)
+	}
+	return res.Error
+}
@@ -0,0 +1,203 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"my-crm-backend/internal/evento"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler define os manipuladores HTTP para os webhooks.
+type Handler struct {
+	repo       Repository
+	entregador *Entregador
+}
+
+// NovoHandler cria e retorna um novo handler de webhooks.
+func NovoHandler(repo Repository, entregador *Entregador) *Handler {
+	return &Handler{repo: repo, entregador: entregador}
+}
+
+// Criar cadastra um webhook.
+// Espera receber um JSON como: {"nome": "ERP", "url": "https://erp.exemplo.com/crm",
+// "eventos": ["negociacao.ganha", "negociacao.etapa_alterada"]}. O segredo da
+// assinatura é gerado quando não informado e retornado na resposta.
+func (h *Handler) Criar(c *gin.Context) {
+	var w Webhook
+	if err := c.ShouldBindJSON(&w); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := Validar(w); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	criado, err := h.repo.Adicionar(w)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, criado)
+}
+
+// Listar retorna todos os webhooks, sem os segredos.
+func (h *Handler) Listar(c *gin.Context) {
+	webhooks, err := h.repo.Listar()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range webhooks {
+		webhooks[i].Segredo = ""
+	}
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// ListarEventos retorna os eventos que podem ser assinados.
+func (h *Handler) ListarEventos(c *gin.Context) {
+	c.JSON(http.StatusOK, evento.Tipos)
+}
+
+// Obter retorna um webhook pelo ID, sem o segredo.
+func (h *Handler) Obter(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	w, err := h.repo.ObterPorID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	w.Segredo = ""
+	c.JSON(http.StatusOK, w)
+}
+
+// Atualizar altera um webhook. Enviar {"ativo": true} reativa um webhook
+// desativado por falhas.
+func (h *Handler) Atualizar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	atual, err := h.repo.ObterPorID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	// Os campos omitidos no JSON mantêm os valores atuais, inclusive "ativo":
+	// só {"ativo": false} desativa o webhook.
+	w := *atual
+	w.Segredo = ""
+	if err := c.ShouldBindJSON(&w); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := Validar(w); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	atualizado, err := h.repo.Atualizar(id, w)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	atualizado.Segredo = ""
+	c.JSON(http.StatusOK, atualizado)
+}
+
+// Deletar remove um webhook.
+func (h *Handler) Deletar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	if err := h.repo.Deletar(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Testar envia imediatamente o evento webhook.teste e retorna a entrega com o
+// resultado da tentativa.
+func (h *Handler) Testar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	en, err := h.repo.CriarTeste(id)
+	if errors.Is(err, ErrWebhookNaoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.responderEntrega(c, id, en.ID)
+}
+
+// ListarEntregas retorna as últimas entregas do webhook: GET /api/webhooks/1/entregas?status=falhou
+func (h *Handler) ListarEntregas(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	entregas, err := h.repo.ListarEntregas(id, c.Query("status"))
+	if errors.Is(err, ErrWebhookNaoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entregas)
+}
+
+// ObterEntrega retorna uma entrega com o histórico de tentativas e os códigos de resposta.
+func (h *Handler) ObterEntrega(c *gin.Context) {
+	id, errID := strconv.Atoi(c.Param("id"))
+	entregaID, errEntrega := strconv.Atoi(c.Param("entregaId"))
+	if errID != nil || errEntrega != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	en, err := h.repo.ObterEntrega(id, entregaID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, en)
+}
+
+// Reenviar tenta entregar novamente, na hora, uma entrega do webhook. Se
+// falhar, a entrega volta à fila de novas tentativas enquanto houver tentativas.
+func (h *Handler) Reenviar(c *gin.Context) {
+	id, errID := strconv.Atoi(c.Param("id"))
+	entregaID, errEntrega := strconv.Atoi(c.Param("entregaId"))
+	if errID != nil || errEntrega != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	h.responderEntrega(c, id, entregaID)
+}
+
+func (h *Handler) responderEntrega(c *gin.Context, webhookID, id int) {
+	en, err := h.entregador.EntregarAgora(c.Request.Context(), webhookID, id)
+	if errors.Is(err, ErrEntregaNaoEncontrada) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, en)
+}
@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Os eventos assináveis são os eventos de domínio (evento.Tipos).
+const (
+	// EventoTeste é enviado apenas por Testar, independentemente da assinatura.
+	EventoTeste = "webhook.teste"
+
+	// TodosEventos assina todos os eventos, inclusive os criados no futuro.
+	TodosEventos = "*"
+)
+
+// Webhook é a assinatura de um sistema externo: os eventos assinados são
+// enviados por POST para a URL, assinados com HMAC-SHA256 usando o Segredo.
+type Webhook struct {
+	ID        int                         `json:"id" gorm:"primaryKey;autoIncrement"`
+	Nome      string                      `json:"nome"`
+	URL       string                      `json:"url" gorm:"not null"`
+	Eventos   datatypes.JSONSlice[string] `json:"eventos"`
+	Segredo   string                      `json:"segredo,omitempty"` // Gerado na criação quando não informado; só é retornado na criação
+	Ativo     bool                        `json:"ativo" gorm:"not null;default:true"`
+	Descricao string                      `json:"descricao,omitempty"`
+
+	// Tentativas de entrega que falharam em sequência; ao atingir o limite do
+	// entregador o webhook é desativado.
+	FalhasConsecutivas int        `json:"falhas_consecutivas"`
+	DesativadoEm       *time.Time `json:"desativado_em,omitempty"`
+	MotivoDesativacao  string     `json:"motivo_desativacao,omitempty"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// Situações de uma entrega.
+const (
+	EntregaPendente = "pendente" // Aguardando a primeira tentativa ou uma nova tentativa
+	EntregaEntregue = "entregue" // O destino respondeu com 2xx
+	EntregaFalhou   = "falhou"   // Esgotou as tentativas; pode ser reenviada manualmente
+)
+
+// Entrega é o envio de um evento para um webhook. O mesmo EventoID (o ID do
+// evento de domínio) é usado em todas as entregas do evento, para que o destino
+// descarte duplicatas.
+type Entrega struct {
+	ID               int            `json:"id" gorm:"primaryKey;autoIncrement"`
+	WebhookID        int            `json:"webhook_id" gorm:"index;not null"`
+	EventoID         string         `json:"evento_id" gorm:"size:32;index"`
+	Evento           string         `json:"evento"`
+	Payload          datatypes.JSON `json:"payload"`
+	Status           string         `json:"status" gorm:"index:idx_entrega_fila,priority:1"`
+	ProximaTentativa *time.Time     `json:"proxima_tentativa,omitempty" gorm:"index:idx_entrega_fila,priority:2"`
+	Tentativas       int            `json:"tentativas"`
+	UltimoStatusHTTP int            `json:"ultimo_status_http,omitempty"`
+	UltimoErro       string         `json:"ultimo_erro,omitempty"`
+	EntregueEm       *time.Time     `json:"entregue_em,omitempty"`
+	Historico        []Tentativa    `json:"historico,omitempty" gorm:"foreignKey:EntregaID"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Entrega) TableName() string {
+	return "webhook_entregas"
+}
+
+// Tentativa registra o resultado de uma tentativa de entrega.
+type Tentativa struct {
+	ID         int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	EntregaID  int       `json:"entrega_id" gorm:"index;not null"`
+	Numero     int       `json:"numero"`
+	StatusHTTP int       `json:"status_http,omitempty"` // Zero quando não houve resposta
+	Resposta   string    `json:"resposta,omitempty"`    // Início do corpo da resposta
+	Erro       string    `json:"erro,omitempty"`
+	DuracaoMs  int64     `json:"duracao_ms"`
+	Data       time.Time `json:"data"`
+}
+
+// TableName retorna o nome da tabela que o GORM deverá usar.
+func (Tentativa) TableName() string {
+	return "webhook_tentativas"
+}
@@ -0,0 +1,232 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"my-crm-backend/internal/evento"
+
+	"gorm.io/gorm"
+)
+
+// Erros de busca de webhooks e entregas.
+var (
+	ErrWebhookNaoEncontrado = errors.New("webhook não encontrado")
+	ErrEntregaNaoEncontrada = errors.New("entrega não encontrada")
+)
+
+// Repository define as operações sobre as assinaturas de webhooks e suas entregas.
+type Repository interface {
+	Adicionar(w Webhook) (Webhook, error)
+	Listar() ([]Webhook, error)
+	ObterPorID(id int) (*Webhook, error)
+	Atualizar(id int, updated Webhook) (Webhook, error)
+	Deletar(id int) error
+	ListarEntregas(webhookID int, status string) ([]Entrega, error)
+	ObterEntrega(webhookID, id int) (*Entrega, error)
+	CriarTeste(webhookID int) (Entrega, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NovoRepositorio cria e retorna um repositório baseado em GORM.
+func NovoRepositorio(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Validar confere a URL e os eventos assinados.
+func Validar(w Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url inválida: %q (use uma URL http ou https)", w.URL)
+	}
+	if len(w.Eventos) == 0 {
+		return errors.New("informe ao menos um evento")
+	}
+	for _, e := range w.Eventos {
+		if e != TodosEventos && !evento.TipoValido(e) {
+			return fmt.Errorf("evento inválido: %q", e)
+		}
+	}
+	return nil
+}
+
+// novoID gera um identificador aleatório em hexadecimal com n bytes.
+func novoID(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Adicionar cria o webhook ativo, gerando o segredo quando não informado.
+func (r *repository) Adicionar(w Webhook) (Webhook, error) {
+	if w.Segredo == "" {
+		segredo, err := novoID(32)
+		if err != nil {
+			return Webhook{}, err
+		}
+		w.Segredo = segredo
+	}
+	w.ID = 0
+	w.Ativo = true
+	w.FalhasConsecutivas = 0
+	w.DesativadoEm = nil
+	w.MotivoDesativacao = ""
+	err := r.db.Create(&w).Error
+	return w, err
+}
+
+// Listar retorna todos os webhooks.
+func (r *repository) Listar() ([]Webhook, error) {
+	var webhooks []Webhook
+	err := r.db.Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// ObterPorID busca um webhook pelo ID.
+func (r *repository) ObterPorID(id int) (*Webhook, error) {
+	var w Webhook
+	if err := r.db.First(&w, id).Error; err != nil {
+		return nil, ErrWebhookNaoEncontrado
+	}
+	return &w, nil
+}
+
+// Atualizar altera nome, URL, eventos, descrição e situação do webhook. Um
+// segredo vazio mantém o atual. Reativar o webhook zera a contagem de falhas.
+func (r *repository) Atualizar(id int, updated Webhook) (Webhook, error) {
+	var w Webhook
+	if err := r.db.First(&w, id).Error; err != nil {
+		return Webhook{}, ErrWebhookNaoEncontrado
+	}
+	campos := []string{"nome", "url", "eventos", "descricao", "ativo"}
+	if updated.Segredo != "" {
+		campos = append(campos, "segredo")
+	}
+	if updated.Ativo && !w.Ativo {
+		updated.FalhasConsecutivas = 0
+		updated.DesativadoEm = nil
+		updated.MotivoDesativacao = ""
+		campos = append(campos, "falhas_consecutivas", "desativado_em", "motivo_desativacao")
+	}
+	if err := r.db.Model(&w).Select(campos).Updates(updated).Error; err != nil {
+		return Webhook{}, err
+	}
+	err := r.db.First(&w, id).Error
+	return w, err
+}
+
+// Deletar remove o webhook. As entregas pendentes deixam de ser enviadas.
+func (r *repository) Deletar(id int) error {
+	return r.db.Delete(&Webhook{}, id).Error
+}
+
+// ListarEntregas retorna as últimas entregas do webhook, das mais recentes para
+// as mais antigas, opcionalmente filtradas pela situação.
+func (r *repository) ListarEntregas(webhookID int, status string) ([]Entrega, error) {
+	if _, err := r.ObterPorID(webhookID); err != nil {
+		return nil, err
+	}
+	entregas := []Entrega{}
+	query := r.db.Where("webhook_id = ?", webhookID).Order("id DESC").Limit(200)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&entregas).Error
+	return entregas, err
+}
+
+// ObterEntrega busca uma entrega do webhook com o histórico de tentativas.
+func (r *repository) ObterEntrega(webhookID, id int) (*Entrega, error) {
+	var e Entrega
+	err := r.db.Preload("Historico", func(db *gorm.DB) *gorm.DB {
+		return db.Order("numero")
+	}).Where("webhook_id = ?", webhookID).First(&e, id).Error
+	if err != nil {
+		return nil, ErrEntregaNaoEncontrada
+	}
+	return &e, nil
+}
+
+// CriarTeste cria uma entrega do evento de teste para o webhook, mesmo que ele
+// esteja desativado ou não assine nenhum evento.
+func (r *repository) CriarTeste(webhookID int) (Entrega, error) {
+	w, err := r.ObterPorID(webhookID)
+	if err != nil {
+		return Entrega{}, err
+	}
+	id, err := novoID(8)
+	if err != nil {
+		return Entrega{}, err
+	}
+	dados, err := json.Marshal(map[string]interface{}{"webhook_id": w.ID, "mensagem": "Entrega de teste"})
+	if err != nil {
+		return Entrega{}, err
+	}
+	entregas, err := novasEntregas([]Webhook{*w}, "teste-"+id, EventoTeste, time.Now(), dados)
+	if err != nil {
+		return Entrega{}, err
+	}
+	err = r.db.Create(&entregas[0]).Error
+	return entregas[0], err
+}
+
+// Envelope é o corpo JSON enviado em todas as entregas.
+type Envelope struct {
+	ID       string          `json:"id"` // Identificador do evento, igual em todas as entregas dele
+	Evento   string          `json:"evento"`
+	CriadoEm time.Time       `json:"criado_em"`
+	Dados    json.RawMessage `json:"dados"`
+}
+
+// Disparar enfileira o evento de domínio para todos os webhooks ativos que o
+// assinam, usando a conexão (ou transação) informada. É registrado como
+// assinante do barramento de eventos; o envio é feito pelo Entregador.
+func Disparar(tx *gorm.DB, e evento.Evento) error {
+	var webhooks []Webhook
+	err := tx.Where("ativo AND (eventos @> ?::jsonb OR eventos @> ?::jsonb)",
+		fmt.Sprintf("[%q]", e.Tipo), fmt.Sprintf("[%q]", TodosEventos)).
+		Find(&webhooks).Error
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+	entregas, err := novasEntregas(webhooks, strconv.Itoa(e.ID), e.Tipo, e.CreatedAt, json.RawMessage(e.Dados))
+	if err != nil {
+		return err
+	}
+	return tx.Create(&entregas).Error
+}
+
+// novasEntregas monta as entregas pendentes de um evento, uma por webhook.
+func novasEntregas(webhooks []Webhook, id, tipo string, criadoEm time.Time, dados json.RawMessage) ([]Entrega, error) {
+	if len(dados) == 0 {
+		dados = json.RawMessage("null")
+	}
+	payload, err := json.Marshal(Envelope{ID: id, Evento: tipo, CriadoEm: criadoEm, Dados: dados})
+	if err != nil {
+		return nil, err
+	}
+	agora := time.Now()
+	entregas := make([]Entrega, 0, len(webhooks))
+	for _, w := range webhooks {
+		entregas = append(entregas, Entrega{
+			WebhookID:        w.ID,
+			EventoID:         id,
+			Evento:           tipo,
+			Payload:          payload,
+			Status:           EntregaPendente,
+			ProximaTentativa: &agora,
+		})
+	}
+	return entregas, nil
+}